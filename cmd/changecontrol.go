@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// changeControlStatusFilter est le flag CLI `--status` de `get changecontrols`.
+var changeControlStatusFilter string
+
+// changeControlSince est le flag CLI `--since` de `get changecontrols` (ex : "7d").
+var changeControlSince string
+
+// getChangeControlsCmd liste les change controls, du plus récemment actif au plus
+// ancien, avec leur état et leur approbation.
+var getChangeControlsCmd = &cobra.Command{
+	Use:   "changecontrols",
+	Short: "Afficher les change controls",
+	Run: func(cmd *cobra.Command, args []string) {
+		var since time.Time
+		if changeControlSince != "" {
+			var err error
+			since, err = internal.ParseSince(changeControlSince, time.Now())
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		changeControls, err := internal.ListChangeControls(ctx, conn, changeControlStatusFilter, since)
+		if err != nil {
+			fmt.Printf("❌ Erreur lecture des change controls : %v\n", err)
+			os.Exit(1)
+		}
+		if jsonOutput() {
+			if changeControls == nil {
+				changeControls = []internal.ChangeControlInfo{}
+			}
+			printJSON(changeControls)
+			return
+		}
+		if len(changeControls) == 0 {
+			fmt.Println("ℹ️  Aucun change control")
+			return
+		}
+		for _, cc := range changeControls {
+			line := fmt.Sprintf("%s %s (%s) - %s — %s", changeControlSymbols[cc.Status], cc.Name, cc.ID, cc.Status,
+				cc.UpdatedAt.Local().Format("2006-01-02 15:04"))
+			if cc.Approval != nil {
+				line += ", approuvé par " + cc.Approval.User
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+// getChangeControlCmd affiche le détail d'un change control : état, auteur, approbation,
+// démarrage, arbre des étapes et actions regroupées par device.
+var getChangeControlCmd = &cobra.Command{
+	Use:   "changecontrol <id>",
+	Short: "Afficher le détail d'un change control",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		cc, err := internal.GetChangeControlInfo(ctx, conn, args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if jsonOutput() {
+			printJSON(cc)
+			return
+		}
+		printChangeControl(cc)
+	},
+}
+
+// changeControlSymbols associe un symbole à chaque état de change control ou d'étape.
+var changeControlSymbols = map[string]string{
+	internal.ChangeControlPending:   "⏸️ ",
+	internal.ChangeControlRunning:   "⏳",
+	internal.ChangeControlCompleted: "✅",
+	internal.ChangeControlFailed:    "❌",
+}
+
+// printChangeControl affiche le détail d'un change control.
+func printChangeControl(cc *internal.ChangeControlInfo) {
+	const layout = "2006-01-02 15:04:05"
+	fmt.Printf("📋 %s (%s)\n", cc.Name, cc.ID)
+	fmt.Printf("   État : %s %s\n", changeControlSymbols[cc.Status], cc.Status)
+	if cc.Error != "" {
+		fmt.Printf("   Erreur : %s\n", cc.Error)
+	}
+	if cc.Notes != "" {
+		fmt.Printf("   Notes : %s\n", cc.Notes)
+	}
+	if cc.ModifiedBy != "" {
+		fmt.Printf("   Modifié par %s le %s\n", cc.ModifiedBy, cc.ModifiedAt.Local().Format(layout))
+	}
+	if cc.Approval != nil {
+		fmt.Printf("   Approuvé par %s le %s%s\n", cc.Approval.User, cc.Approval.Time.Local().Format(layout), flagNotes(cc.Approval))
+	} else {
+		fmt.Println("   Non approuvé")
+	}
+	if cc.Start != nil {
+		fmt.Printf("   Démarré par %s le %s%s\n", cc.Start.User, cc.Start.Time.Local().Format(layout), flagNotes(cc.Start))
+	}
+	if cc.Root == nil {
+		return
+	}
+
+	fmt.Println("🧩 Étapes :")
+	printStage(cc.Root, 1, 0)
+
+	byDevice := map[string][]*internal.ChangeControlStage{}
+	collectDeviceStages(cc.Root, byDevice)
+	if len(byDevice) == 0 {
+		return
+	}
+	devices := make([]string, 0, len(byDevice))
+	for device := range byDevice {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	fmt.Println("📟 Actions par device :")
+	for _, device := range devices {
+		var actions []string
+		for _, s := range byDevice[device] {
+			actions = append(actions, fmt.Sprintf("%s %s", changeControlSymbols[s.Status], s.Action))
+		}
+		fmt.Printf("   %s : %s\n", device, strings.Join(actions, ", "))
+	}
+}
+
+// flagNotes retourne les notes d'une approbation ou d'un démarrage, entre parenthèses.
+func flagNotes(f *internal.ChangeControlFlag) string {
+	if f.Notes == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", f.Notes)
+}
+
+// printStage affiche une étape puis ses sous-étapes, indentées sous elle et numérotées
+// par rangée : les étapes d'un même numéro s'exécutent en parallèle.
+func printStage(s *internal.ChangeControlStage, depth, row int) {
+	line := strings.Repeat("   ", depth)
+	if row > 0 {
+		line += fmt.Sprintf("%d. ", row)
+	}
+	name := s.Name
+	if name == "" {
+		name = s.ID
+	}
+	line += changeControlSymbols[s.Status] + " " + name
+	if s.Action != "" {
+		line += " — " + s.Action
+		if s.DeviceID != "" {
+			line += " sur " + stageDevice(s)
+		}
+	}
+	if s.StartTime != nil {
+		line += " (" + s.StartTime.Local().Format("15:04:05")
+		if s.EndTime != nil {
+			line += " → " + s.EndTime.Local().Format("15:04:05")
+		}
+		line += ")"
+	}
+	if s.Error != "" {
+		line += " : " + s.Error
+	}
+	fmt.Println(line)
+	for i, children := range s.Rows {
+		for _, child := range children {
+			printStage(child, depth+1, i+1)
+		}
+	}
+}
+
+// stageDevice retourne le hostname du device d'une étape, ou son ID s'il est inconnu.
+func stageDevice(s *internal.ChangeControlStage) string {
+	if s.Hostname != "" {
+		return s.Hostname
+	}
+	return s.DeviceID
+}
+
+// collectDeviceStages regroupe par device les étapes portant une action sur un device.
+func collectDeviceStages(s *internal.ChangeControlStage, byDevice map[string][]*internal.ChangeControlStage) {
+	if s.DeviceID != "" {
+		device := stageDevice(s)
+		byDevice[device] = append(byDevice[device], s)
+	}
+	for _, children := range s.Rows {
+		for _, child := range children {
+			collectDeviceStages(child, byDevice)
+		}
+	}
+}
+
+// init configure les flags de `get changecontrols` et attache les deux commandes à `get`.
+func init() {
+	getChangeControlsCmd.Flags().StringVar(&changeControlStatusFilter, "status", "", "Filtrer par état (pending, running, completed, failed)")
+	getChangeControlsCmd.Flags().StringVar(&changeControlSince, "since", "", "Seulement les change controls actifs depuis (ex: 7d, 12h, 2025-06-01)")
+	getCmd.AddCommand(getChangeControlsCmd, getChangeControlCmd)
+}
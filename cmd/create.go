@@ -2,21 +2,43 @@ package cmd
 
 import (
 	// "bufio"
+	"context"
 	"cvaas_cli/internal"
 	"fmt"
 	"os"
+	"slices"
 	// "strings"
-	"time"
-	"path/filepath"
-	"gopkg.in/yaml.v2"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 // workspaceName est un flag CLI utilisé pour spécifier le nom du workspace à créer
 // via la commande `create workspace`. Ce champ est obligatoire.
 var workspaceName string
 
+// workspaceIDFlag est un flag CLI permettant d'imposer l'ID du workspace à créer.
+// Par défaut, un UUID est généré.
+var workspaceIDFlag string
+
+// requestIDFlag est un flag CLI permettant d'imposer le requestID de la création.
+// Réutiliser le même requestID permet de rejouer une création interrompue.
+var requestIDFlag string
+
+// workspaceDescription est un flag CLI contenant la description du workspace à créer.
+var workspaceDescription string
+
+// templateFile est un flag CLI donnant le template de workspace dont les opérations
+// sont appliquées dans le workspace créé.
+var templateFile string
+
+// templateSets contient les affectations `--set clé=valeur` des variables du template.
+var templateSets []string
+
+// ifNotExists est un flag CLI indiquant que la commande doit réussir sans rien créer
+// si un workspace de même ID, ou de même nom et encore modifiable, existe déjà.
+var ifNotExists bool
+
 // createCmd est la commande principale `create` du CLI, utilisée pour créer
 // des ressources sur la plateforme CVaaS (comme des workspaces).
 var createCmd = &cobra.Command{
@@ -24,96 +46,195 @@ var createCmd = &cobra.Command{
 	Short: "Créer des ressources dans cvaas-cli",
 }
 
-// WorkspaceEntry représente un enregistrement unique d'un workspace CVaaS,
-// utilisé pour la tracabilité dans un fichier YAML local.
-type WorkspaceEntry struct {
-	WorkspaceID   string `yaml:"workspaceID"`
-	RequestID     string `yaml:"RequestID"`
-	WorkspaceName string `yaml:"workspaceName"`
-}
-
-// WorkspaceYAML est une structure regroupant plusieurs workspaces,
-// utilisée pour sérialiser et désérialiser les données dans un fichier YAML.
-type WorkspaceYAML struct {
-	Workspace []WorkspaceEntry `yaml:"workspace"`
-}
-
 // createWorkspaceCmd est une sous-commande de `create` permettant de créer
 // un nouveau workspace sur la plateforme CVaaS.
 //
-// La commande génère automatiquement un ID de workspace et un requestID,
-// appelle l'API via gRPC, puis enregistre les métadonnées dans un fichier YAML local.
+// La commande génère automatiquement un ID de workspace et un requestID (UUID),
+// vérifie qu'aucun workspace de même ID ou de même nom n'existe, appelle l'API via gRPC,
+// attend que le WorkspaceService confirme la création, puis enregistre les métadonnées
+// dans un fichier YAML local. Relancer la commande avec les mêmes --id et --request-id
+// reprend la création au lieu de la refuser.
 //
 // Flag requis :
 //   --name : nom du workspace à créer.
 //
+// Flags optionnels :
+//   --id, --request-id : identifiants à utiliser à la place des UUID générés.
+//   --description : description du workspace.
+//   --if-not-exists : ne rien faire (sans erreur) si le workspace existe déjà.
+//   --template, --set : template de workspace et valeurs de ses variables ; les
+//     opérations rendues (tags, assignations, inputs de studios) sont appliquées
+//     dans le workspace créé. Le nom peut alors être fourni par le template.
+//
 // Fichier local :
-//   Les données du workspace sont stockées dans `data/workspace.yaml`.
-//   Si le fichier ou le dossier n'existent pas, ils seront créés automatiquement.
+//   Les données du workspace sont stockées dans le registre local
+//   (`$XDG_STATE_HOME/cvaas-cli/workspace.yaml`), voir `workspace registry`.
 //
 // Panique / erreurs gérées :
 //   - Si le nom du workspace n'est pas fourni
-//   - Si une erreur survient lors de l'appel gRPC, de la sérialisation YAML,
-//     ou de l'écriture dans le système de fichiers
+//   - Si une erreur survient lors de l'appel gRPC ou de l'écriture du registre local
 var createWorkspaceCmd = &cobra.Command{
 	Use:   "workspace",
-	Short: "Créer un workspace",
+	Short: "Créer un workspace, éventuellement à partir d'un template",
 	Run: func(cmd *cobra.Command, args []string) {
+		var body *internal.TemplateBody
+		if templateFile != "" {
+			body = renderTemplate(templateFile, templateSets)
+			if workspaceName == "" {
+				workspaceName = body.Name
+			}
+			if workspaceDescription == "" {
+				workspaceDescription = body.Description
+			}
+		}
 		if workspaceName == "" {
 			fmt.Println("❌ Veuillez spécifier un nom avec --name")
 			os.Exit(1)
 		}
 
-		workspaceID := fmt.Sprintf("ws-%d", time.Now().Unix())
-		requestID := workspaceID
-
 		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
 		defer cancel()
 		defer conn.Close()
 
-		fmt.Printf("🆔 Workspace ID généré : %s\n", workspaceID)
-		internal.CreateWorkspace(ctx, conn, workspaceID, requestID, workspaceName)
-
-
-		entry := WorkspaceEntry{
-			WorkspaceID:   workspaceID,
-			RequestID:     requestID,
-			WorkspaceName: workspaceName,
+		var changes internal.WorkspaceChanges
+		if body != nil {
+			var err error
+			devices := internal.ReadInventory(ctx, conn, "", false, false)
+			var tags map[string]map[string][]string
+			if body.UsesTagQuery() {
+				tags = internal.MainlineDeviceTags(ctx, conn, devices)
+			}
+			changes, err = body.Changes(devices, tags)
+			if err != nil {
+				fmt.Printf("❌ Template : %v\n", err)
+				os.Exit(1)
+			}
 		}
 
-		yamlPath := filepath.Join("data", "workspace.yaml")
-
-		var workspaceFile WorkspaceYAML
-		if content, err := os.ReadFile(yamlPath); err == nil {
-			_ = yaml.Unmarshal(content, &workspaceFile)
+		existing := internal.FindWorkspace(ctx, conn, workspaceIDFlag, workspaceName)
+		if existing != nil && isRetriedCreation(*existing, workspaceIDFlag, requestIDFlag) {
+			fmt.Printf("🔁 Reprise de la création du workspace %s (requestID %s)\n", existing.ID, requestIDFlag)
+			existing = nil
 		}
-
-		workspaceFile.Workspace = append(workspaceFile.Workspace, entry)
-
-		savedData, err := yaml.Marshal(&workspaceFile)
-		if err != nil {
-			fmt.Printf("❌ Erreur encodage YAML : %v\n", err)
-			return
+		if existing != nil {
+			if ifNotExists {
+				fmt.Printf("ℹ️  Workspace déjà existant : %s (%s) - State: %s\n", existing.DisplayName, existing.ID, existing.State)
+				return
+			}
+			fmt.Printf("❌ Un workspace existe déjà : %s (%s). Utilisez --if-not-exists pour l'ignorer.\n", existing.DisplayName, existing.ID)
+			os.Exit(1)
 		}
 
-		if err := os.MkdirAll("data", os.ModePerm); err != nil {
-			fmt.Printf("❌ Erreur création dossier data : %v\n", err)
+		if body == nil {
+			newWorkspace(ctx, conn, workspaceIDFlag, requestIDFlag, workspaceName, workspaceDescription)
 			return
 		}
+		populateWorkspace(ctx, conn, workspaceIDFlag, requestIDFlag, workspaceName, workspaceDescription, changes, nil)
+	},
+}
 
-		if err := os.WriteFile(yamlPath, savedData, 0644); err != nil {
-			fmt.Printf("❌ Erreur écriture workspace.yaml : %v\n", err)
-			return
+// isRetriedCreation indique si le workspace existant est celui d'une précédente
+// invocation avec les mêmes --id et --request-id : CVaaS a répondu à ce requestID, ou
+// le registre local l'associe au workspace. La création est alors rejouée (sans effet
+// côté CVaaS) au lieu d'être refusée.
+func isRetriedCreation(existing internal.WorkspaceInfo, workspaceID, requestID string) bool {
+	if workspaceID == "" || requestID == "" || existing.ID != workspaceID {
+		return false
+	}
+	if slices.Contains(existing.RequestIDs, requestID) {
+		return true
+	}
+	registry, err := internal.LoadRegistry()
+	if err != nil {
+		return false
+	}
+	for _, e := range registry.Workspace {
+		if e.WorkspaceID == workspaceID && e.RequestID == requestID {
+			return true
 		}
+	}
+	return false
+}
 
-		fmt.Println("✅ Workspace sauvegardé dans data/workspace.yaml")
-	},
+// renderTemplate charge un template de workspace, valide les variables `--set` et
+// retourne les opérations rendues. La commande s'arrête en erreur au premier problème.
+func renderTemplate(path string, sets []string) *internal.TemplateBody {
+	tmpl, err := internal.LoadTemplate(path)
+	if err != nil {
+		fmt.Printf("❌ Template : %v\n", err)
+		os.Exit(1)
+	}
+	values, err := internal.ParseSetFlags(sets)
+	if err == nil {
+		values, err = tmpl.Validate(values)
+	}
+	if err != nil {
+		fmt.Printf("❌ Variables du template : %v\n", err)
+		os.Exit(1)
+	}
+	body, err := tmpl.Render(values)
+	if err != nil {
+		fmt.Printf("❌ Rendu du template : %v\n", err)
+		os.Exit(1)
+	}
+	return &body
+}
+
+// newWorkspace crée un workspace, attend que le WorkspaceService le reflète, puis
+// l'enregistre dans le registre local sous le profil courant, en affichant chaque étape.
+// Un ID ou un requestID vide est remplacé par un UUID.
+//
+// Une erreur d'écriture du registre est signalée sans interrompre la commande :
+// le workspace existe déjà sur CVaaS.
+func newWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID, name, description string) internal.WorkspaceInfo {
+	if workspaceID == "" {
+		workspaceID = internal.NewUUID()
+	}
+	if requestID == "" {
+		requestID = internal.NewUUID()
+	}
+	fmt.Printf("🆔 Workspace ID : %s (requestID : %s)\n", workspaceID, requestID)
+	created, err := createAndRecordWorkspace(ctx, conn, workspaceID, requestID, name, description)
+	fmt.Printf("🧪 %s (%s) - State: %s\n", created.DisplayName, created.ID, created.State)
+	if err != nil {
+		fmt.Printf("❌ Erreur écriture du registre des workspaces : %v\n", err)
+		return created
+	}
+	path, _ := internal.RegistryPath()
+	fmt.Printf("✅ Workspace enregistré dans %s\n", path)
+	return created
+}
+
+// createAndRecordWorkspace crée un workspace, attend qu'il soit visible puis
+// l'enregistre dans le registre local. Seule la confirmation de CreateWorkspace est
+// affichée. L'erreur retournée ne concerne que l'écriture du registre ; les erreurs
+// gRPC provoquent une panique.
+func createAndRecordWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID, name, description string) (internal.WorkspaceInfo, error) {
+	internal.CreateWorkspace(ctx, conn, workspaceID, requestID, name, description)
+	created := internal.WaitForWorkspace(ctx, conn, workspaceID, requestID)
+	err := internal.RecordWorkspace(internal.WorkspaceEntry{
+		WorkspaceID:   workspaceID,
+		RequestID:     requestID,
+		WorkspaceName: name,
+		Profile:       profileName,
+		State:         created.State,
+		CreatedAt:     created.CreatedAt,
+	})
+	return created, err
 }
 
-// init configure la commande `create workspace` avec son flag obligatoire `--name`,
-// l'attache à la commande `create`, puis enregistre `create` dans la racine du CLI.
+// init configure la commande `create workspace` avec son flag obligatoire `--name`
+// et ses flags optionnels, l'attache à la commande `create`, puis enregistre `create`
+// dans la racine du CLI.
 func init() {
 	createWorkspaceCmd.Flags().StringVar(&workspaceName, "name", "", "Nom du workspace à créer (obligatoire)")
+	createWorkspaceCmd.Flags().StringVar(&workspaceIDFlag, "id", "", "ID du workspace (UUID généré par défaut)")
+	createWorkspaceCmd.Flags().StringVar(&requestIDFlag, "request-id", "", "requestID de la création (UUID généré par défaut)")
+	createWorkspaceCmd.Flags().StringVar(&workspaceDescription, "description", "", "Description du workspace")
+	createWorkspaceCmd.Flags().StringVar(&templateFile, "template", "", "Template de workspace (opérations à appliquer)")
+	createWorkspaceCmd.Flags().StringArrayVar(&templateSets, "set", nil, "Valeur d'une variable du template (clé=valeur, répétable)")
+	createWorkspaceCmd.Flags().BoolVar(&ifNotExists, "if-not-exists", false, "Ne rien faire si un workspace de même ID ou nom existe déjà")
+	createWorkspaceCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec du template, laisser en place le workspace créé")
 	createCmd.AddCommand(createWorkspaceCmd)
 	rootCmd.AddCommand(createCmd)
 }
\ No newline at end of file
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// sitesFile est un flag CLI donnant le fichier CSV de `create workspaces`.
+var sitesFile string
+
+// bulkWorkers est un flag CLI bornant le nombre de workspaces créés en parallèle.
+var bulkWorkers int
+
+// bulkTimeout est la durée maximale de `create workspaces`, toutes lignes comprises.
+var bulkTimeout time.Duration
+
+// bulkResult est le résultat de la création du workspace d'une ligne du CSV.
+type bulkResult struct {
+	Line        int    `json:"line"`
+	Name        string `json:"name"`
+	WorkspaceID string `json:"workspaceId,omitempty"`
+	// Run est l'ID du run journalisé de la ligne (voir `run resume`).
+	Run         string `json:"run,omitempty"`
+	Tags        int    `json:"tags"`
+	Assignments int    `json:"assignments"`
+	Error       string `json:"error,omitempty"`
+	// Warning signale un échec sans incidence sur le workspace créé (ex : registre local).
+	Warning string `json:"warning,omitempty"`
+	// Rollback est le rapport de compensation d'une ligne en échec.
+	Rollback []internal.UndoReport `json:"rollback,omitempty"`
+}
+
+// createWorkspacesCmd crée un workspace par ligne d'un fichier CSV (colonnes name,
+// description, tags, devices, query), avec un nombre borné de créations simultanées.
+//
+// Chaque workspace est enregistré dans le registre local, puis reçoit les tags de
+// sa ligne assignés aux équipements sélectionnés. Chaque ligne est un run journalisé,
+// repris par `run resume`. Un tableau récapitulatif donne le résultat de chaque ligne ;
+// la commande se termine en erreur si une ligne a échoué. Le workspace d'une ligne en
+// échec est abandonné, sauf avec --keep-on-failure.
+var createWorkspacesCmd = &cobra.Command{
+	Use:   "workspaces",
+	Short: "Créer des workspaces en masse à partir d'un fichier CSV",
+	Run: func(cmd *cobra.Command, args []string) {
+		if sitesFile == "" {
+			fmt.Println("❌ Veuillez spécifier un fichier avec --from")
+			os.Exit(1)
+		}
+		if bulkWorkers < 1 {
+			fmt.Println("❌ --workers doit être supérieur ou égal à 1")
+			os.Exit(1)
+		}
+		rows, err := internal.ReadSitesCSV(sitesFile)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, bulkTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		devices := internal.ReadInventory(ctx, conn, "", false, false)
+		var tags map[string]map[string][]string
+		for _, row := range rows {
+			if row.Query != "" {
+				tags = internal.MainlineDeviceTags(ctx, conn, devices)
+				break
+			}
+		}
+		results := make([]bulkResult, len(rows))
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < bulkWorkers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i] = createSiteWorkspace(ctx, conn, rows[i], devices, tags)
+				}
+			}()
+		}
+		for i := range rows {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		failed := printBulkResults(results)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// createSiteWorkspace crée et remplit le workspace d'une ligne du CSV, dans un run
+// journalisé (voir internal.ChangesPlaybook) dont les étapes sont exécutées sans
+// affichage. Les paniques des appels CVaaS sont converties en erreur de ligne pour ne
+// pas interrompre les autres. Les opérations d'une ligne en échec sont compensées, sauf
+// avec --keep-on-failure.
+func createSiteWorkspace(ctx context.Context, conn *grpc.ClientConn, row internal.SiteRow, devices []internal.DeviceInfo, tags map[string]map[string][]string) bulkResult {
+	result := bulkResult{Line: row.Line, Name: row.Name}
+	changes, err := row.Changes(devices, tags)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	playbook, vars, err := internal.ChangesPlaybook(fmt.Sprintf("create workspaces (ligne %d)", row.Line), row.Name, row.Description, changes, false, false)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	journal, err := internal.NewRunJournal(sitesFile, playbook, vars, profileName)
+	if err != nil {
+		result.Error = fmt.Sprintf("journal : %v", err)
+		return result
+	}
+	defer journal.Unlock()
+	result.Run = journal.ID
+
+	compensator := internal.NewCompensator()
+	ctx = internal.WithCompensator(ctx, compensator)
+	run := internal.NewPlaybookRun(ctx, conn, journal)
+	_, err = internal.RunSteps(run.Steps(), nil)
+	result.WorkspaceID = journal.Output("ws", "id")
+	if registryErr := journal.Output("ws", "registryError"); registryErr != "" {
+		result.Warning = "registre local : " + registryErr
+	}
+	result.Tags, _ = strconv.Atoi(journal.Output("changes", internal.KindTag))
+	result.Assignments, _ = strconv.Atoi(journal.Output("changes", internal.KindTagAssignment))
+	if err != nil {
+		result.Error = err.Error()
+		if !keepOnFailure {
+			rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+			defer cancel()
+			result.Rollback = compensator.Rollback(rollbackCtx)
+		}
+	}
+	if saveErr := run.Finish(err, result.Rollback); saveErr != nil && result.Error == "" {
+		result.Error = fmt.Sprintf("journal : %v", saveErr)
+	}
+	return result
+}
+
+// printBulkResults affiche le tableau récapitulatif (ou le JSON) et retourne le nombre de lignes en échec.
+func printBulkResults(results []bulkResult) int {
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if jsonOutput() {
+		printJSON(results)
+		return failed
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LIGNE\tNOM\tWORKSPACE\tRUN\tTAGS\tASSIGNATIONS\tRÉSULTAT")
+	for _, r := range results {
+		status := "✅"
+		if r.Error != "" {
+			status = "❌ " + r.Error + rollbackSummary(r.Rollback)
+		} else if r.Warning != "" {
+			status = "✅ ⚠️  " + r.Warning
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%s\n", r.Line, r.Name, r.WorkspaceID, r.Run, r.Tags, r.Assignments, status)
+	}
+	tw.Flush()
+	fmt.Printf("📊 %d workspace(s) créé(s), %d échec(s)\n", len(results)-failed, failed)
+	return failed
+}
+
+// rollbackSummary résume la compensation d'une ligne en échec : « (compensé) », ou
+// « (compensation incomplète) » si une annulation a échoué.
+func rollbackSummary(reports []internal.UndoReport) string {
+	if len(reports) == 0 {
+		return ""
+	}
+	for _, r := range reports {
+		if r.Status == internal.UndoFailed {
+			return " (compensation incomplète : " + r.Error + ")"
+		}
+	}
+	return " (compensé)"
+}
+
+// init configure les flags de `create workspaces` et l'attache à `create`.
+func init() {
+	createWorkspacesCmd.Flags().StringVar(&sitesFile, "from", "", "Fichier CSV des workspaces à créer (obligatoire)")
+	createWorkspacesCmd.Flags().IntVar(&bulkWorkers, "workers", 4, "Nombre maximal de créations simultanées")
+	createWorkspacesCmd.Flags().DurationVar(&bulkTimeout, "timeout", 30*time.Minute, "Durée maximale de la commande, toutes lignes comprises")
+	createWorkspacesCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "Conserver le workspace d'une ligne en échec")
+	createCmd.AddCommand(createWorkspacesCmd)
+}
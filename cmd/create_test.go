@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+
+	"cvaas_cli/internal"
+)
+
+func TestIsRetriedCreation(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	err := internal.UpdateRegistry(func(r *internal.WorkspaceYAML) error {
+		r.Workspace = append(r.Workspace, internal.WorkspaceEntry{WorkspaceID: "ws-registry", RequestID: "req-registry"})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answered := internal.WorkspaceInfo{ID: "ws-1", RequestIDs: []string{"req-1", "req-2"}}
+	tests := []struct {
+		name                   string
+		existing               internal.WorkspaceInfo
+		workspaceID, requestID string
+		want                   bool
+	}{
+		{"requestID auquel CVaaS a répondu", answered, "ws-1", "req-2", true},
+		{"requestID inconnu", answered, "ws-1", "req-3", false},
+		{"autre workspace de même nom", answered, "ws-2", "req-1", false},
+		{"sans --id", answered, "", "req-1", false},
+		{"sans --request-id", answered, "ws-1", "", false},
+		{"requestID du registre local", internal.WorkspaceInfo{ID: "ws-registry"}, "ws-registry", "req-registry", true},
+		{"requestID d'une autre entrée du registre", internal.WorkspaceInfo{ID: "ws-registry"}, "ws-registry", "req-1", false},
+	}
+	for _, tt := range tests {
+		if got := isRetriedCreation(tt.existing, tt.workspaceID, tt.requestID); got != tt.want {
+			t.Errorf("%s : %v, attendu %v", tt.name, got, tt.want)
+		}
+	}
+}
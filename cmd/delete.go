@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// deleteCmd est la commande principale `delete` du CLI, utilisée pour supprimer
+// des ressources sur la plateforme CVaaS (comme des tags).
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprimer des ressources dans cvaas-cli",
+}
+
+// init enregistre la commande `delete` dans la racine du CLI.
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -25,6 +25,10 @@ var mlagFilter bool
 // les équipements avec DANZ activé.
 var danzFilter bool
 
+// tagQueryFilter est un flag CLI contenant une requête de tags sélectionnant les
+// devices (ex: "site:Paris AND role:leaf AND NOT model:cEOSLab").
+var tagQueryFilter string
+
 // getCmd est la commande principale `get` du CLI, utilisée pour récupérer
 // des ressources depuis la plateforme CVaaS (CloudVision-as-a-Service).
 //
@@ -36,7 +40,7 @@ var getCmd = &cobra.Command{
 
 // getDevicesCmd est une sous-commande de `get` utilisée pour afficher l'inventaire
 // des équipements disponibles sur CVaaS, avec la possibilité de filtrer par modèle,
-// MLAG ou DANZ.
+// MLAG, DANZ ou requête de tags (`--tag-query`).
 //
 // Conflit logique : les flags `--mlag` et `--danz` sont mutuellement exclusifs, et
 // leur combinaison est bloquée lors de l'exécution.
@@ -53,7 +57,11 @@ var getDevicesCmd = &cobra.Command{
 		defer cancel()
 		defer conn.Close()
 
-		devices := internal.ReadInventory(ctx, conn, modelFilter, mlagFilter, danzFilter)
+		devices, err := internal.FilterByTagQuery(ctx, conn, internal.ReadInventory(ctx, conn, modelFilter, mlagFilter, danzFilter), tagQueryFilter)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
 
 		for _, d := range devices {
 			fmt.Printf("📟 %s (%s) - %s\n", d.Hostname, d.DeviceID, d.Model)
@@ -84,6 +92,7 @@ var getWorkspacesCmd = &cobra.Command{
 func init() {
 	getCmd.AddCommand(getDevicesCmd)
 	getDevicesCmd.Flags().StringVar(&modelFilter, "model", "", "Filtrer par modèle (ex: cEOSLab)")
+	getDevicesCmd.Flags().StringVar(&tagQueryFilter, "tag-query", "", "Filtrer par requête de tags (ex: 'site:Paris AND NOT model:cEOSLab')")
 	getCmd.AddCommand(getWorkspacesCmd)
 	getWorkspacesCmd.Flags().StringVar(&workspaceStateFilter, "state", "NONE", "Filtrer les workspaces par état (UNSPECIFIED, PENDING, SUBMITTED, ABANDONED, CONFLICTS, ROLLED_BACK)")
 	getDevicesCmd.Flags().BoolVar(&mlagFilter, "mlag", false, "Afficher uniquement les devices avec MLAG activé")
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// outputFormat est le flag global `--output` (`-o`) sélectionnant le format de sortie
+// des commandes qui le supportent : "text" (par défaut) ou "json".
+var outputFormat string
+
+// outputFormats liste les valeurs acceptées par `--output`.
+var outputFormats = map[string]bool{"text": true, "json": true}
+
+// jsonOutput indique si l'utilisateur a demandé une sortie JSON.
+func jsonOutput() bool {
+	return outputFormat == "json"
+}
+
+// printJSON écrit v en JSON indenté sur la sortie standard.
+func printJSON(v interface{}) {
+	writeJSON(os.Stdout, v)
+}
+
+// writeJSON écrit v en JSON indenté dans w.
+func writeJSON(w io.Writer, v interface{}) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Printf("❌ Erreur encodage JSON : %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ANSI escape codes utilisés pour colorer les diffs.
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	colorBold  = "\033[1m"
+)
+
+// useColor indique si la sortie peut être colorée : la sortie standard doit être
+// un terminal, et ni `--no-color` ni la variable NO_COLOR ne doivent être positionnés.
+func useColor(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize entoure s du code couleur donné si enabled est vrai.
+func colorize(enabled bool, color, s string) string {
+	if !enabled {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// confirm affiche question et lit la réponse de l'utilisateur sur l'entrée standard.
+// Seules les réponses "o", "oui", "y" et "yes" valent acceptation.
+func confirm(question string) bool {
+	fmt.Printf("❓ %s [o/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "o", "oui", "y", "yes":
+		return true
+	}
+	return false
+}
@@ -3,19 +3,43 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+
+	"cvaas_cli/internal"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	tokenPath string
-	urlPath   string
+	tokenPath   string
+	urlPath     string
+	profileName string
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "cvaas-cli",
 	Short: "CLI pour interagir avec Arista CloudVision",
 	Long:  "Outil CLI permettant de créer des workspaces, tags et exécuter des opérations via cvaas-cli",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if !outputFormats[outputFormat] {
+			return fmt.Errorf("format de sortie invalide : %s (text ou json)", outputFormat)
+		}
+		// Sans --token/--url explicites, les identifiants sont lus dans le profil. Un
+		// profil incomplet n'est une erreur que pour les commandes qui se connectent.
+		if tokenPath == "" || urlPath == "" {
+			if token, url, err := internal.ProfileCredentials(profileName); err == nil {
+				if tokenPath == "" {
+					tokenPath = token
+				}
+				if urlPath == "" {
+					urlPath = url
+				}
+			}
+		}
+		// Les écritures de la commande sont journalisées pour pouvoir être annulées (undo).
+		internal.StartOperation(strings.Join(append([]string{cmd.Root().Name()}, os.Args[1:]...), " "), profileName)
+		return nil
+	},
 }
 
 func Execute() {
@@ -26,11 +50,10 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&tokenPath, "token", "", "Chemin vers le fichier token")
-	rootCmd.PersistentFlags().StringVar(&urlPath, "url", "", "Chemin vers le fichier URL")
-
-	rootCmd.MarkPersistentFlagRequired("token")
-	rootCmd.MarkPersistentFlagRequired("url")
+	rootCmd.PersistentFlags().StringVar(&tokenPath, "token", "", "Chemin vers le fichier token (par défaut : celui du profil)")
+	rootCmd.PersistentFlags().StringVar(&urlPath, "url", "", "Chemin vers le fichier URL (par défaut : celui du profil)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Format de sortie (text, json)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "default", "Nom du tenant CVaaS : identifiants par défaut et entrées du registre local")
 
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(getCmd)
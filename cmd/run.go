@@ -1,29 +1,218 @@
 package cmd
 
 import (
-	// "cvaas_cli/internal"
-	// "fmt"
-	// "time"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
+// processModel est le flag CLI `--model` de `run process` : modèle des devices à tagger.
+var processModel string
+
+// processName est le flag CLI `--name` de `run process` : nom du workspace créé.
+var processName string
+
+// processSubmit est un flag CLI indiquant que `run process` doit soumettre le workspace
+// après un build réussi.
+var processSubmit bool
+
+// processTimeout est la durée maximale de `run process`, build et soumission compris.
+var processTimeout time.Duration
+
+// keepOnFailure est le flag CLI `--keep-on-failure` des commandes à plusieurs étapes :
+// en cas d'échec, les opérations déjà effectuées sont laissées en place au lieu d'être
+// compensées.
+var keepOnFailure bool
+
+// rollbackTimeout est la durée laissée à la compensation d'un échec, y compris
+// lorsque le délai de la commande est déjà écoulé.
+const rollbackTimeout = time.Minute
+
+// runCmd est la commande principale `run` du CLI, qui regroupe les processus
+// complets enchaînant plusieurs opérations CloudVision.
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Exécuter un processus complet dans cvaas-cli",
-	Hidden: true, // Pour masquer la cmd dans le help
 }
 
+// runProcessCmd enchaîne, en affichant chaque étape : la création d'un workspace, la
+// création des tags de data/tag.yaml et leur assignation aux devices sélectionnés
+// (cEOSLab par défaut), le build du workspace, puis, avec --submit, sa soumission.
+//
+// Les devices sont sélectionnés par --model, --devices et --tag-query ; une entrée de
+// data/tag.yaml ayant ses propres sélecteurs restreint encore cette sélection.
+// Le processus est un playbook intégré (voir internal.ChangesPlaybook) : il est
+// journalisé et peut être repris avec `run resume`. En cas d'échec, les opérations
+// effectuées sont compensées (voir compensate).
 var runProcessCmd = &cobra.Command{
 	Use:   "process",
 	Short: "Créer workspace, tag, et assigner aux cEOSLab",
 	Run: func(cmd *cobra.Command, args []string) {
-		// ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
-		// defer cancel()
-		// defer conn.Close()
+		file := loadTagFile()
+		if len(file.Tag) == 0 {
+			fmt.Printf("❌ Aucun tag dans %s\n", tagFilePath)
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, processTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		devices, err := internal.FilterByTagQuery(ctx, conn, internal.SelectDevices(internal.ReadInventory(ctx, conn, processModel, false, false), assignDevices), tagQueryFilter)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if len(devices) == 0 {
+			fmt.Println("❌ Aucun device ne correspond à la sélection")
+			os.Exit(1)
+		}
+		if !jsonOutput() {
+			fmt.Printf("📟 %d device(s) sélectionné(s)\n", len(devices))
+		}
+		changes := tagFileChanges(file, devices, internal.DeviceTags(devices, internal.GetTagAssignments(ctx, conn, "", "", "device")))
+
+		name := processName
+		if name == "" {
+			name = "process " + time.Now().Format("2006-01-02 15:04:05")
+		}
+		journal, ok := runChanges(ctx, conn, "run process", tagFilePath, name, "Tags de "+tagFilePath, changes, true, processSubmit)
+		if !ok {
+			os.Exit(1)
+		}
+		if !jsonOutput() && !processSubmit {
+			fmt.Printf("ℹ️  Workspace %s buildé : soumettez-le depuis CloudVision ou relancez avec --submit\n", journal.Output("ws", "id"))
+		}
 	},
 }
 
+// tagFileChanges retourne les modifications créant les tags d'un fichier de tags et les
+// assignant aux devices donnés, restreints aux sélecteurs propres de l'entrée s'il en a.
+func tagFileChanges(file internal.TagFile, devices []internal.DeviceInfo, deviceTags map[string]map[string][]string) internal.WorkspaceChanges {
+	var changes internal.WorkspaceChanges
+	for _, r := range file.Tag {
+		changes.Tags = append(changes.Tags, internal.TagChange{Action: internal.ChangeAdd, Label: r.Label, Value: r.Value, ElementType: "device"})
+		for _, d := range devices {
+			if r.HasSelector() && !r.Selects(d, deviceTags[d.DeviceID]) {
+				continue
+			}
+			changes.TagAssignments = append(changes.TagAssignments, internal.TagAssignmentChange{
+				Action: internal.ChangeAdd, Label: r.Label, Value: r.Value, ElementType: "device",
+				DeviceID: d.DeviceID, Hostname: d.Hostname,
+			})
+		}
+	}
+	return changes
+}
+
+// runChanges journalise puis exécute le playbook intégré qui crée le workspace name et
+// y écrit changes, puis le builde et le soumet si demandé (voir internal.ChangesPlaybook
+// et executeRun). source est l'origine des modifications, affichée par `run status`.
+// Retourne le journal du run et true si toutes les étapes ont réussi.
+func runChanges(ctx context.Context, conn *grpc.ClientConn, title, source, name, description string, changes internal.WorkspaceChanges, build, submit bool) (*internal.RunJournal, bool) {
+	playbook, vars, err := internal.ChangesPlaybook(title, name, description, changes, build, submit)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	journal, err := internal.NewRunJournal(source, playbook, vars, profileName)
+	if err != nil {
+		fmt.Printf("❌ Journal d'exécution : %v\n", err)
+		os.Exit(1)
+	}
+	return journal, executeRun(ctx, conn, journal)
+}
+
+// compensate annule, de la plus récente à la plus ancienne, les opérations enregistrées
+// dans compensator après l'échec d'une commande, et affiche ce qui a été annulé. Avec
+// --keep-on-failure, les opérations sont laissées en place et seul leur nombre est signalé.
+//
+// La compensation dispose de son propre délai, pour rester possible si le délai de la
+// commande est écoulé. Retourne le rapport de compensation (vide si rien n'a été annulé).
+func compensate(ctx context.Context, compensator *internal.Compensator) []internal.UndoReport {
+	n := compensator.Len()
+	if n == 0 {
+		return nil
+	}
+	if keepOnFailure {
+		if !jsonOutput() {
+			fmt.Printf("⚠️  %d opération(s) laissée(s) en place (--keep-on-failure)\n", n)
+		}
+		return nil
+	}
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	reports := compensator.Rollback(rollbackCtx)
+	if !jsonOutput() {
+		printUndoReports(reports)
+	}
+	return reports
+}
+
+// printUndoReports affiche le rapport de compensation d'un échec. Les opérations
+// annulées par l'abandon de leur workspace sont résumées par workspace.
+func printUndoReports(reports []internal.UndoReport) {
+	fmt.Println("↩️  Compensation de l'échec :")
+	covered := map[string]int{}
+	var workspaces []string
+	for _, r := range reports {
+		switch r.Status {
+		case internal.UndoDone:
+			fmt.Printf("   ✅ %s annulée\n", r.Description)
+		case internal.UndoFailed:
+			fmt.Printf("   ❌ %s non annulée : %s\n", r.Description, r.Error)
+		case internal.UndoCovered:
+			if covered[r.WorkspaceID] == 0 {
+				workspaces = append(workspaces, r.WorkspaceID)
+			}
+			covered[r.WorkspaceID]++
+		}
+	}
+	for _, ws := range workspaces {
+		fmt.Printf("   ⏭️  %d opération(s) annulée(s) par l'abandon du workspace %s\n", covered[ws], ws)
+	}
+}
+
+// stepSymbols associe un symbole à chaque état d'étape.
+var stepSymbols = map[internal.StepStatus]string{
+	internal.StepOK:      "✅",
+	internal.StepFailed:  "❌",
+	internal.StepSkipped: "⏭️ ",
+}
+
+// printStepReport affiche le résultat de l'étape i : « ✅ [2/5] Nom — détail (1.2s) ».
+func printStepReport(i, total int, r internal.StepReport) {
+	line := fmt.Sprintf("%s [%d/%d] %s", stepSymbols[r.Status], i+1, total, r.Name)
+	switch r.Status {
+	case internal.StepOK:
+		if r.Detail != "" {
+			line += " — " + r.Detail
+		}
+		line += fmt.Sprintf(" (%s)", r.Duration.Round(100*time.Millisecond))
+	case internal.StepFailed:
+		line += " : " + r.Error
+	case internal.StepSkipped:
+		if r.Detail != "" {
+			line += " — " + r.Detail
+		}
+	}
+	fmt.Println(line)
+}
+
+// init configure les flags de `run process` et l'attache à `run`.
 func init() {
+	runProcessCmd.Flags().StringVarP(&tagFilePath, "file", "f", "data/tag.yaml", "Fichier de tags à créer et assigner")
+	runProcessCmd.Flags().StringVar(&processModel, "model", "cEOSLab", "Modèle des devices à tagger (vide pour tous)")
+	runProcessCmd.Flags().StringVar(&assignDevices, "devices", "", "Motifs de hostname séparés par des virgules (ex: 'leaf-*')")
+	runProcessCmd.Flags().StringVar(&tagQueryFilter, "tag-query", "", "Requête de tags sélectionnant les devices")
+	runProcessCmd.Flags().StringVar(&processName, "name", "", "Nom du workspace créé (par défaut : « process <date> »)")
+	runProcessCmd.Flags().BoolVar(&processSubmit, "submit", false, "Soumettre le workspace après un build réussi")
+	runProcessCmd.Flags().DurationVar(&processTimeout, "timeout", 10*time.Minute, "Durée maximale du processus, build et soumission compris")
+	runProcessCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place les opérations effectuées")
 	runCmd.AddCommand(runProcessCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// runResumeCmd reprend un run de playbook interrompu ou en échec à partir de son
+// journal : les étapes terminées ne sont pas réexécutées, l'étape interrompue est
+// rejouée avec les identifiants (workspace, requestIds) journalisés avant son envoi.
+// Le journal est verrouillé pendant la reprise : un run encore exécuté par un autre
+// processus est refusé.
+var runResumeCmd = &cobra.Command{
+	Use:   "resume <run-id>",
+	Short: "Reprendre un run de playbook interrompu ou en échec",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		journal, err := internal.ResumeRunJournal(args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if !journal.Resumable() {
+			journal.Unlock()
+			fmt.Printf("ℹ️  Run %s terminé (%s) : rien à reprendre\n", journal.ID, journal.Status)
+			return
+		}
+		if journal.Profile != "" && journal.Profile != profileName {
+			journal.Unlock()
+			fmt.Printf("❌ Run %s lancé avec le profil %s : relancez avec --profile %s\n", journal.ID, journal.Profile, journal.Profile)
+			os.Exit(1)
+		}
+		journal.Status = internal.RunRunning
+		if !executePlaybook(journal) {
+			os.Exit(1)
+		}
+	},
+}
+
+// runStatusCmd affiche le journal d'un run (étapes, sorties et écritures effectuées),
+// ou, sans argument, la liste des runs journalisés.
+var runStatusCmd = &cobra.Command{
+	Use:   "status [run-id]",
+	Short: "Afficher l'état d'un run de playbook, ou lister les runs",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			journals, err := internal.ListRunJournals()
+			if err != nil {
+				fmt.Printf("❌ Lecture des journaux : %v\n", err)
+				os.Exit(1)
+			}
+			printRunList(journals)
+			return
+		}
+		journal, err := internal.LoadRunJournal(args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if jsonOutput() {
+			printJSON(journal)
+			return
+		}
+		printRunStatus(journal)
+	},
+}
+
+// runSymbols associe un symbole à chaque état de run.
+var runSymbols = map[internal.RunStatus]string{
+	internal.RunRunning:    "⏳",
+	internal.RunFailed:     "❌",
+	internal.RunCompleted:  "✅",
+	internal.RunRolledBack: "↩️ ",
+}
+
+// printRunList affiche les runs journalisés, du plus récent au plus ancien.
+func printRunList(journals []*internal.RunJournal) {
+	if jsonOutput() {
+		printJSON(journals)
+		return
+	}
+	if len(journals) == 0 {
+		fmt.Println("ℹ️  Aucun run journalisé")
+		return
+	}
+	for _, j := range journals {
+		done := 0
+		for _, s := range j.Steps {
+			if s.Done() {
+				done++
+			}
+		}
+		fmt.Printf("%s %s  %-9s %d/%d étape(s)  %s  %s\n", runSymbols[j.Status], j.ID, j.Status, done, len(j.Steps),
+			j.StartedAt.Local().Format("2006-01-02 15:04"), j.Playbook.Name)
+	}
+}
+
+// printRunStatus affiche le détail d'un run : état de chaque étape, sorties et écritures.
+func printRunStatus(j *internal.RunJournal) {
+	fmt.Printf("%s Run %s : %s\n", runSymbols[j.Status], j.ID, j.Status)
+	switch {
+	case j.Playbook.Name != "" && j.File != "":
+		fmt.Printf("   Playbook : %s (%s)\n", j.Playbook.Name, j.File)
+	case j.Playbook.Name != "":
+		fmt.Printf("   Playbook : %s\n", j.Playbook.Name)
+	}
+	fmt.Printf("   Démarré : %s, mis à jour : %s\n",
+		j.StartedAt.Local().Format(time.DateTime), j.UpdatedAt.Local().Format(time.DateTime))
+	for i, s := range j.Steps {
+		status := s.Status
+		if status == "" {
+			status = "pending"
+		}
+		symbol := stepSymbols[status]
+		if status == internal.StepRunning || status == "pending" {
+			symbol = "⏸️ "
+		}
+		line := fmt.Sprintf("%s [%d/%d] %s (%s) : %s", symbol, i+1, len(j.Steps), s.ID, j.Playbook.Steps[i].Action, status)
+		if s.Error != "" {
+			line += " — " + s.Error
+		}
+		fmt.Println(line)
+		for _, w := range s.Writes {
+			fmt.Printf("      ✏️  %s\n", w)
+		}
+		if len(s.Outputs) > 0 {
+			fmt.Printf("      ➡️  %s\n", internal.FormatOutputs(s.Outputs))
+		}
+	}
+	if len(j.Rollback) > 0 {
+		printUndoReports(j.Rollback)
+	}
+	if j.Resumable() {
+		fmt.Printf("↩️  Reprendre : cvaas-cli run resume %s\n", j.ID)
+	}
+}
+
+// init attache `run resume` et `run status` à `run`.
+func init() {
+	runResumeCmd.Flags().DurationVar(&playbookTimeout, "timeout", 30*time.Minute, "Durée maximale de la reprise, attentes comprises")
+	runResumeCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place les opérations de la reprise")
+	runCmd.AddCommand(runResumeCmd)
+	runCmd.AddCommand(runStatusCmd)
+}
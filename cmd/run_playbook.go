@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// playbookFile est le flag CLI `--file` (`-f`) de `run playbook` : le playbook à exécuter.
+var playbookFile string
+
+// playbookSets contient les affectations `--set clé=valeur` des variables du playbook.
+var playbookSets []string
+
+// playbookCheck est un flag CLI indiquant que `run playbook` valide le playbook et
+// liste ses étapes sans se connecter à CloudVision.
+var playbookCheck bool
+
+// playbookTimeout est la durée maximale de `run playbook`, attentes comprises.
+var playbookTimeout time.Duration
+
+// runPlaybookCmd exécute un playbook YAML : des variables et une liste ordonnée
+// d'étapes (workspaces, tags, inputs de studios, change controls, attentes,
+// assertions). Les sorties d'une étape sont référencées par les suivantes via
+// `{{ .steps.<id>.<sortie> }}`.
+//
+// Chaque exécution est journalisée (voir `run status`) et s'arrête à la première étape
+// en échec. Les opérations effectuées sont alors compensées ; avec --keep-on-failure,
+// elles sont laissées en place et le run peut être repris avec `run resume`.
+var runPlaybookCmd = &cobra.Command{
+	Use:   "playbook",
+	Short: "Exécuter un playbook YAML d'étapes CloudVision",
+	Run: func(cmd *cobra.Command, args []string) {
+		playbook, err := internal.LoadPlaybook(playbookFile)
+		if err != nil {
+			fmt.Printf("❌ Playbook : %v\n", err)
+			os.Exit(1)
+		}
+		values, err := internal.ParseSetFlags(playbookSets)
+		if err == nil {
+			values, err = playbook.ResolveVars(values)
+		}
+		if err != nil {
+			fmt.Printf("❌ Variables du playbook : %v\n", err)
+			os.Exit(1)
+		}
+		if playbookCheck {
+			fmt.Printf("✅ Playbook %s valide : %d étape(s)\n", playbookFile, len(playbook.Steps))
+			for i, s := range playbook.Steps {
+				fmt.Printf("   %d. %s (%s)\n", i+1, s.ID, s.Action)
+			}
+			return
+		}
+
+		journal, err := internal.NewRunJournal(playbookFile, playbook, values, profileName)
+		if err != nil {
+			fmt.Printf("❌ Journal d'exécution : %v\n", err)
+			os.Exit(1)
+		}
+		if !executePlaybook(journal) {
+			os.Exit(1)
+		}
+	},
+}
+
+// executePlaybook exécute, ou reprend, le playbook d'un journal dans une connexion
+// bornée par --timeout (voir executeRun). Retourne true si toutes les étapes ont réussi.
+func executePlaybook(journal *internal.RunJournal) bool {
+	ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, playbookTimeout)
+	defer cancel()
+	defer conn.Close()
+	return executeRun(ctx, conn, journal)
+}
+
+// executeRun exécute, ou reprend, le playbook d'un journal verrouillé en affichant
+// chaque étape, enregistre l'issue du run puis libère le journal. En cas d'échec, les
+// opérations de cette exécution sont compensées (voir compensate) ; sinon, la commande
+// de reprise est indiquée. Retourne true si toutes les étapes ont réussi.
+func executeRun(ctx context.Context, conn *grpc.ClientConn, journal *internal.RunJournal) bool {
+	defer journal.Unlock()
+	compensator := internal.NewCompensator()
+	ctx = internal.WithCompensator(ctx, compensator)
+
+	playbook := journal.Playbook
+	if !jsonOutput() {
+		if playbook.Name != "" {
+			fmt.Printf("▶️  %s\n", playbook.Name)
+		}
+		fmt.Printf("🧾 Run %s (journal : %s)\n", journal.ID, journal.Path())
+	}
+	run := internal.NewPlaybookRun(ctx, conn, journal)
+	steps := run.Steps()
+	reports, err := internal.RunSteps(steps, func(i int, r internal.StepReport) {
+		if !jsonOutput() {
+			printStepReport(i, len(steps), r)
+		}
+	})
+	var rollback []internal.UndoReport
+	if err != nil {
+		rollback = compensate(ctx, compensator)
+	}
+	if saveErr := run.Finish(err, rollback); saveErr != nil && !jsonOutput() {
+		fmt.Printf("⚠️  Journal non mis à jour : %v\n", saveErr)
+	}
+	if jsonOutput() {
+		printJSON(map[string]interface{}{
+			"run": journal.ID, "playbook": playbook.Name, "steps": reports, "outputs": run.Outputs(), "rollback": rollback,
+		})
+	} else if err != nil && journal.Resumable() {
+		fmt.Printf("↩️  Reprendre après correction : cvaas-cli run resume %s\n", journal.ID)
+	}
+	return err == nil
+}
+
+// init configure les flags de `run playbook` et l'attache à `run`.
+func init() {
+	runPlaybookCmd.Flags().StringVarP(&playbookFile, "file", "f", "data/playbook.yaml", "Playbook à exécuter")
+	runPlaybookCmd.Flags().StringArrayVar(&playbookSets, "set", nil, "Valeur d'une variable du playbook (clé=valeur, répétable)")
+	runPlaybookCmd.Flags().BoolVar(&playbookCheck, "check", false, "Valider le playbook et lister ses étapes sans l'exécuter")
+	runPlaybookCmd.Flags().DurationVar(&playbookTimeout, "timeout", 30*time.Minute, "Durée maximale du playbook, attentes comprises")
+	runPlaybookCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place les opérations effectuées (reprise possible)")
+	runCmd.AddCommand(runPlaybookCmd)
+}
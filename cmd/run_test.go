@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cvaas_cli/internal"
+)
+
+func TestTagFileChanges(t *testing.T) {
+	devices := []internal.DeviceInfo{
+		{DeviceID: "SN1", Hostname: "leaf-1", Model: "cEOSLab"},
+		{DeviceID: "SN2", Hostname: "leaf-2", Model: "DCS-7280SR"},
+		{DeviceID: "SN3", Hostname: "spine-1", Model: "cEOSLab"},
+	}
+	deviceTags := internal.DeviceTags(devices, []internal.TagAssignmentInfo{
+		{Label: "site", Value: "Paris", ElementType: "device", DeviceID: "SN1"},
+		{Label: "site", Value: "Paris", ElementType: "device", DeviceID: "SN3"},
+	})
+	def := func(label, value string) internal.TagChange {
+		return internal.TagChange{Action: internal.ChangeAdd, Label: label, Value: value, ElementType: "device"}
+	}
+	assign := func(label, value string, d internal.DeviceInfo) internal.TagAssignmentChange {
+		return internal.TagAssignmentChange{
+			Action: internal.ChangeAdd, Label: label, Value: value, ElementType: "device",
+			DeviceID: d.DeviceID, Hostname: d.Hostname,
+		}
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want internal.WorkspaceChanges
+	}{
+		{
+			name: "sans sélecteur : tous les devices sélectionnés",
+			file: "tag:\n  - {label: env, value: lab}\n",
+			want: internal.WorkspaceChanges{
+				Tags:           []internal.TagChange{def("env", "lab")},
+				TagAssignments: []internal.TagAssignmentChange{assign("env", "lab", devices[0]), assign("env", "lab", devices[1]), assign("env", "lab", devices[2])},
+			},
+		},
+		{
+			name: "sélecteurs propres à chaque entrée",
+			file: "tag:\n  - {label: role, value: leaf, devices: 'leaf-*'}\n  - {label: role, value: spine, model: cEOSLab, query: 'site:Paris AND NOT role:leaf'}\n",
+			want: internal.WorkspaceChanges{
+				Tags: []internal.TagChange{def("role", "leaf"), def("role", "spine")},
+				// La requête porte sur les tags de mainline : SN1 n'a pas encore role=leaf.
+				TagAssignments: []internal.TagAssignmentChange{
+					assign("role", "leaf", devices[0]), assign("role", "leaf", devices[1]),
+					assign("role", "spine", devices[0]), assign("role", "spine", devices[2]),
+				},
+			},
+		},
+		{
+			name: "aucun device ne correspond",
+			file: "tag:\n  - {label: role, value: border, devices: 'border-*'}\n",
+			want: internal.WorkspaceChanges{Tags: []internal.TagChange{def("role", "border")}},
+		},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "tag.yaml")
+		if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		file, err := internal.LoadTagFile(path)
+		if err != nil {
+			t.Fatalf("%s : %v", tt.name, err)
+		}
+		if got := tagFileChanges(file, devices, deviceTags); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : modifications = %+v, attendu %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// tagLabel et tagValue sont les flags CLI `--label` et `--value` identifiant un tag.
+var tagLabel, tagValue string
+
+// tagWorkspaceID est le flag CLI `--workspace` donnant le workspace dans lequel le tag
+// est créé ou supprimé. Pour `get tags`, une valeur vide désigne mainline.
+var tagWorkspaceID string
+
+// assignDevices est le flag CLI `--devices` de `tag assign|unassign` : motifs glob de
+// hostname séparés par des virgules (ex : "leaf-*,spine-1").
+var assignDevices string
+
+// assignInterfaces est le flag CLI `--interfaces` de `tag assign|unassign` : sélecteurs
+// "hostname:interface" séparés par des virgules. Il fait porter le tag aux interfaces.
+var assignInterfaces string
+
+// tagDevice est le flag CLI `--device` de `get tags` : motifs de hostname dont les
+// assignations de tags sont affichées.
+var tagDevice string
+
+// tagInterface est le flag CLI `--interface` sélectionnant les tags d'interface
+// au lieu des tags de device.
+var tagInterface bool
+
+// tagSubType est le flag CLI `--sub-type` donnant le sous-type tag.v2 d'un tag
+// d'interface (ex : "external") ; vide pour une interface sans sous-type.
+var tagSubType string
+
+// createTagCmd est une sous-commande de `create` qui crée un tag label=value dans
+// un workspace.
+//
+// Flags requis : --label, --value, --workspace.
+// Flags optionnels : --interface pour créer un tag d'interface, --sub-type pour son sous-type.
+var createTagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Créer un tag dans un workspace",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTagFlags()
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		t := internal.CreateTag(ctx, conn, tagWorkspaceID, tagLabel, tagValue, tagElementType(), tagSubType)
+		if jsonOutput() {
+			printJSON(t)
+			return
+		}
+		fmt.Printf("🏷️  Tag ajouté : %s=%s (%s) dans %s\n", t.Label, t.Value, elementKind(t.ElementType, t.ElementSubType), tagWorkspaceID)
+	},
+}
+
+// deleteTagCmd est une sous-commande de `delete` qui supprime un tag label=value
+// dans un workspace. La suppression s'applique à mainline à la soumission du workspace.
+//
+// Flags requis : --label, --value, --workspace.
+// Flags optionnels : --interface pour supprimer un tag d'interface, --sub-type pour son sous-type.
+var deleteTagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Supprimer un tag dans un workspace",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTagFlags()
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		t := internal.DeleteTag(ctx, conn, tagWorkspaceID, tagLabel, tagValue, tagElementType(), tagSubType)
+		if jsonOutput() {
+			printJSON(t)
+			return
+		}
+		fmt.Printf("🗑️  Tag supprimé : %s=%s (%s) dans %s\n", t.Label, t.Value, elementKind(t.ElementType, t.ElementSubType), tagWorkspaceID)
+	},
+}
+
+// getTagsCmd est une sous-commande de `get` qui affiche les tags de mainline
+// (ou d'un workspace avec --workspace), éventuellement filtrés par label.
+//
+// Avec --device, la commande affiche les assignations de tags des devices
+// correspondants (ou de leurs interfaces avec --interface), regroupées par device.
+var getTagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Afficher les tags de devices ou d'interfaces",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		if tagDevice != "" {
+			printDeviceTagAssignments(internal.SelectDevices(internal.ReadInventory(ctx, conn, "", false, false), tagDevice),
+				internal.GetTagAssignments(ctx, conn, tagWorkspaceID, tagLabel, tagElementType()))
+			return
+		}
+
+		tags := internal.GetTags(ctx, conn, tagWorkspaceID, tagLabel, tagElementType())
+		if jsonOutput() {
+			if tags == nil {
+				tags = []internal.TagInfo{}
+			}
+			printJSON(tags)
+			return
+		}
+		if len(tags) == 0 {
+			fmt.Println("ℹ️  Aucun tag")
+			return
+		}
+		for _, t := range tags {
+			fmt.Printf("🏷️  %s=%s (%s, %s)\n", t.Label, t.Value, elementKind(t.ElementType, t.ElementSubType), t.CreatorType)
+		}
+	},
+}
+
+// tagCmd est la commande principale `tag` du CLI, qui regroupe les opérations
+// d'assignation de tags aux équipements.
+var tagCmd = &cobra.Command{
+	Use:     "tag",
+	Aliases: []string{"tags"},
+	Short:   "Assigner des tags aux équipements",
+}
+
+// tagAssignCmd assigne un tag label=value aux devices sélectionnés par hostname
+// (--devices), requête de tags (--tag-query), modèle (--model) ou fonctionnalité
+// (--mlag, --danz), ou à des interfaces de ces devices (--interfaces). Le tag est créé
+// dans le workspace s'il n'existe pas.
+var tagAssignCmd = &cobra.Command{
+	Use:   "assign <label=value>",
+	Short: "Assigner un tag à des devices dans un workspace",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTagAssignment(args[0], false)
+	},
+}
+
+// tagUnassignCmd retire un tag label=value des devices sélectionnés, avec les mêmes
+// sélecteurs que `tag assign`.
+var tagUnassignCmd = &cobra.Command{
+	Use:   "unassign <label=value>",
+	Short: "Retirer un tag de devices dans un workspace",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTagAssignment(args[0], true)
+	},
+}
+
+// runTagAssignment résout la sélection de devices puis écrit les assignations par lots,
+// en affichant le résultat de chaque device. La commande échoue si un device est en erreur.
+func runTagAssignment(arg string, remove bool) {
+	label, value, err := internal.ParseTag(arg)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if tagWorkspaceID == "" {
+		fmt.Println("❌ Veuillez spécifier un workspace avec --workspace")
+		os.Exit(1)
+	}
+	if assignDevices == "" && assignInterfaces == "" && tagQueryFilter == "" && modelFilter == "" && !mlagFilter && !danzFilter {
+		fmt.Println("❌ Veuillez sélectionner des devices avec --devices, --interfaces, --tag-query, --model, --mlag ou --danz")
+		os.Exit(1)
+	}
+	if tagSubType != "" && assignInterfaces == "" {
+		fmt.Println("❌ --sub-type est réservé aux tags d'interface (--interfaces)")
+		os.Exit(1)
+	}
+	var selectors []internal.InterfaceSelector
+	elementType := "device"
+	if assignInterfaces != "" {
+		selectors, err = internal.ParseInterfaceSelectors(assignInterfaces)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		elementType = "interface"
+	}
+	if mlagFilter && danzFilter {
+		fmt.Println("❌ Les filtres --mlag et --danz ne peuvent pas être utilisés en même temps.")
+		os.Exit(1)
+	}
+
+	ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+	defer cancel()
+	defer conn.Close()
+
+	devices, err := internal.FilterByTagQuery(ctx, conn, internal.SelectDevices(internal.ReadInventory(ctx, conn, modelFilter, mlagFilter, danzFilter), assignDevices), tagQueryFilter)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	targets := internal.DeviceTargets(devices)
+	if selectors != nil {
+		interfaces, err := internal.DeviceInterfaces(ctx, conn)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		var missing []internal.TagTarget
+		targets, missing = internal.SelectInterfaces(devices, selectors, interfaces)
+		if len(missing) > 0 {
+			fmt.Printf("❌ %d interface(s) inexistante(s) sur leur device :\n", len(missing))
+			for _, t := range missing {
+				fmt.Printf("   - %s\n", t)
+			}
+			os.Exit(1)
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("❌ Aucun device ou interface ne correspond à la sélection")
+		os.Exit(1)
+	}
+	if !remove {
+		internal.CreateTag(ctx, conn, tagWorkspaceID, label, value, elementType, tagSubType)
+	}
+	results, err := internal.SetTagAssignments(ctx, conn, tagWorkspaceID, label, value, elementType, tagSubType, targets, remove)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if !printAssignmentResults(label, value, results, remove) {
+		os.Exit(1)
+	}
+}
+
+// printAssignmentResults affiche le résultat de chaque assignation, en texte ou en JSON,
+// et indique si toutes ont réussi.
+func printAssignmentResults(label, value string, results []internal.AssignmentResult, remove bool) bool {
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if jsonOutput() {
+		printJSON(results)
+		return failed == 0
+	}
+	verb := "assigné à"
+	if remove {
+		verb = "retiré de"
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Printf("❌ %s=%s : %s : %s\n", label, value, r.TagTarget, r.Error)
+			continue
+		}
+		fmt.Printf("📌 Tag '%s=%s' %s %s (%s)\n", label, value, verb, r.TagTarget, r.DeviceID)
+	}
+	fmt.Printf("📊 %d réussi(s), %d en échec\n", len(results)-failed, failed)
+	return failed == 0
+}
+
+// printDeviceTagAssignments affiche, pour chaque device, ses assignations de tags
+// (ou celles de ses interfaces), en texte ou en JSON.
+func printDeviceTagAssignments(devices []internal.DeviceInfo, assignments []internal.TagAssignmentInfo) {
+	byDevice := map[string][]internal.TagAssignmentInfo{}
+	for _, a := range assignments {
+		byDevice[a.DeviceID] = append(byDevice[a.DeviceID], a)
+	}
+	if jsonOutput() {
+		out := map[string][]internal.TagAssignmentInfo{}
+		for _, d := range devices {
+			out[d.Hostname] = append([]internal.TagAssignmentInfo{}, byDevice[d.DeviceID]...)
+		}
+		printJSON(out)
+		return
+	}
+	if len(devices) == 0 {
+		fmt.Println("ℹ️  Aucun device ne correspond à --device")
+		return
+	}
+	for _, d := range devices {
+		fmt.Printf("📟 %s (%s)\n", d.Hostname, d.DeviceID)
+		if len(byDevice[d.DeviceID]) == 0 {
+			fmt.Println("   (aucun tag)")
+		}
+		for _, a := range byDevice[d.DeviceID] {
+			if a.InterfaceID != "" {
+				fmt.Printf("   🔌 %s : %s=%s\n", a.InterfaceID, a.Label, a.Value)
+				continue
+			}
+			fmt.Printf("   🏷️  %s=%s\n", a.Label, a.Value)
+		}
+	}
+}
+
+// requireTagFlags arrête la commande si --label, --value ou --workspace manque, ou si
+// --sub-type est donné sans --interface.
+func requireTagFlags() {
+	if tagLabel == "" || tagValue == "" || tagWorkspaceID == "" {
+		fmt.Println("❌ Veuillez spécifier --label, --value et --workspace")
+		os.Exit(1)
+	}
+	if tagSubType != "" && !tagInterface {
+		fmt.Println("❌ --sub-type est réservé aux tags d'interface (--interface)")
+		os.Exit(1)
+	}
+}
+
+// elementKind retourne le type d'élément d'un tag suivi de son sous-type éventuel
+// (ex : "interface/external").
+func elementKind(elementType, elementSubType string) string {
+	if elementSubType == "" {
+		return elementType
+	}
+	return elementType + "/" + elementSubType
+}
+
+// tagElementType retourne le type d'élément sélectionné par --interface.
+func tagElementType() string {
+	if tagInterface {
+		return "interface"
+	}
+	return "device"
+}
+
+// init attache les commandes de tags à `create`, `get` et `delete`, et enregistre
+// la commande `tag` dans la racine du CLI.
+func init() {
+	for _, c := range []*cobra.Command{createTagCmd, deleteTagCmd} {
+		c.Flags().StringVar(&tagLabel, "label", "", "Label du tag (obligatoire)")
+		c.Flags().StringVar(&tagValue, "value", "", "Valeur du tag (obligatoire)")
+		c.Flags().StringVar(&tagWorkspaceID, "workspace", "", "ID du workspace (obligatoire)")
+		c.Flags().BoolVar(&tagInterface, "interface", false, "Tag d'interface au lieu d'un tag de device")
+		c.Flags().StringVar(&tagSubType, "sub-type", "", "Sous-type d'un tag d'interface (ex: external)")
+	}
+	getTagsCmd.Flags().StringVar(&tagLabel, "label", "", "Filtrer par label (ex: site)")
+	getTagsCmd.Flags().StringVar(&tagWorkspaceID, "workspace", "", "Lire les tags d'un workspace (mainline par défaut)")
+	getTagsCmd.Flags().BoolVar(&tagInterface, "interface", false, "Afficher les tags d'interface")
+	getTagsCmd.Flags().StringVar(&tagDevice, "device", "", "Afficher les assignations des devices correspondants (motifs de hostname)")
+	for _, c := range []*cobra.Command{tagAssignCmd, tagUnassignCmd} {
+		c.Flags().StringVar(&tagWorkspaceID, "workspace", "", "ID du workspace (obligatoire)")
+		c.Flags().StringVar(&assignDevices, "devices", "", "Motifs de hostname séparés par des virgules (ex: 'leaf-*')")
+		c.Flags().StringVar(&assignInterfaces, "interfaces", "", "Interfaces à tagger (ex: 'leaf-1:Ethernet49/1,leaf-*:Ethernet50/1')")
+		c.Flags().StringVar(&tagSubType, "sub-type", "", "Sous-type des tags d'interface (ex: external)")
+		c.Flags().StringVar(&tagQueryFilter, "tag-query", "", "Sélectionner les devices par requête de tags (ex: 'site:Paris AND role:leaf')")
+		c.Flags().StringVar(&modelFilter, "model", "", "Sélectionner les devices d'un modèle (ex: cEOSLab)")
+		c.Flags().BoolVar(&mlagFilter, "mlag", false, "Sélectionner les devices avec MLAG activé")
+		c.Flags().BoolVar(&danzFilter, "danz", false, "Sélectionner les devices avec DANZ activé")
+		tagCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(tagCmd)
+	createCmd.AddCommand(createTagCmd)
+	deleteCmd.AddCommand(deleteTagCmd)
+	getCmd.AddCommand(getTagsCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// autotagFile est le flag CLI `-f` donnant le fichier de règles d'auto-tagging.
+var autotagFile string
+
+// autotagDryRun est un flag CLI indiquant que `tags autotag` doit seulement afficher le plan.
+var autotagDryRun bool
+
+// tagAutotagCmd dérive des tags des attributs d'inventaire (modèle, hostname,
+// version...) selon un fichier de règles, affiche le plan par rapport à mainline, puis
+// l'applique dans un nouveau workspace (sauf avec --dry-run).
+//
+// Les flags --name, --build, --submit et --timeout sont ceux de `tags apply`.
+var tagAutotagCmd = &cobra.Command{
+	Use:   "autotag",
+	Short: "Dériver des tags de l'inventaire selon des règles",
+	Run: func(cmd *cobra.Command, args []string) {
+		rules, err := internal.LoadAutoTagRules(autotagFile)
+		if err != nil {
+			fmt.Printf("❌ Règles d'auto-tagging : %v\n", err)
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, tagApplyTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		plan := internal.PlanAutoTags(ctx, conn, rules, internal.ReadInventory(ctx, conn, "", false, false), tagPrune)
+		if autotagDryRun && jsonOutput() {
+			printJSON(plan)
+			return
+		}
+		printTagPlan(plan)
+		if autotagDryRun || plan.Count() == 0 {
+			return
+		}
+		applyTagPlan(ctx, conn, plan, "tags autotag", autotagFile, "Auto-tagging depuis "+autotagFile)
+	},
+}
+
+// init configure les flags de `tags autotag` et l'attache à `tag`.
+func init() {
+	tagAutotagCmd.Flags().StringVarP(&autotagFile, "file", "f", "data/autotag.yaml", "Fichier de règles d'auto-tagging")
+	tagAutotagCmd.Flags().BoolVar(&autotagDryRun, "dry-run", false, "Afficher le plan sans créer de workspace")
+	tagAutotagCmd.Flags().BoolVar(&tagPrune, "prune", false, "Retirer les autres valeurs des labels dérivés")
+	tagAutotagCmd.Flags().StringVar(&tagApplyName, "name", "", "Nom du workspace créé (par défaut : « tags <date> »)")
+	tagAutotagCmd.Flags().BoolVar(&tagApplyBuild, "build", false, "Builder le workspace après écriture")
+	tagAutotagCmd.Flags().BoolVar(&tagApplySubmit, "submit", false, "Builder puis soumettre le workspace")
+	tagAutotagCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place le workspace créé")
+	tagAutotagCmd.Flags().DurationVar(&tagApplyTimeout, "timeout", 5*time.Minute, "Durée maximale de la commande, build et soumission compris")
+	tagCmd.AddCommand(tagAutotagCmd)
+}
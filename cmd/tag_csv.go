@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// tagExportFormat est le flag CLI `--format` de `tags export` : "csv" ou "json".
+var tagExportFormat string
+
+// tagCSVFile est le flag CLI `-f` de `tags export` (fichier produit, sortie standard
+// par défaut) et de `tags import` (fichier lu).
+var tagCSVFile string
+
+// tagExportCmd exporte les assignations de tags de devices et d'interfaces de mainline
+// (ou d'un workspace avec --workspace) : hostname, serial, label, value, type
+// d'élément et interface. Seules les assignations créées par un utilisateur sont
+// exportées : les tags système ne peuvent pas être réimportés.
+var tagExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exporter les assignations de tags (CSV)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if tagExportFormat != "csv" && tagExportFormat != "json" {
+			fmt.Printf("❌ Format d'export invalide : %q (csv ou json)\n", tagExportFormat)
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		devices := internal.ReadInventory(ctx, conn, "", false, false)
+		all := append(internal.GetTagAssignments(ctx, conn, tagWorkspaceID, "", "device"),
+			internal.GetTagAssignments(ctx, conn, tagWorkspaceID, "", "interface")...)
+		assignments := internal.UserAssignments(all)
+
+		var out io.Writer = os.Stdout
+		if tagCSVFile != "" {
+			f, err := os.Create(tagCSVFile)
+			if err != nil {
+				fmt.Printf("❌ Erreur création de %s : %v\n", tagCSVFile, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+		if tagExportFormat == "json" {
+			writeJSON(out, assignments)
+		} else if err := internal.WriteTagsCSV(out, devices, assignments); err != nil {
+			fmt.Printf("❌ Erreur écriture CSV : %v\n", err)
+			os.Exit(1)
+		}
+		if tagCSVFile != "" {
+			fmt.Printf("✅ %d assignation(s) exportée(s) dans %s\n", len(assignments), tagCSVFile)
+			if skipped := len(all) - len(assignments); skipped > 0 {
+				fmt.Printf("⏭️  %d assignation(s) de tags système ignorée(s)\n", skipped)
+			}
+		}
+	},
+}
+
+// tagImportCmd importe un CSV de tags dans un workspace : les lignes sont validées
+// contre l'inventaire, les doublons sont signalés et ignorés, puis les tags sont créés
+// et assignés par lots SetSome communs à tous les tags. Aucune écriture n'est faite si un device est inconnu.
+var tagImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Importer des assignations de tags depuis un CSV",
+	Run: func(cmd *cobra.Command, args []string) {
+		if tagCSVFile == "" || tagWorkspaceID == "" {
+			fmt.Println("❌ Veuillez spécifier un fichier avec -f et un workspace avec --workspace")
+			os.Exit(1)
+		}
+		rows, err := internal.ReadTagsCSV(tagCSVFile)
+		if err != nil {
+			fmt.Printf("❌ CSV de tags : %v\n", err)
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		plan := internal.ResolveTagsCSV(rows, internal.ReadInventory(ctx, conn, "", false, false))
+		for _, row := range plan.Duplicates {
+			fmt.Printf("⚠️  Doublon ignoré, %s\n", row)
+		}
+		for _, row := range plan.Unknown {
+			fmt.Printf("❌ Device inconnu (ou hostname et serial incohérents), %s\n", row)
+		}
+		if len(plan.Unknown) > 0 {
+			fmt.Printf("❌ %d ligne(s) en erreur : aucun tag importé\n", len(plan.Unknown))
+			os.Exit(1)
+		}
+
+		for _, g := range plan.Groups {
+			internal.CreateTag(ctx, conn, tagWorkspaceID, g.Label, g.Value, g.ElementType, g.ElementSubType)
+		}
+		results, err := internal.SetTagAssignmentGroups(ctx, conn, tagWorkspaceID, plan.Groups, false)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		ok := true
+		for i, g := range plan.Groups {
+			ok = printAssignmentResults(g.Label, g.Value, results[i], false) && ok
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+// init configure les flags de `tags export` et `tags import` et les attache à `tag`.
+func init() {
+	tagExportCmd.Flags().StringVar(&tagExportFormat, "format", "csv", "Format d'export (csv ou json)")
+	tagExportCmd.Flags().StringVarP(&tagCSVFile, "file", "f", "", "Fichier produit (sortie standard par défaut)")
+	tagExportCmd.Flags().StringVar(&tagWorkspaceID, "workspace", "", "Exporter les tags d'un workspace (mainline par défaut)")
+	tagImportCmd.Flags().StringVarP(&tagCSVFile, "file", "f", "", "Fichier CSV à importer (obligatoire)")
+	tagImportCmd.Flags().StringVar(&tagWorkspaceID, "workspace", "", "ID du workspace (obligatoire)")
+	tagCmd.AddCommand(tagExportCmd, tagImportCmd)
+}
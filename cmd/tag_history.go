@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// historyDevice est le flag CLI `--device` de `tags history` : hostname ou ID du device.
+var historyDevice string
+
+// historySince est le flag CLI `--since` de `tags history` (ex : "30d", "2025-06-01").
+var historySince string
+
+// historyTimeout est la durée maximale de `tags history`, attribution comprise.
+var historyTimeout time.Duration
+
+// tagHistoryCmd affiche la chronologie des ajouts (+) et retraits (-) de tags d'un
+// device sur mainline, avec le workspace soumis qui a porté chaque modification
+// lorsqu'il a pu être identifié.
+var tagHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Afficher l'historique des tags d'un device",
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyDevice == "" {
+			fmt.Println("❌ Veuillez spécifier un device avec --device")
+			os.Exit(1)
+		}
+		since, err := internal.ParseSince(historySince, time.Now())
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, historyTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		var device *internal.DeviceInfo
+		for _, d := range internal.ReadInventory(ctx, conn, "", false, false) {
+			if d.Hostname == historyDevice || d.DeviceID == historyDevice {
+				device = &d
+				break
+			}
+		}
+		if device == nil {
+			fmt.Printf("❌ Device introuvable : %s\n", historyDevice)
+			os.Exit(1)
+		}
+
+		events, err := internal.GetTagHistory(ctx, conn, device.DeviceID, tagLabel, since)
+		if err != nil {
+			fmt.Printf("❌ Erreur lecture de l'historique des tags : %v\n", err)
+			os.Exit(1)
+		}
+		if jsonOutput() {
+			if events == nil {
+				events = []internal.TagHistoryEvent{}
+			}
+			printJSON(events)
+			return
+		}
+		fmt.Printf("📟 %s (%s) depuis le %s\n", device.Hostname, device.DeviceID, since.Format("2006-01-02 15:04"))
+		if len(events) == 0 {
+			fmt.Println("ℹ️  Aucune modification de tag sur la période")
+			return
+		}
+		for _, e := range events {
+			target := ""
+			if e.InterfaceID != "" {
+				target = " sur " + e.InterfaceID
+			}
+			origin := "workspace inconnu"
+			if e.WorkspaceID != "" {
+				origin = fmt.Sprintf("workspace %s (%s)", e.WorkspaceName, e.WorkspaceID)
+			}
+			fmt.Printf("   %s %s %s=%s%s — %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), changeSymbols[e.Action], e.Label, e.Value, target, origin)
+		}
+	},
+}
+
+// init configure les flags de `tags history` et l'attache à `tag`.
+func init() {
+	tagHistoryCmd.Flags().StringVar(&historyDevice, "device", "", "Hostname ou ID du device (obligatoire)")
+	tagHistoryCmd.Flags().StringVar(&tagLabel, "label", "", "Limiter l'historique à un label (ex: site)")
+	tagHistoryCmd.Flags().StringVar(&historySince, "since", "30d", "Début de la période (ex: 30d, 2w, 12h, 2025-06-01)")
+	tagHistoryCmd.Flags().DurationVar(&historyTimeout, "timeout", 2*time.Minute, "Durée maximale de la commande")
+	tagCmd.AddCommand(tagHistoryCmd)
+}
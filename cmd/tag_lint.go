@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// lintRequire est le flag CLI `--require` : labels que chaque device doit porter.
+var lintRequire []string
+
+// lintSingle est le flag CLI `--single` : labels à valeur unique par device, en plus
+// des labels obligatoires.
+var lintSingle []string
+
+// lintCleanup est un flag CLI indiquant que `tags lint` doit supprimer les tags
+// inutilisés et les assignations orphelines dans un nouveau workspace.
+var lintCleanup bool
+
+// tagLintCmd signale les incohérences des tags de mainline : tags inutilisés,
+// devices sans label obligatoire, devices avec plusieurs valeurs d'un label à valeur
+// unique, labels n'ayant qu'une valeur sur toute la flotte, et assignations vers des
+// devices absents de l'inventaire.
+//
+// Sans --cleanup, la commande se termine en erreur si un problème est trouvé.
+// Avec --cleanup, les tags inutilisés et les assignations orphelines sont supprimés
+// dans un nouveau workspace (flags --name, --build, --submit et --timeout de `tags apply`).
+var tagLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Signaler les tags inutilisés, manquants ou incohérents",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, tagApplyTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		devices := internal.ReadInventory(ctx, conn, "", false, false)
+		report := internal.LintTags(ctx, conn, devices, lintRequire, lintSingle)
+		if jsonOutput() {
+			printJSON(report)
+		} else {
+			printTagLintReport(report)
+		}
+		if !lintCleanup {
+			if report.Count() > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		cleanup := report.Cleanup()
+		if cleanup.Count() == 0 {
+			fmt.Println("ℹ️  Rien à nettoyer")
+			return
+		}
+		printWorkspaceChanges(cleanup)
+		applyTagPlan(ctx, conn, cleanup, "tags lint --cleanup", "", "Nettoyage des tags (tags lint)")
+	},
+}
+
+// printTagLintReport affiche un rapport de lint groupé par type de problème.
+func printTagLintReport(r internal.TagLintReport) {
+	if r.Count() == 0 {
+		fmt.Println("✅ Aucun problème détecté")
+		return
+	}
+	if len(r.UnusedTags) > 0 {
+		fmt.Printf("🏷️  Tags inutilisés (%d)\n", len(r.UnusedTags))
+		for _, t := range r.UnusedTags {
+			fmt.Printf("   %s=%s (%s)\n", t.Label, t.Value, t.ElementType)
+		}
+	}
+	if len(r.MissingLabels) > 0 {
+		fmt.Printf("❓ Labels obligatoires manquants (%d)\n", len(r.MissingLabels))
+		for _, d := range r.MissingLabels {
+			fmt.Printf("   %s (%s) : %s\n", d.Hostname, d.DeviceID, strings.Join(d.Labels, ", "))
+		}
+	}
+	if len(r.MultiValued) > 0 {
+		fmt.Printf("🔀 Labels à valeurs multiples (%d)\n", len(r.MultiValued))
+		for _, m := range r.MultiValued {
+			fmt.Printf("   %s (%s) : %s = %s\n", m.Hostname, m.DeviceID, m.Label, strings.Join(m.Values, ", "))
+		}
+	}
+	if len(r.SingleValued) > 0 {
+		fmt.Printf("1️⃣  Labels à valeur unique sur toute la flotte (%d)\n", len(r.SingleValued))
+		for _, l := range r.SingleValued {
+			fmt.Printf("   %s=%s (%s) : %d élément(s)\n", l.Label, l.Value, l.ElementType, l.Assigned)
+		}
+	}
+	if len(r.Orphans) > 0 {
+		fmt.Printf("👻 Assignations vers des devices absents de l'inventaire (%d)\n", len(r.Orphans))
+		for _, a := range r.Orphans {
+			target := a.DeviceID
+			if a.InterfaceID != "" {
+				target += ":" + a.InterfaceID
+			}
+			fmt.Printf("   %s=%s → %s (%s)\n", a.Label, a.Value, target, a.CreatorType)
+		}
+	}
+}
+
+// init configure les flags de `tags lint` et l'attache à `tag`.
+func init() {
+	tagLintCmd.Flags().StringSliceVar(&lintRequire, "require", nil, "Labels obligatoires sur chaque device (ex: site,role)")
+	tagLintCmd.Flags().StringSliceVar(&lintSingle, "single", nil, "Labels à valeur unique, en plus des labels obligatoires")
+	tagLintCmd.Flags().BoolVar(&lintCleanup, "cleanup", false, "Supprimer les tags inutilisés et les assignations orphelines dans un workspace")
+	tagLintCmd.Flags().StringVar(&tagApplyName, "name", "", "Nom du workspace de nettoyage (par défaut : « tags <date> »)")
+	tagLintCmd.Flags().BoolVar(&tagApplyBuild, "build", false, "Builder le workspace de nettoyage")
+	tagLintCmd.Flags().BoolVar(&tagApplySubmit, "submit", false, "Builder puis soumettre le workspace de nettoyage")
+	tagLintCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place le workspace créé")
+	tagLintCmd.Flags().DurationVar(&tagApplyTimeout, "timeout", 5*time.Minute, "Durée maximale de la commande, build et soumission compris")
+	tagCmd.AddCommand(tagLintCmd)
+}
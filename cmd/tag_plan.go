@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// tagFilePath est le flag CLI `-f` donnant le fichier de tags déclaratif.
+var tagFilePath string
+
+// tagPrune est un flag CLI indiquant que les assignations non déclarées dans le
+// fichier (pour les labels qu'il gère) doivent être retirées.
+var tagPrune bool
+
+// tagApplyName est le nom du workspace créé par `tag apply`.
+var tagApplyName string
+
+// tagApplyBuild et tagApplySubmit indiquent si `tag apply` doit builder, puis
+// soumettre, le workspace créé.
+var tagApplyBuild, tagApplySubmit bool
+
+// tagApplyTimeout est la durée maximale de `tag apply`, build et soumission compris.
+var tagApplyTimeout time.Duration
+
+// tagPlanCmd affiche les modifications nécessaires pour que mainline corresponde au
+// fichier de tags : tags à créer (+), assignations à ajouter (+) et, avec --prune,
+// assignations à retirer (-). Aucune écriture n'est faite.
+var tagPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Comparer le fichier de tags à mainline",
+	Run: func(cmd *cobra.Command, args []string) {
+		file := loadTagFile()
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		plan := internal.PlanTags(ctx, conn, file, internal.ReadInventory(ctx, conn, "", false, false), tagPrune)
+		if jsonOutput() {
+			printJSON(plan)
+			return
+		}
+		printTagPlan(plan)
+	},
+}
+
+// tagApplyCmd applique le fichier de tags : il calcule le plan, crée un workspace,
+// y écrit les modifications, puis, avec --build ou --submit, le build et le soumet.
+// L'exécution est journalisée (voir applyTagPlan).
+var tagApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Appliquer le fichier de tags dans un nouveau workspace",
+	Run: func(cmd *cobra.Command, args []string) {
+		file := loadTagFile()
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, tagApplyTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		plan := internal.PlanTags(ctx, conn, file, internal.ReadInventory(ctx, conn, "", false, false), tagPrune)
+		printTagPlan(plan)
+		if plan.Count() == 0 {
+			return
+		}
+
+		applyTagPlan(ctx, conn, plan, "tags apply", tagFilePath, "Tags appliqués depuis "+tagFilePath)
+	},
+}
+
+// applyTagPlan crée un workspace (nommé par --name, ou « tags <date> »), y écrit un plan
+// de modifications, puis le builde (--build) ou le builde et le soumet (--submit).
+// L'exécution est un run journalisé (titre title, origine source) repris par
+// `run resume`. La commande s'arrête en erreur au premier échec, après avoir compensé
+// les opérations effectuées (abandon du workspace créé) sauf avec --keep-on-failure.
+func applyTagPlan(ctx context.Context, conn *grpc.ClientConn, plan internal.WorkspaceChanges, title, source, description string) {
+	name := tagApplyName
+	if name == "" {
+		name = "tags " + time.Now().Format("2006-01-02 15:04:05")
+	}
+	journal, ok := runChanges(ctx, conn, title, source, name, description, plan, tagApplyBuild, tagApplySubmit)
+	if !ok {
+		os.Exit(1)
+	}
+	if !jsonOutput() && !tagApplyBuild && !tagApplySubmit {
+		fmt.Printf("ℹ️  Workspace %s prêt : buildez-le et soumettez-le depuis CloudVision\n", journal.Output("ws", "id"))
+	}
+}
+
+// loadTagFile lit le fichier de tags désigné par -f, ou arrête la commande.
+func loadTagFile() internal.TagFile {
+	file, err := internal.LoadTagFile(tagFilePath)
+	if err != nil {
+		fmt.Printf("❌ Fichier de tags : %v\n", err)
+		os.Exit(1)
+	}
+	return file
+}
+
+// printTagPlan affiche un plan de tags au format changelog.
+func printTagPlan(plan internal.WorkspaceChanges) {
+	if plan.Count() == 0 {
+		fmt.Println("✅ Mainline est conforme au fichier de tags")
+		return
+	}
+	printWorkspaceChanges(plan)
+}
+
+// init configure les flags de `tag plan` et `tag apply` et les attache à `tag`.
+func init() {
+	for _, c := range []*cobra.Command{tagPlanCmd, tagApplyCmd} {
+		c.Flags().StringVarP(&tagFilePath, "file", "f", "data/tag.yaml", "Fichier de tags déclaratif")
+		c.Flags().BoolVar(&tagPrune, "prune", false, "Retirer les assignations non déclarées pour les labels du fichier")
+		tagCmd.AddCommand(c)
+	}
+	tagApplyCmd.Flags().StringVar(&tagApplyName, "name", "", "Nom du workspace créé (par défaut : « tags <date> »)")
+	tagApplyCmd.Flags().BoolVar(&tagApplyBuild, "build", false, "Builder le workspace après écriture")
+	tagApplyCmd.Flags().BoolVar(&tagApplySubmit, "submit", false, "Builder puis soumettre le workspace")
+	tagApplyCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place le workspace créé")
+	tagApplyCmd.Flags().DurationVar(&tagApplyTimeout, "timeout", 5*time.Minute, "Durée maximale de la commande, build et soumission compris")
+}
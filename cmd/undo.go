@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// undoYes est un flag CLI appliquant l'annulation sans demander de confirmation.
+var undoYes bool
+
+// undoList est un flag CLI listant les opérations journalisées au lieu d'en annuler une.
+var undoList bool
+
+// undoName est un flag CLI donnant le nom du workspace créé par `undo`.
+var undoName string
+
+// undoCmd annule une opération de la CLI (la dernière non annulée du profil par
+// défaut) : les ressources qu'elle a modifiées retrouvent, dans un nouveau workspace,
+// leur valeur sur mainline avant l'opération. Les modifications sont affichées et
+// confirmées avant d'être écrites ; le workspace reste à builder et soumettre.
+//
+// Les ressources déjà dans leur état antérieur (écritures jamais soumises, workspace
+// abandonné) sont ignorées. Sans argument, les opérations sans effet sur mainline
+// (workspaces abandonnés ou compensés) et celles de `undo` lui-même sont écartées :
+// un second `undo` annule l'opération précédente au lieu de rétablir la première.
+var undoCmd = &cobra.Command{
+	Use:   "undo [op-id]",
+	Short: "Annuler une opération de la CLI dans un nouveau workspace",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if undoList {
+			ops, err := internal.ListOperations(profileName)
+			if err != nil {
+				fmt.Printf("❌ Lecture du journal des opérations : %v\n", err)
+				os.Exit(1)
+			}
+			printOperationList(ops)
+			return
+		}
+
+		var op *internal.Operation
+		var err error
+		if len(args) == 1 {
+			op, err = internal.LoadOperation(args[0])
+		} else {
+			op, err = internal.LastOperation(profileName)
+		}
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if op.Profile != profileName {
+			fmt.Printf("❌ Opération %s effectuée avec le profil %s : relancez avec --profile %s\n", op.ID, op.Profile, op.Profile)
+			os.Exit(1)
+		}
+
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		fmt.Printf("🧾 Opération %s du %s : %s\n", op.ID, op.Time.Local().Format("2006-01-02 15:04"), op.Command)
+		if op.UndoneBy != "" {
+			fmt.Printf("⚠️  Opération déjà annulée par le workspace %s\n", op.UndoneBy)
+		}
+		changes, unchanged := op.UndoChanges(ctx, conn)
+		if unchanged > 0 {
+			fmt.Printf("ℹ️  %d ressource(s) déjà dans leur état antérieur, ignorée(s)\n", unchanged)
+		}
+		if changes.Count() == 0 {
+			for _, u := range changes.Unreadable {
+				fmt.Printf("⚠️  %s illisible : %s\n", u.Kind, u.Error)
+			}
+			fmt.Println("ℹ️  Rien à annuler")
+			return
+		}
+		fmt.Println("↩️  Modifications d'annulation :")
+		printWorkspaceChanges(changes)
+
+		name := undoName
+		if name == "" {
+			name = "undo " + op.ID
+		}
+		if !undoYes && !confirm(fmt.Sprintf("Appliquer ces modifications dans un nouveau workspace %q ?", name)) {
+			os.Exit(1)
+		}
+		internal.MarkOperationUndo(op.ID)
+		created := newWorkspace(ctx, conn, "", "", name, fmt.Sprintf("Annulation de l'opération %s (%s)", op.ID, op.Command))
+		report := internal.ApplyWorkspaceChanges(ctx, conn, created.ID, changes)
+		if err := op.MarkUndone(created.ID); err != nil {
+			fmt.Printf("⚠️  Journal des opérations non mis à jour : %v\n", err)
+		}
+		if !printApplyReport(report, nil) || len(changes.Unreadable) > 0 {
+			fmt.Printf("⚠️  Annulation partielle dans le workspace %s\n", created.ID)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Annulation prête dans le workspace %s : à builder et soumettre\n", created.ID)
+	},
+}
+
+// printOperationList affiche les opérations journalisées, de la plus récente à la plus ancienne.
+func printOperationList(ops []*internal.Operation) {
+	if jsonOutput() {
+		printJSON(ops)
+		return
+	}
+	if len(ops) == 0 {
+		fmt.Println("ℹ️  Aucune opération journalisée")
+		return
+	}
+	for _, op := range ops {
+		status := "✏️ "
+		switch {
+		case op.UndoneBy != "":
+			status = "↩️ "
+		case op.Discarded():
+			status = "🗑️ "
+		case op.Undoes != "":
+			status = "⏪"
+		}
+		fmt.Printf("%s %s  %s  %3d modification(s)  %s\n", status, op.ID,
+			op.Time.Local().Format("2006-01-02 15:04"), op.Changes.Count(), op.Command)
+	}
+}
+
+// init configure les flags de `undo` et l'attache à la racine du CLI.
+func init() {
+	undoCmd.Flags().BoolVarP(&undoYes, "yes", "y", false, "Appliquer l'annulation sans demander de confirmation")
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "Lister les opérations journalisées du profil")
+	undoCmd.Flags().StringVar(&undoName, "name", "", "Nom du workspace d'annulation (par défaut : undo <op-id>)")
+	rootCmd.AddCommand(undoCmd)
+}
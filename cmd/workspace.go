@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// pruneDryRun est un flag CLI indiquant que `workspace registry prune` doit seulement
+// afficher les entrées qui seraient supprimées.
+var pruneDryRun bool
+
+// syncAdopt est un flag CLI rattachant au profil courant les entrées du registre sans
+// profil lors de `workspace registry sync`.
+var syncAdopt bool
+
+// workspaceCmd est la commande principale `workspace` du CLI, qui regroupe les
+// opérations portant sur des workspaces existants.
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Gérer les workspaces CVaaS existants",
+}
+
+// workspaceRegistryCmd regroupe les sous-commandes du registre local des workspaces
+// créés par la CLI (`$XDG_STATE_HOME/cvaas-cli/workspace.yaml`).
+var workspaceRegistryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Gérer le registre local des workspaces créés par la CLI",
+}
+
+// workspaceRegistryListCmd affiche les entrées du registre local, tous profils confondus,
+// avec l'état connu lors de la dernière synchronisation.
+var workspaceRegistryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Afficher le registre local des workspaces",
+	Run: func(cmd *cobra.Command, args []string) {
+		registry, err := internal.LoadRegistry()
+		if err != nil {
+			fmt.Printf("❌ Erreur lecture du registre : %v\n", err)
+			os.Exit(1)
+		}
+		if len(registry.Workspace) == 0 {
+			fmt.Println("ℹ️  Registre vide")
+			return
+		}
+		for _, e := range registry.Workspace {
+			printRegistryEntry(e)
+		}
+	},
+}
+
+// workspaceRegistrySyncCmd met à jour les entrées du profil courant avec l'état
+// et la date de création lus sur CVaaS. Les entrées sans profil ne sont rattachées
+// au profil courant qu'avec --adopt.
+var workspaceRegistrySyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Synchroniser le registre local avec CloudVision",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		synced, err := internal.SyncRegistry(ctx, conn, profileName, syncAdopt)
+		if err != nil {
+			fmt.Printf("❌ Erreur synchronisation du registre : %v\n", err)
+			os.Exit(1)
+		}
+		for _, e := range synced {
+			printRegistryEntry(e)
+		}
+		fmt.Printf("✅ %d entrée(s) synchronisée(s) pour le profil %s\n", len(synced), profileName)
+	},
+}
+
+// workspaceRegistryPruneCmd supprime du registre les workspaces abandonnés ou
+// inconnus de CVaaS pour le profil courant.
+var workspaceRegistryPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Supprimer du registre les workspaces abandonnés ou inconnus",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		removed, err := internal.PruneRegistry(ctx, conn, profileName, pruneDryRun)
+		if err != nil {
+			fmt.Printf("❌ Erreur nettoyage du registre : %v\n", err)
+			os.Exit(1)
+		}
+		for _, e := range removed {
+			fmt.Printf("🗑️  %s (%s) - State: %s\n", e.WorkspaceName, e.WorkspaceID, e.State)
+		}
+		if pruneDryRun {
+			fmt.Printf("ℹ️  %d entrée(s) seraient supprimée(s) (--dry-run)\n", len(removed))
+			return
+		}
+		fmt.Printf("✅ %d entrée(s) supprimée(s)\n", len(removed))
+	},
+}
+
+// printRegistryEntry affiche une entrée du registre local sur une ligne.
+func printRegistryEntry(e internal.WorkspaceEntry) {
+	state := e.State
+	if state == "" {
+		state = "?"
+	}
+	created := "?"
+	if !e.CreatedAt.IsZero() {
+		created = e.CreatedAt.Local().Format("2006-01-02 15:04")
+	}
+	fmt.Printf("📒 [%s] %s (%s) - State: %s - Créé : %s\n", e.Profile, e.WorkspaceName, e.WorkspaceID, state, created)
+}
+
+// init attache les sous-commandes du registre à `workspace registry`, puis
+// enregistre `workspace` dans la racine du CLI.
+func init() {
+	workspaceRegistrySyncCmd.Flags().BoolVar(&syncAdopt, "adopt", false, "Rattacher au profil courant les entrées sans profil (anciens enregistrements)")
+	workspaceRegistryPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Afficher les entrées à supprimer sans modifier le registre")
+	workspaceRegistryCmd.AddCommand(workspaceRegistryListCmd, workspaceRegistrySyncCmd, workspaceRegistryPruneCmd)
+	workspaceCmd.AddCommand(workspaceRegistryCmd)
+	rootCmd.AddCommand(workspaceCmd)
+}
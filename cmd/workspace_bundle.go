@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// bundleFile est un flag CLI donnant le chemin du bundle lu ou écrit.
+var bundleFile string
+
+// importName est un flag CLI donnant le nom du workspace créé par `workspace import`.
+var importName string
+
+// importAllowMissing est un flag CLI autorisant l'import sans les assignations dont
+// l'équipement est absent de l'inventaire cible.
+var importAllowMissing bool
+
+// workspaceExportCmd sérialise dans un bundle YAML versionné toutes les modifications
+// d'un workspace (tags, assignations, inputs de studios, configlets), pour les
+// rejouer sur un autre tenant avec `workspace import`. Si un type de ressource est
+// illisible, aucun bundle n'est écrit et la commande sort en erreur.
+var workspaceExportCmd = &cobra.Command{
+	Use:   "export <workspace-id>",
+	Short: "Exporter les modifications d'un workspace dans un bundle YAML",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if bundleFile == "" {
+			fmt.Println("❌ Veuillez spécifier un fichier avec -f")
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		ws := internal.FindWorkspace(ctx, conn, args[0], "")
+		if ws == nil {
+			fmt.Printf("❌ Workspace introuvable : %s\n", args[0])
+			os.Exit(1)
+		}
+		changes := internal.ReadWorkspaceChanges(ctx, conn, ws.ID)
+		for _, u := range changes.Unreadable {
+			fmt.Printf("⚠️  %s illisible : %s\n", u.Kind, u.Error)
+		}
+		if len(changes.Unreadable) > 0 {
+			fmt.Printf("❌ Export interrompu : %d type(s) de ressource illisible(s), le bundle serait incomplet\n", len(changes.Unreadable))
+			os.Exit(1)
+		}
+		devices, err := internal.ListInventory(ctx, conn, "", false, false)
+		if err != nil {
+			fmt.Printf("❌ Inventaire illisible, équipements du bundle non résolus : %v\n", err)
+			os.Exit(1)
+		}
+		if err := internal.WriteBundle(bundleFile, internal.NewBundle(*ws, profileName, changes, devices)); err != nil {
+			fmt.Printf("❌ Erreur écriture du bundle : %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ %d modification(s) exportée(s) dans %s\n", changes.Count(), bundleFile)
+	},
+}
+
+// workspaceImportCmd crée un workspace à partir d'un bundle et y applique ses
+// modifications. Les équipements des assignations de tags, des inputs de studios et
+// des requêtes d'assignation sont remappés par hostname sur l'inventaire du tenant
+// cible (sélectionné par `--profile` ou `--token/--url`). Un équipement cité dans un
+// input ou une requête et absent du tenant cible interrompt l'import.
+// Si une modification n'a pu être écrite, le workspace créé est abandonné, sauf avec
+// --keep-on-failure.
+var workspaceImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Créer un workspace à partir d'un bundle YAML",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if bundleFile == "" {
+			fmt.Println("❌ Veuillez spécifier un fichier avec -f")
+			os.Exit(1)
+		}
+		bundle, err := internal.ReadBundle(bundleFile)
+		if err != nil {
+			fmt.Printf("❌ Bundle invalide : %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		changes := bundle.Changes
+		if len(changes.TagAssignments) > 0 || len(bundle.Devices) > 0 {
+			var dropped []internal.TagAssignmentChange
+			var unresolved []internal.BundleDevice
+			changes, dropped, unresolved = internal.RemapDevices(bundle, internal.ReadInventory(ctx, conn, "", false, false))
+			if len(unresolved) > 0 {
+				fmt.Printf("⚠️  %d équipement(s) cité(s) par des inputs de studios ou des requêtes d'assignation absent(s) de l'inventaire cible :\n", len(unresolved))
+				for _, d := range unresolved {
+					fmt.Printf("   - %s (%s)\n", d.Hostname, d.DeviceID)
+				}
+				fmt.Println("❌ Import interrompu : ces références ne peuvent pas être retirées sans modifier les inputs ou les requêtes")
+				os.Exit(1)
+			}
+			if len(dropped) > 0 {
+				fmt.Printf("⚠️  %d assignation(s) sans équipement dans l'inventaire cible :\n", len(dropped))
+				for _, a := range dropped {
+					reason := "hostname absent de l'inventaire cible"
+					if a.Hostname == "" {
+						reason = "sans hostname"
+					}
+					fmt.Printf("   - %s (%s)\n", a, reason)
+				}
+				if !importAllowMissing {
+					fmt.Println("❌ Import interrompu (--allow-missing pour importer sans ces assignations)")
+					os.Exit(1)
+				}
+				fmt.Printf("⏭️  %d assignation(s) ignorée(s)\n", len(dropped))
+			}
+		}
+
+		name := importName
+		if name == "" {
+			name = fmt.Sprintf("Import de %s", bundle.Metadata.SourceWorkspaceName)
+		}
+		description := fmt.Sprintf("Importé depuis %s (%s)", bundle.Metadata.SourceWorkspaceID, bundle.Metadata.Profile)
+		populateWorkspace(ctx, conn, "", "", name, description, changes, nil)
+	},
+}
+
+// init configure les flags de `workspace export` et `workspace import` et les attache à `workspace`.
+func init() {
+	workspaceExportCmd.Flags().StringVarP(&bundleFile, "file", "f", "", "Fichier bundle à écrire (obligatoire)")
+	workspaceImportCmd.Flags().StringVarP(&bundleFile, "file", "f", "", "Fichier bundle à lire (obligatoire)")
+	workspaceImportCmd.Flags().StringVar(&importName, "name", "", "Nom du workspace créé")
+	workspaceImportCmd.Flags().BoolVar(&importAllowMissing, "allow-missing", false, "Importer sans les assignations d'équipements absents de l'inventaire cible")
+	workspaceImportCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place le workspace créé")
+	workspaceCmd.AddCommand(workspaceExportCmd, workspaceImportCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// workspaceChangesCmd affiche le changelog d'un workspace : toutes les ressources de
+// configuration qu'il écrit (tags, assignations de tags, inputs et assignations de
+// studios, configlets et leurs assignations), regroupées par type et qualifiées
+// d'ajout (+), de suppression (-) ou de modification (~).
+var workspaceChangesCmd = &cobra.Command{
+	Use:   "changes <workspace-id>",
+	Short: "Lister les modifications en attente dans un workspace",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		changes := internal.ReadWorkspaceChanges(ctx, conn, args[0])
+		if jsonOutput() {
+			printJSON(changes)
+			return
+		}
+		printWorkspaceChanges(changes)
+	},
+}
+
+// printWorkspaceChanges affiche un changelog groupé par type de ressource.
+func printWorkspaceChanges(changes internal.WorkspaceChanges) {
+	if changes.Count() == 0 {
+		fmt.Println("ℹ️  Aucune modification dans ce workspace")
+	}
+	if len(changes.Tags) > 0 {
+		fmt.Printf("🏷️  Tags (%d)\n", len(changes.Tags))
+		for _, c := range changes.Tags {
+			printChange(c.Action, c.String())
+		}
+	}
+	if len(changes.TagAssignments) > 0 {
+		fmt.Printf("📌 Assignations de tags (%d)\n", len(changes.TagAssignments))
+		for _, c := range changes.TagAssignments {
+			printChange(c.Action, c.String())
+		}
+	}
+	if len(changes.StudioInputs) > 0 {
+		fmt.Printf("🎛️  Inputs de studios (%d)\n", len(changes.StudioInputs))
+		for _, c := range changes.StudioInputs {
+			printChange(c.Action, c.String())
+		}
+	}
+	if len(changes.StudioAssignments) > 0 {
+		fmt.Printf("🎯 Assignations de studios (%d)\n", len(changes.StudioAssignments))
+		for _, c := range changes.StudioAssignments {
+			printChange(c.Action, c.String())
+		}
+	}
+	if len(changes.Configlets) > 0 {
+		fmt.Printf("📄 Configlets (%d)\n", len(changes.Configlets))
+		for _, c := range changes.Configlets {
+			printChange(c.Action, c.String())
+		}
+	}
+	if len(changes.ConfigletAssignments) > 0 {
+		fmt.Printf("🔗 Assignations de configlets (%d)\n", len(changes.ConfigletAssignments))
+		for _, c := range changes.ConfigletAssignments {
+			printChange(c.Action, c.String())
+		}
+	}
+	for _, u := range changes.Unreadable {
+		fmt.Printf("⚠️  %s illisible : %s\n", u.Kind, u.Error)
+	}
+}
+
+// changeSymbols associe un symbole de changelog à chaque action.
+var changeSymbols = map[internal.ChangeAction]string{
+	internal.ChangeAdd:    "+",
+	internal.ChangeRemove: "-",
+	internal.ChangeModify: "~",
+}
+
+// printChange affiche une ligne de changelog.
+func printChange(action internal.ChangeAction, description string) {
+	fmt.Printf("   %s %s\n", changeSymbols[action], description)
+}
+
+// init attache `workspace changes` à `workspace`.
+func init() {
+	workspaceCmd.AddCommand(workspaceChangesCmd)
+}
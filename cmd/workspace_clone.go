@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// cloneName est un flag CLI donnant le nom du workspace créé par `workspace clone`.
+var cloneName string
+
+// cloneDescription est un flag CLI donnant la description du workspace cloné.
+var cloneDescription string
+
+// workspaceCloneCmd lit toutes les ressources de configuration écrites dans un workspace
+// (tags, assignations, inputs de studios, configlets) et les rejoue dans un nouveau
+// workspace. Utile pour reconstruire un workspace en CONFLICTS ou abandonné.
+//
+// Les types de ressources qui n'ont pu être ni lus dans la source ni écrits dans la
+// cible sont listés à la fin ; la commande se termine alors en erreur, après avoir
+// abandonné le nouveau workspace sauf avec --keep-on-failure.
+var workspaceCloneCmd = &cobra.Command{
+	Use:   "clone <workspace-id>",
+	Short: "Cloner les modifications d'un workspace dans un nouveau workspace",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if cloneName == "" {
+			fmt.Println("❌ Veuillez spécifier un nom avec --name")
+			os.Exit(1)
+		}
+		ctx, cancel, conn := internal.Connect(tokenPath, urlPath)
+		defer cancel()
+		defer conn.Close()
+
+		changes := internal.ReadWorkspaceChanges(ctx, conn, args[0])
+		fmt.Printf("📝 %d modification(s) lue(s) dans %s\n", changes.Count(), args[0])
+
+		description := cloneDescription
+		if description == "" {
+			description = fmt.Sprintf("Clone de %s", args[0])
+		}
+		populateWorkspace(ctx, conn, "", "", cloneName, description, changes, changes.Unreadable)
+	},
+}
+
+// populateWorkspace crée un workspace (voir newWorkspace), y écrit changes et affiche le
+// décompte des ressources écrites (voir printApplyReport). unreadable liste les types de
+// ressources illisibles dans la source. En cas d'échec (écriture incomplète, source
+// partiellement illisible ou panique d'un appel CVaaS), le workspace créé est abandonné
+// (voir compensate) sauf avec --keep-on-failure, et la commande s'arrête en erreur.
+func populateWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID, name, description string, changes internal.WorkspaceChanges, unreadable []internal.ResourceError) internal.WorkspaceInfo {
+	compensator := internal.NewCompensator()
+	ctx = internal.WithCompensator(ctx, compensator)
+	defer func() {
+		if r := recover(); r != nil {
+			compensate(ctx, compensator)
+			panic(r)
+		}
+	}()
+	created := newWorkspace(ctx, conn, workspaceID, requestID, name, description)
+	if !printApplyReport(internal.ApplyWorkspaceChanges(ctx, conn, created.ID, changes), unreadable) {
+		compensate(ctx, compensator)
+		os.Exit(1)
+	}
+	return created
+}
+
+// printApplyReport affiche le décompte des ressources écrites puis les types de
+// ressources non copiés (illisibles dans la source ou en échec d'écriture).
+// Retourne false si au moins un type n'a pas pu être copié.
+func printApplyReport(report internal.ApplyReport, unreadable []internal.ResourceError) bool {
+	for _, kind := range []string{
+		internal.KindTag, internal.KindTagAssignment, internal.KindStudioInput,
+		internal.KindStudioAssignment, internal.KindConfiglet, internal.KindConfigletAssignment,
+	} {
+		if n := report.Applied[kind]; n > 0 {
+			fmt.Printf("✅ %s : %d écrit(s)\n", kind, n)
+		}
+	}
+	for _, u := range unreadable {
+		fmt.Printf("⚠️  %s non copié (lecture) : %s\n", u.Kind, u.Error)
+	}
+	for _, f := range report.Failed {
+		fmt.Printf("⚠️  %s non copié (écriture) : %s\n", f.Kind, f.Error)
+	}
+	return len(unreadable) == 0 && len(report.Failed) == 0
+}
+
+// init configure les flags de `workspace clone` et l'attache à `workspace`.
+func init() {
+	workspaceCloneCmd.Flags().StringVar(&cloneName, "name", "", "Nom du nouveau workspace (obligatoire)")
+	workspaceCloneCmd.Flags().StringVar(&cloneDescription, "description", "", "Description du nouveau workspace")
+	workspaceCloneCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec, laisser en place le workspace créé")
+	workspaceCmd.AddCommand(workspaceCloneCmd)
+}
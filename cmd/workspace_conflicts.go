@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// rebaseCloneName est un flag CLI donnant le nom du workspace créé lorsque le rebase
+// n'est pas possible et que les modifications sans conflit sont clonées.
+var rebaseCloneName string
+
+// rebaseYes est un flag CLI acceptant sans confirmation le clonage de repli.
+var rebaseYes bool
+
+// conflictsTimeout est la durée maximale de `workspace conflicts`.
+var conflictsTimeout time.Duration
+
+// rebaseTimeout est la durée maximale de `workspace rebase`, clonage de repli compris.
+var rebaseTimeout time.Duration
+
+// workspaceConflictsCmd affiche les ressources d'un workspace modifiées sur mainline
+// depuis sa création ou son dernier rebase, avec l'auteur et la date de chaque
+// modification mainline.
+var workspaceConflictsCmd = &cobra.Command{
+	Use:   "conflicts <workspace-id>",
+	Short: "Afficher les ressources d'un workspace en conflit avec mainline",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, conflictsTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		ws, _, conflicts := inspectConflicts(ctx, conn, args[0])
+		if jsonOutput() {
+			printJSON(conflicts)
+			return
+		}
+		fmt.Printf("🧪 %s (%s) - State: %s\n", ws.DisplayName, ws.ID, ws.State)
+		if len(conflicts) == 0 {
+			fmt.Println("✅ Aucun conflit détecté avec mainline")
+			return
+		}
+		printConflicts(conflicts)
+	},
+}
+
+// workspaceRebaseCmd demande à CVaaS de rebaser un workspace sur mainline.
+//
+// Si l'API refuse la requête ou si le rebase échoue, la commande propose de cloner
+// les modifications sans conflit dans un nouveau workspace (`--yes` pour accepter
+// sans confirmation, `--clone-name` pour nommer le nouveau workspace). L'attente du
+// rebase est bornée à la moitié de `--timeout`, pour laisser au repli le temps de
+// s'exécuter.
+var workspaceRebaseCmd = &cobra.Command{
+	Use:   "rebase <workspace-id>",
+	Short: "Rebaser un workspace sur mainline, ou cloner ses modifications sans conflit",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, rebaseTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		rebaseCtx, cancelRebase := context.WithTimeout(ctx, rebaseTimeout/2)
+		defer cancelRebase()
+		requestID, err := internal.RequestWorkspace(rebaseCtx, conn, args[0], workspace.Request_REQUEST_REBASE)
+		if err == nil {
+			err = internal.WaitForRequest(rebaseCtx, conn, args[0], requestID)
+			if err == nil {
+				fmt.Printf("✅ Workspace %s rebasé sur mainline\n", args[0])
+				return
+			}
+			fmt.Printf("⚠️  Rebase en échec : %v\n", err)
+		} else {
+			fmt.Printf("⚠️  Rebase refusé par CVaaS : %v\n", err)
+		}
+
+		ws, changes, conflicts := inspectConflicts(ctx, conn, args[0])
+		printConflicts(conflicts)
+		remaining := internal.WithoutConflicts(changes, conflicts)
+		fmt.Printf("📝 %d modification(s) sans conflit sur %d\n", remaining.Count(), changes.Count())
+		if remaining.Count() == 0 {
+			os.Exit(1)
+		}
+
+		name := rebaseCloneName
+		if name == "" {
+			name = ws.DisplayName + " (rebase)"
+		}
+		if !rebaseYes && !confirm(fmt.Sprintf("Cloner ces modifications dans un nouveau workspace %q ?", name)) {
+			os.Exit(1)
+		}
+		populateWorkspace(ctx, conn, "", "", name, fmt.Sprintf("Rebase manuel de %s", ws.ID), remaining, changes.Unreadable)
+	},
+}
+
+// inspectConflicts lit un workspace, ses modifications et ses conflits avec mainline.
+// La commande s'arrête en erreur si le workspace est introuvable ou si mainline est illisible.
+func inspectConflicts(ctx context.Context, conn *grpc.ClientConn, workspaceID string) (internal.WorkspaceInfo, internal.WorkspaceChanges, []internal.Conflict) {
+	ws := internal.FindWorkspace(ctx, conn, workspaceID, "")
+	if ws == nil {
+		fmt.Printf("❌ Workspace introuvable : %s\n", workspaceID)
+		os.Exit(1)
+	}
+	changes := internal.ReadWorkspaceChanges(ctx, conn, workspaceID)
+	conflicts, err := internal.FindConflicts(ctx, conn, *ws, changes)
+	if err != nil {
+		fmt.Printf("❌ Erreur lecture de mainline : %v\n", err)
+		os.Exit(1)
+	}
+	return *ws, changes, conflicts
+}
+
+// printConflicts affiche une ligne par ressource en conflit.
+func printConflicts(conflicts []internal.Conflict) {
+	for _, c := range conflicts {
+		fmt.Printf("⚔️  %s %s - modifié sur mainline par %s le %s\n",
+			c.Kind, c.Resource, c.ModifiedBy, c.ModifiedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+// init configure les flags de `workspace rebase` et attache `conflicts` et `rebase` à `workspace`.
+func init() {
+	workspaceRebaseCmd.Flags().StringVar(&rebaseCloneName, "clone-name", "", "Nom du workspace créé si le rebase est impossible")
+	workspaceRebaseCmd.Flags().BoolVarP(&rebaseYes, "yes", "y", false, "Cloner sans demander de confirmation si le rebase est impossible")
+	workspaceRebaseCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "En cas d'échec du clone, laisser en place le workspace créé")
+	workspaceRebaseCmd.Flags().DurationVar(&rebaseTimeout, "timeout", 5*time.Minute, "Durée maximale de la commande, dont la moitié au plus pour le rebase")
+	workspaceConflictsCmd.Flags().DurationVar(&conflictsTimeout, "timeout", 2*time.Minute, "Durée maximale de la lecture des conflits")
+	workspaceCmd.AddCommand(workspaceConflictsCmd, workspaceRebaseCmd)
+}
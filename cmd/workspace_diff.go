@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cvaas_cli/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// diffDevice est un flag CLI limitant `workspace diff` aux équipements dont le hostname
+// correspond (nom exact ou motif glob, ex: "leaf-*").
+var diffDevice string
+
+// diffAgainst est un flag CLI sélectionnant la référence du diff : "running",
+// "mainline" ou "all" (par défaut).
+var diffAgainst string
+
+// diffContext est un flag CLI donnant le nombre de lignes de contexte autour des modifications.
+var diffContext int
+
+// diffNoColor est un flag CLI désactivant la coloration du diff.
+var diffNoColor bool
+
+// diffTimeout est la durée maximale de `workspace diff`, lecture des diffs comprise.
+var diffTimeout time.Duration
+
+// workspaceDiffCmd affiche, pour chaque équipement, le diff entre la configuration
+// conçue dans le workspace et la configuration de référence (running et/ou mainline),
+// lu via les ressources configstatus.v1.
+//
+// En sortie texte, chaque équipement modifié est affiché en diff unifié suivi d'un
+// résumé des lignes ajoutées/supprimées ; avec `-o json`, la liste des diffs est
+// émise telle quelle pour les outils de revue automatique. Les équipements dont le
+// diff n'a pu être lu sont signalés et la commande sort en erreur.
+var workspaceDiffCmd = &cobra.Command{
+	Use:   "diff <workspace-id>",
+	Short: "Afficher les diffs de configuration des équipements d'un workspace",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workspaceID := args[0]
+		var kinds []internal.DiffKind
+		switch diffAgainst {
+		case "all":
+			kinds = []internal.DiffKind{internal.DiffRunning, internal.DiffMainline}
+		case string(internal.DiffRunning), string(internal.DiffMainline):
+			kinds = []internal.DiffKind{internal.DiffKind(diffAgainst)}
+		default:
+			fmt.Println("❌ --against doit valoir running, mainline ou all")
+			os.Exit(1)
+		}
+
+		ctx, cancel, conn := internal.ConnectWithTimeout(tokenPath, urlPath, diffTimeout)
+		defer cancel()
+		defer conn.Close()
+
+		devices, err := internal.FilterByTagQuery(ctx, conn, internal.SelectDevices(internal.ReadInventory(ctx, conn, "", false, false), diffDevice), tagQueryFilter)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		var diffs, failed []internal.ConfigDiff
+		for _, kind := range kinds {
+			for _, diff := range internal.GetConfigDiffs(ctx, conn, devices, kind, workspaceID) {
+				switch {
+				case diff.Error != "":
+					failed = append(failed, diff)
+				case diff.Added+diff.Removed > 0:
+					diffs = append(diffs, diff)
+				}
+			}
+		}
+
+		if jsonOutput() {
+			printJSON(append(diffs, failed...))
+			if len(failed) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		defer func() {
+			for _, diff := range failed {
+				fmt.Printf("⚠️  %s (%s) : diff illisible : %s\n", diff.Hostname, diff.Kind, diff.Error)
+			}
+			if len(failed) > 0 {
+				os.Exit(1)
+			}
+		}()
+		if len(diffs) == 0 {
+			fmt.Println("ℹ️  Aucune différence de configuration")
+			return
+		}
+		color := useColor(diffNoColor)
+		for _, diff := range diffs {
+			printUnifiedDiff(diff, color)
+		}
+		fmt.Println("📊 Résumé :")
+		for _, diff := range diffs {
+			fmt.Printf("   📟 %s (%s) : %s / %s\n", diff.Hostname, diff.Kind,
+				colorize(color, colorGreen, fmt.Sprintf("+%d", diff.Added)),
+				colorize(color, colorRed, fmt.Sprintf("-%d", diff.Removed)))
+		}
+	},
+}
+
+// printUnifiedDiff affiche le diff d'un équipement au format unifié.
+func printUnifiedDiff(diff internal.ConfigDiff, color bool) {
+	from, to := "running", "designed@workspace"
+	if diff.Kind == internal.DiffMainline {
+		from = "designed@mainline"
+	}
+	fmt.Println(colorize(color, colorBold, fmt.Sprintf("--- %s/%s", diff.Hostname, from)))
+	fmt.Println(colorize(color, colorBold, fmt.Sprintf("+++ %s/%s", diff.Hostname, to)))
+	for _, h := range diff.Hunks(diffContext) {
+		fmt.Println(colorize(color, colorCyan, fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.AStart, h.ALen, h.BStart, h.BLen)))
+		for _, l := range h.Lines {
+			switch l.Op {
+			case "+":
+				fmt.Println(colorize(color, colorGreen, "+"+l.Text))
+			case "-":
+				fmt.Println(colorize(color, colorRed, "-"+l.Text))
+			default:
+				fmt.Println(" " + l.Text)
+			}
+		}
+	}
+}
+
+// init configure les flags de `workspace diff` et l'attache à `workspace`.
+func init() {
+	workspaceDiffCmd.Flags().StringVar(&diffDevice, "device", "", "Limiter aux équipements dont le hostname correspond (glob accepté)")
+	workspaceDiffCmd.Flags().StringVar(&tagQueryFilter, "tag-query", "", "Limiter aux équipements satisfaisant une requête de tags")
+	workspaceDiffCmd.Flags().StringVar(&diffAgainst, "against", "all", "Référence du diff : running, mainline ou all")
+	workspaceDiffCmd.Flags().IntVar(&diffContext, "context", 3, "Nombre de lignes de contexte")
+	workspaceDiffCmd.Flags().BoolVar(&diffNoColor, "no-color", false, "Désactiver la coloration")
+	workspaceDiffCmd.Flags().DurationVar(&diffTimeout, "timeout", 5*time.Minute, "Durée maximale de la lecture des diffs")
+	workspaceCmd.AddCommand(workspaceDiffCmd)
+}
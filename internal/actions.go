@@ -2,17 +2,19 @@ package internal
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
+	"sort"
+	"time"
 
 	inventory "github.com/aristanetworks/cloudvision-go/api/arista/inventory.v1"
 	// tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
 	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
-	// "google.golang.org/protobuf/types/known/wrapperspb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // DeviceInfo contient les informations essentielles d'un équipement retourné par l'inventaire CVaaS.
@@ -31,6 +33,42 @@ type WorkspaceInfo struct {
 	ID          string
 	DisplayName string
 	State       string
+	CreatedAt   time.Time
+	CreatedBy   string
+	// LastRebasedAt est la date du dernier rebase sur mainline (zéro si jamais rebasé).
+	LastRebasedAt time.Time
+	// LastModifiedAt est la date de la dernière modification, soumission comprise.
+	LastModifiedAt time.Time
+	// ChangeControlIDs liste les change controls créés par la soumission du workspace.
+	ChangeControlIDs []string
+	// RequestIDs liste, triés, les requestID auxquels le WorkspaceService a répondu.
+	RequestIDs []string
+}
+
+// workspaceInfoFromProto extrait un WorkspaceInfo d'un workspace retourné par le WorkspaceService.
+func workspaceInfoFromProto(val *workspace.Workspace) WorkspaceInfo {
+	info := WorkspaceInfo{
+		ID:          val.GetKey().GetWorkspaceId().GetValue(),
+		DisplayName: val.GetDisplayName().GetValue(),
+		State:       val.GetState().String(),
+		CreatedBy:   val.GetCreatedBy().GetValue(),
+
+		ChangeControlIDs: val.GetCcIds().GetValues(),
+	}
+	if val.GetCreatedAt() != nil {
+		info.CreatedAt = val.GetCreatedAt().AsTime()
+	}
+	if val.GetLastRebasedAt() != nil {
+		info.LastRebasedAt = val.GetLastRebasedAt().AsTime()
+	}
+	if val.GetLastModifiedAt() != nil {
+		info.LastModifiedAt = val.GetLastModifiedAt().AsTime()
+	}
+	for requestID := range val.GetResponses().GetValues() {
+		info.RequestIDs = append(info.RequestIDs, requestID)
+	}
+	sort.Strings(info.RequestIDs)
+	return info
 }
 
 // ReadInventory interroge l'inventaire des équipements depuis la plateforme CloudVision-as-a-Service (CVaaS)
@@ -51,45 +89,31 @@ type WorkspaceInfo struct {
 //
 // Panique :
 //   - Si mlagFilter et danzFilter sont tous deux activés simultanément.
-//   - Si une erreur survient lors de la sérialisation JSON ou la récupération du flux.
+//   - Si une erreur survient lors de la récupération du flux.
 func ReadInventory(ctx context.Context, conn *grpc.ClientConn, model string, mlagFilter, danzFilter bool) []DeviceInfo {
 	if mlagFilter && danzFilter {
 		panic("❌ Impossible d'utiliser simultanément les filtres MLAG et DANZ (limitation API CVaaS).")
 	}
-
-	client := inventory.NewDeviceServiceClient(conn)
-	var req inventory.DeviceStreamRequest
-
-	filterMap := map[string]interface{}{}
-	if model != "" {
-		filterMap["modelName"] = model
-	}
-	if mlagFilter {
-		filterMap["extendedAttributes"] = map[string]interface{}{
-			"featureEnabled": map[string]bool{"mlag": true},
-		}
-	} else if danzFilter {
-		filterMap["extendedAttributes"] = map[string]interface{}{
-			"featureEnabled": map[string]bool{"danz": true},
-		}
+	devices, err := ListInventory(ctx, conn, model, mlagFilter, danzFilter)
+	if err != nil {
+		panic(fmt.Sprintf("❌ %v", err))
 	}
+	return devices
+}
 
-	if len(filterMap) > 0 {
-		filterObj := map[string]interface{}{
-			"partialEqFilter": []interface{}{filterMap},
-		}
-		jsonData, err := json.Marshal(filterObj)
-		if err != nil {
-			panic(fmt.Sprintf("Erreur json.Marshal : %v", err))
-		}
-		if err := protojson.Unmarshal(jsonData, &req); err != nil {
-			panic(fmt.Sprintf("Erreur protojson.Unmarshal : %v", err))
-		}
+// ListInventory est la variante de ReadInventory qui retourne une erreur au lieu de
+// paniquer, pour les appelants qui signalent l'échec sans interrompre la commande.
+func ListInventory(ctx context.Context, conn *grpc.ClientConn, model string, mlagFilter, danzFilter bool) ([]DeviceInfo, error) {
+	if mlagFilter && danzFilter {
+		return nil, fmt.Errorf("Impossible d'utiliser simultanément les filtres MLAG et DANZ (limitation API CVaaS).")
 	}
 
-	stream, err := client.GetAll(ctx, &req)
+	client := inventory.NewDeviceServiceClient(conn)
+	req := deviceStreamRequest(model, mlagFilter, danzFilter)
+
+	stream, err := client.GetAll(ctx, req)
 	if err != nil {
-		panic(fmt.Sprintf("❌ Erreur stream inventaire : %v", err))
+		return nil, fmt.Errorf("Erreur stream inventaire : %v", err)
 	}
 
 	var devices []DeviceInfo
@@ -99,7 +123,7 @@ func ReadInventory(ctx context.Context, conn *grpc.ClientConn, model string, mla
 			break
 		}
 		if err != nil {
-			panic(fmt.Sprintf("❌ Erreur lecture stream : %v", err))
+			return nil, fmt.Errorf("Erreur lecture stream : %v", err)
 		}
 		val := res.GetValue()
 		features := val.GetExtendedAttributes().GetFeatureEnabled()
@@ -114,7 +138,7 @@ func ReadInventory(ctx context.Context, conn *grpc.ClientConn, model string, mla
 			MlagEnabled:     features["Mlag"],
 		})
 	}
-	return devices
+	return devices, nil
 }
 
 // GetWorkspacesByState retourne une liste de workspaces présents sur la plateforme CVaaS
@@ -130,61 +154,49 @@ func ReadInventory(ctx context.Context, conn *grpc.ClientConn, model string, mla
 //
 // Panique :
 //   - Si un état invalide est fourni
-//   - Si une erreur survient lors de la sérialisation ou du streaming gRPC
+//   - Si une erreur survient lors du streaming gRPC
 func GetWorkspacesByState(ctx context.Context, conn *grpc.ClientConn, stateName string) []WorkspaceInfo {
-	stateMap := map[string]int{
-		"UNSPECIFIED":  0,
-		"UNRECOGNIZED": -1,
-		"PENDING":      1,
-		"SUBMITTED":    2,
-		"ABANDONED":    3,
-		"CONFLICTS":    4,
-		"ROLLED_BACK":  5,
+	results, err := ListWorkspaces(ctx, conn, stateName)
+	if err != nil {
+		panic(err.Error())
 	}
+	return results
+}
 
-	var req workspace.WorkspaceStreamRequest
-	if stateName != "" && strings.ToUpper(stateName) != "NONE" {
-		stateValue, ok := stateMap[strings.ToUpper(stateName)]
-		if !ok {
-			panic(fmt.Sprintf("❌ État invalide : %s", stateName))
-		}
-		filter := fmt.Sprintf(`{"partialEqFilter":[{"state":%d}]}`, stateValue)
-		if err := protojson.Unmarshal([]byte(filter), &req); err != nil {
-			panic(fmt.Sprintf("Erreur parsing JSON request : %v", err))
-		}
+// ListWorkspaces est la variante de GetWorkspacesByState qui retourne une erreur au lieu
+// de paniquer, pour les appelants qui peuvent se passer de la liste des workspaces.
+func ListWorkspaces(ctx context.Context, conn *grpc.ClientConn, stateName string) ([]WorkspaceInfo, error) {
+	req, err := workspaceStreamRequest(stateName)
+	if err != nil {
+		return nil, fmt.Errorf("❌ %v", err)
 	}
 
 	client := workspace.NewWorkspaceServiceClient(conn)
-	stream, err := client.GetAll(ctx, &req)
+	stream, err := client.GetAll(ctx, req)
 	if err != nil {
-		panic(fmt.Sprintf("Erreur stream : %v", err))
+		return nil, fmt.Errorf("Erreur stream : %v", err)
 	}
 
-	var results []WorkspaceInfo 
+	var results []WorkspaceInfo
 	for {
 		res, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			panic(fmt.Sprintf("Erreur lecture : %v", err))
+			return nil, fmt.Errorf("Erreur lecture : %v", err)
 		}
-		val := res.GetValue()
-		results = append(results, WorkspaceInfo {
-			ID:          val.GetKey().GetWorkspaceId().GetValue(),
-			DisplayName: val.GetDisplayName().GetValue(),
-			State:       val.GetState().String(),
-		})
+		results = append(results, workspaceInfoFromProto(res.GetValue()))
 	}
-	return results
+	return results, nil
 }
 
 
 // CreateWorkspace crée un nouveau workspace sur la plateforme CloudVision-as-a-Service (CVaaS)
 // en utilisant l'API gRPC de configuration des workspaces.
 //
-// Cette fonction construit dynamiquement une requête JSON contenant l'ID du workspace, son nom
-// lisible et un ID de requête (requestID), puis l'envoie à l'API CVaaS pour créer le workspace.
+// Cette fonction construit une requête typée contenant l'ID du workspace, son nom
+// lisible, sa description et un ID de requête (requestID), puis l'envoie à l'API CVaaS pour créer le workspace.
 //
 // Paramètres :
 //   - ctx : contexte d'exécution pour l'appel gRPC (gestion des délais, annulations, etc.)
@@ -192,88 +204,257 @@ func GetWorkspacesByState(ctx context.Context, conn *grpc.ClientConn, stateName
 //   - workspaceID : identifiant unique du nouveau workspace à créer
 //   - requestID : identifiant de la requête (souvent utilisé pour le traçage ou l'idempotence)
 //   - displayName : nom lisible du workspace, tel qu’il apparaîtra dans l’interface utilisateur
+//   - description : description libre du workspace (optionnelle)
 //
 // Panique :
-//   - Si une erreur survient lors du parsing JSON ou de l'appel gRPC à CVaaS.
+//   - Si une erreur survient lors de l'appel gRPC à CVaaS.
 //
 // Affiche un message de confirmation dans la sortie standard en cas de succès.
-func CreateWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID, displayName string) {
+func CreateWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID, displayName, description string) {
 	client := workspace.NewWorkspaceConfigServiceClient(conn)
-	jsonPayload := fmt.Sprintf(`{
-		"value": {
-			"displayName": "%s",
-			"key": {"workspaceId": "%s"},
-			"requestParams": {"requestId": "%s"}
-		}
-	}`, displayName, workspaceID, requestID)
-
-	var req workspace.WorkspaceConfigSetRequest
-	if err := protojson.Unmarshal([]byte(jsonPayload), &req); err != nil {
-		panic(fmt.Sprintf("❌ Erreur parsing JSON workspace : %v", err))
-	}
+	req := workspaceConfigSetRequest(workspaceID, requestID, displayName, description)
 
-	resp, err := client.Set(ctx, &req)
+	resp, err := client.Set(ctx, req)
 	if err != nil {
 		panic(fmt.Sprintf("❌ Erreur création workspace : %v", err))
 	}
+	registerUndo(ctx, workspaceID, fmt.Sprintf("création du workspace %s", displayName), true, func(ctx context.Context) error {
+		return AbandonWorkspace(ctx, conn, workspaceID)
+	})
 	fmt.Printf("✅ Workspace créé : %s\n", protojson.Format(resp))
 }
 
-// func CreateTag(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, value string, elementType, elementSubType int) {
-// 	client := tag.NewTagConfigServiceClient(conn)
-// 	jsonPayload := fmt.Sprintf(`{
-// 		"value":{
-// 			"remove":false,
-// 			"key":{
-// 				"workspaceId":"%s",
-// 				"elementType":%d,
-// 				"label":"%s",
-// 				"value":"%s",
-// 				"elementSubType":%d
-// 			}
-// 		}
-// 	}`, workspaceID, elementType, label, value, elementSubType)
+// Live indique si le workspace est encore modifiable : en attente (PENDING) ou en
+// conflit avec mainline (CONFLICTS). Un workspace soumis, abandonné ou annulé ne
+// l'est plus.
+func (w WorkspaceInfo) Live() bool {
+	return w.State == workspace.WorkspaceState_WORKSPACE_STATE_PENDING.String() ||
+		w.State == workspace.WorkspaceState_WORKSPACE_STATE_CONFLICTS.String()
+}
 
-// 	var req tag.TagConfigSetRequest
-// 	if err := protojson.Unmarshal([]byte(jsonPayload), &req); err != nil {
-// 		panic(fmt.Sprintf("❌ Erreur parsing JSON tag : %v", err))
-// 	}
+// FindWorkspace recherche un workspace existant sur CVaaS, d'abord par son identifiant
+// puis par son nom lisible.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour l'appel gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : identifiant recherché, quel que soit l'état du workspace (ignoré si vide)
+//   - displayName : nom recherché parmi les workspaces encore modifiables (voir Live) ;
+//     le nom d'un workspace soumis ou abandonné peut être réutilisé (ignoré si vide)
+//
+// Retourne :
+//   - *WorkspaceInfo : le workspace trouvé, ou nil si aucun ne correspond.
+//
+// Panique :
+//   - Si une erreur gRPC autre que NotFound survient.
+func FindWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, displayName string) *WorkspaceInfo {
+	if workspaceID != "" {
+		client := workspace.NewWorkspaceServiceClient(conn)
+		resp, err := client.GetOne(ctx, &workspace.WorkspaceRequest{
+			Key: &workspace.WorkspaceKey{WorkspaceId: wrapperspb.String(workspaceID)},
+		})
+		if err == nil {
+			info := workspaceInfoFromProto(resp.GetValue())
+			return &info
+		}
+		if status.Code(err) != codes.NotFound {
+			panic(fmt.Sprintf("❌ Erreur lecture workspace %s : %v", workspaceID, err))
+		}
+	}
+	if displayName != "" {
+		for _, w := range GetWorkspacesByState(ctx, conn, "NONE") {
+			if w.DisplayName == displayName && w.Live() {
+				found := w
+				return &found
+			}
+		}
+	}
+	return nil
+}
 
-// 	resp, err := client.Set(ctx, &req)
-// 	if err != nil {
-// 		panic(fmt.Sprintf("❌ Erreur ajout tag : %v", err))
-// 	}
-// 	fmt.Printf("🏷️  Tag ajouté : %s\n", protojson.Format(resp))
-// }
+// workspacePollInterval est l'intervalle entre deux lectures de l'état d'un workspace
+// lors de l'attente de sa création.
+const workspacePollInterval = 500 * time.Millisecond
 
-// func AssignTagToDevice(ctx context.Context, conn *grpc.ClientConn, workspaceID, deviceID, label, value string, elementType, elementSubType int) {
-// 	client := tag.NewTagAssignmentConfigServiceClient(conn)
-// 	jsonPayload := fmt.Sprintf(`{
-// 		"value": {
-// 			"remove": false,
-// 			"key": {
-// 				"workspaceId": "%s",
-// 				"elementType": %d,
-// 				"elementSubType": %d,
-// 				"label": "%s",
-// 				"value": "%s",
-// 				"deviceId": "%s"
-// 			}
-// 		}
-// 	}`, workspaceID, elementType, elementSubType, label, value, deviceID)
+// WaitForWorkspace attend que le WorkspaceService reflète la création d'un workspace,
+// c'est-à-dire que le workspace soit lisible et que la réponse associée au requestID
+// soit en succès.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution ; son délai borne la durée d'attente
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : identifiant du workspace attendu
+//   - requestID : identifiant de la requête de création
+//
+// Retourne :
+//   - WorkspaceInfo : le workspace tel que vu par le WorkspaceService.
+//
+// Panique :
+//   - Si la requête de création est en échec côté CVaaS.
+//   - Si le délai du contexte expire avant que la création soit confirmée.
+func WaitForWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID string) WorkspaceInfo {
+	client := workspace.NewWorkspaceServiceClient(conn)
+	req := &workspace.WorkspaceRequest{
+		Key: &workspace.WorkspaceKey{WorkspaceId: wrapperspb.String(workspaceID)},
+	}
+	ticker := time.NewTicker(workspacePollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := client.GetOne(ctx, req)
+		if err != nil && status.Code(err) != codes.NotFound {
+			panic(fmt.Sprintf("❌ Erreur lecture workspace %s : %v", workspaceID, err))
+		}
+		if err == nil {
+			val := resp.GetValue()
+			if r, ok := val.GetResponses().GetValues()[requestID]; ok {
+				switch r.GetStatus() {
+				case workspace.ResponseStatus_RESPONSE_STATUS_SUCCESS:
+					return workspaceInfoFromProto(val)
+				case workspace.ResponseStatus_RESPONSE_STATUS_FAIL:
+					panic(fmt.Sprintf("❌ Création du workspace %s en échec : %s", workspaceID, r.GetMessage().GetValue()))
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			panic(fmt.Sprintf("❌ Création du workspace %s toujours non confirmée : %v", workspaceID, ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+}
 
-// 	var req tag.TagAssignmentConfigSetRequest
-// 	if err := protojson.Unmarshal([]byte(jsonPayload), &req); err != nil {
-// 		panic(fmt.Sprintf("❌ Erreur parsing tag assignment JSON : %v", err))
-// 	}
+// RequestWorkspace envoie une requête d'action sur un workspace (build, submit,
+// abandon, rebase...) via le WorkspaceConfigService, avec un requestID généré.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour l'appel gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace concerné
+//   - request : action demandée (ex : workspace.Request_REQUEST_START_BUILD)
+//
+// Retourne :
+//   - string : le requestID, à passer à WaitForRequest pour suivre l'action.
+//   - error : l'erreur gRPC si CVaaS refuse la requête.
+func RequestWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID string, request workspace.Request) (string, error) {
+	requestID := NewUUID()
+	if err := RequestWorkspaceWithID(ctx, conn, workspaceID, request, requestID); err != nil {
+		return "", err
+	}
+	return requestID, nil
+}
 
-// 	_, err := client.Set(ctx, &req)
-// 	if err != nil {
-// 		panic(fmt.Sprintf("❌ Erreur assignation tag au device : %v", err))
-// 	}
+// RequestWorkspaceWithID fonctionne comme RequestWorkspace avec un requestID choisi par
+// l'appelant : renvoyer la même requête avec le même requestID est sans effet, ce qui
+// permet de rejouer une action interrompue.
+func RequestWorkspaceWithID(ctx context.Context, conn *grpc.ClientConn, workspaceID string, request workspace.Request, requestID string) error {
+	client := workspace.NewWorkspaceConfigServiceClient(conn)
+	_, err := client.Set(ctx, &workspace.WorkspaceConfigSetRequest{Value: &workspace.WorkspaceConfig{
+		Key:           &workspace.WorkspaceKey{WorkspaceId: wrapperspb.String(workspaceID)},
+		Request:       request,
+		RequestParams: &workspace.RequestParams{RequestId: wrapperspb.String(requestID)},
+	}})
+	return err
+}
 
-// 	fmt.Printf("📌 Tag '%s=%s' assigné à device %s\n", label, value, deviceID)
-// }
+// WaitForRequest attend la réponse du WorkspaceService à une requête envoyée par
+// RequestWorkspace.
+//
+// Retourne :
+//   - error : nil si la requête a réussi, le message de CVaaS si elle a échoué,
+//     ou l'erreur du contexte si le délai expire avant la réponse.
+func WaitForRequest(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID string) error {
+	client := workspace.NewWorkspaceServiceClient(conn)
+	req := &workspace.WorkspaceRequest{
+		Key: &workspace.WorkspaceKey{WorkspaceId: wrapperspb.String(workspaceID)},
+	}
+	ticker := time.NewTicker(workspacePollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := client.GetOne(ctx, req)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if r, ok := resp.GetValue().GetResponses().GetValues()[requestID]; ok {
+			switch r.GetStatus() {
+			case workspace.ResponseStatus_RESPONSE_STATUS_SUCCESS:
+				return nil
+			case workspace.ResponseStatus_RESPONSE_STATUS_FAIL:
+				return fmt.Errorf("%s", r.GetMessage().GetValue())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("pas de réponse à la requête %s : %w", requestID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// BuildWorkspace lance le build d'un workspace, attend la réponse du WorkspaceService
+// puis vérifie l'état du build (dont l'ID est le requestID de la demande).
+//
+// Retourne :
+//   - error : nil si le build a réussi, sinon l'erreur de la requête ou du build.
+func BuildWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID string) error {
+	return BuildWorkspaceWithID(ctx, conn, workspaceID, NewUUID())
+}
+
+// BuildWorkspaceWithID fonctionne comme BuildWorkspace avec un ID de build choisi par
+// l'appelant (voir RequestWorkspaceWithID).
+func BuildWorkspaceWithID(ctx context.Context, conn *grpc.ClientConn, workspaceID, buildID string) error {
+	if err := RequestWorkspaceWithID(ctx, conn, workspaceID, workspace.Request_REQUEST_START_BUILD, buildID); err != nil {
+		return err
+	}
+	if err := WaitForRequest(ctx, conn, workspaceID, buildID); err != nil {
+		return err
+	}
+	client := workspace.NewWorkspaceBuildServiceClient(conn)
+	resp, err := client.GetOne(ctx, &workspace.WorkspaceBuildRequest{
+		Key: &workspace.WorkspaceBuildKey{
+			WorkspaceId: wrapperspb.String(workspaceID),
+			BuildId:     wrapperspb.String(buildID),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if state := resp.GetValue().GetState(); state != workspace.BuildState_BUILD_STATE_SUCCESS {
+		return fmt.Errorf("build %s : %s %s", buildID, state, resp.GetValue().GetError().GetValue())
+	}
+	return nil
+}
+
+// AbandonWorkspace abandonne un workspace et attend la confirmation du WorkspaceService.
+// Les opérations journalisées qui y ont écrit sont marquées sans effet sur mainline.
+//
+// Retourne :
+//   - error : l'erreur de la requête si CVaaS la refuse ou si elle échoue.
+func AbandonWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID string) error {
+	requestID, err := RequestWorkspace(ctx, conn, workspaceID, workspace.Request_REQUEST_ABANDON)
+	if err != nil {
+		return err
+	}
+	if err := WaitForRequest(ctx, conn, workspaceID, requestID); err != nil {
+		return err
+	}
+	closeWorkspace(ctx, workspaceID)
+	revertWorkspaces(workspaceID)
+	return nil
+}
+
+// SubmitWorkspace soumet un workspace avec le requestID donné (voir
+// RequestWorkspaceWithID) et attend la réponse du WorkspaceService. Une fois soumises,
+// les opérations du workspace ne sont plus compensables.
+func SubmitWorkspace(ctx context.Context, conn *grpc.ClientConn, workspaceID, requestID string) error {
+	if err := RequestWorkspaceWithID(ctx, conn, workspaceID, workspace.Request_REQUEST_SUBMIT, requestID); err != nil {
+		return err
+	}
+	if err := WaitForRequest(ctx, conn, workspaceID, requestID); err != nil {
+		return err
+	}
+	closeWorkspace(ctx, workspaceID)
+	return nil
+}
 
 // func ReadInventory(ctx context.Context, conn *grpc.ClientConn, model string, mlagFilter, danzFilter bool) []DeviceInfo {
 // 	// ❌ Protection : un seul des deux filtres doit être activé
@@ -0,0 +1,22 @@
+package internal
+
+import "testing"
+
+func TestWorkspaceInfoLive(t *testing.T) {
+	tests := []struct {
+		state string
+		want  bool
+	}{
+		{"WORKSPACE_STATE_PENDING", true},
+		{"WORKSPACE_STATE_CONFLICTS", true},
+		{"WORKSPACE_STATE_SUBMITTED", false},
+		{"WORKSPACE_STATE_ABANDONED", false},
+		{"WORKSPACE_STATE_ROLLED_BACK", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := (WorkspaceInfo{State: tt.state}).Live(); got != tt.want {
+			t.Errorf("%q : Live = %v, attendu %v", tt.state, got, tt.want)
+		}
+	}
+}
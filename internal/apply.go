@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	configlet "github.com/aristanetworks/cloudvision-go/api/arista/configlet.v1"
+	studio "github.com/aristanetworks/cloudvision-go/api/arista/studio.v1"
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+	"github.com/aristanetworks/cloudvision-go/api/fmp"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ApplyReport résume l'application d'un WorkspaceChanges dans un workspace :
+// le nombre de ressources écrites par type, et les types en échec.
+type ApplyReport struct {
+	Applied map[string]int  `json:"applied"`
+	Failed  []ResourceError `json:"failed,omitempty"`
+}
+
+// parseElementType convertit un nom court ("device", "interface") en type d'élément tag.v2.
+func parseElementType(name string) (tag.ElementType, error) {
+	value, ok := tag.ElementType_value["ELEMENT_TYPE_"+strings.ToUpper(name)]
+	if !ok || value == int32(tag.ElementType_ELEMENT_TYPE_UNSPECIFIED) {
+		return tag.ElementType_ELEMENT_TYPE_UNSPECIFIED, fmt.Errorf("type d'élément invalide : %q (device ou interface)", name)
+	}
+	return tag.ElementType(value), nil
+}
+
+// parseElementKind convertit un type d'élément et un sous-type en noms courts en leurs
+// valeurs tag.v2. Le sous-type est résolu par le nom de l'énumération ElementSubType
+// ("external" pour ELEMENT_SUB_TYPE_EXTERNAL) ; seuls les tags d'interface en ont un,
+// "" valant ELEMENT_SUB_TYPE_NULL (aucun sous-type).
+func parseElementKind(elementType, elementSubType string) (tag.ElementType, tag.ElementSubType, error) {
+	et, err := parseElementType(elementType)
+	if err != nil {
+		return et, tag.ElementSubType_ELEMENT_SUB_TYPE_UNSPECIFIED, err
+	}
+	if elementSubType == "" {
+		return et, tag.ElementSubType_ELEMENT_SUB_TYPE_NULL, nil
+	}
+	if et != tag.ElementType_ELEMENT_TYPE_INTERFACE {
+		return et, tag.ElementSubType_ELEMENT_SUB_TYPE_UNSPECIFIED, fmt.Errorf("sous-type %q réservé aux tags d'interface", elementSubType)
+	}
+	value, ok := tag.ElementSubType_value["ELEMENT_SUB_TYPE_"+strings.ToUpper(elementSubType)]
+	if !ok || value == int32(tag.ElementSubType_ELEMENT_SUB_TYPE_UNSPECIFIED) {
+		return et, tag.ElementSubType_ELEMENT_SUB_TYPE_UNSPECIFIED, fmt.Errorf("sous-type d'élément invalide : %q", elementSubType)
+	}
+	return et, tag.ElementSubType(value), nil
+}
+
+// elementSubTypeName retourne le nom court d'un sous-type tag.v2 ("external"), ou ""
+// s'il n'y a pas de sous-type (NULL ou non renseigné).
+func elementSubTypeName(t tag.ElementSubType) string {
+	if t == tag.ElementSubType_ELEMENT_SUB_TYPE_NULL || t == tag.ElementSubType_ELEMENT_SUB_TYPE_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(t.String(), "ELEMENT_SUB_TYPE_"))
+}
+
+// ApplyWorkspaceChanges rejoue des modifications dans un workspace existant :
+// chaque ressource est réécrite avec la clé du workspace cible, les suppressions
+// étant rejouées comme des suppressions. Les champs de configlets non renseignés
+// dans la source ne sont pas écrits.
+//
+// L'application se poursuit après un échec : pour chaque type de ressource, la
+// première erreur rencontrée est consignée dans le rapport et les ressources
+// suivantes de ce type ne sont pas écrites. Les ressources écrites de chaque type
+// sont enregistrées comme une opération compensable (voir Compensator), et dans le
+// journal des opérations (voir StartOperation). Dans un workspace que la commande n'a
+// pas créé, l'état antérieur des ressources est lu avant l'écriture pour que la
+// compensation le restaure (voir priorConfigs).
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace cible
+//   - changes : modifications à appliquer (ex : issues de ReadWorkspaceChanges)
+//
+// Retourne :
+//   - ApplyReport : le décompte des ressources écrites et les types en échec.
+func ApplyWorkspaceChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes WorkspaceChanges) ApplyReport {
+	report := ApplyReport{Applied: map[string]int{}}
+	write := prepareWrite(ctx, conn, workspaceID, changes)
+	defer write.record(func(kind string, i int) bool { return i < report.Applied[kind] })
+	// run applique les n ressources d'un type, après avoir lu avec read leur état
+	// antérieur dans le workspace ; apply retourne l'annulation de l'écriture de la
+	// ressource i (restauration de cet état, ou suppression de la ressource).
+	run := func(kind string, n int, read func() error, apply func(i int) (func(ctx context.Context) error, error)) {
+		if n == 0 {
+			return
+		}
+		if err := read(); err != nil {
+			report.Failed = append(report.Failed, ResourceError{Kind: kind, Error: fmt.Sprintf("lecture de l'état antérieur : %v", err)})
+			return
+		}
+		var undos []func(ctx context.Context) error
+		defer func() {
+			if len(undos) == 0 {
+				return
+			}
+			registerUndo(ctx, workspaceID, fmt.Sprintf("écriture de %d %s", len(undos), kind), false, func(ctx context.Context) error {
+				for _, undo := range undos {
+					if err := undo(ctx); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}()
+		for i := 0; i < n; i++ {
+			undo, err := apply(i)
+			if err != nil {
+				report.Failed = append(report.Failed, ResourceError{Kind: kind, Error: err.Error()})
+				return
+			}
+			undos = append(undos, undo)
+			report.Applied[kind]++
+		}
+	}
+	ws := wrapperspb.String(workspaceID)
+
+	tags := tag.NewTagConfigServiceClient(conn)
+	var priorTags map[string]*tag.TagConfig
+	run(KindTag, len(changes.Tags), func() (err error) {
+		priorTags, err = priorConfigs(ctx, workspaceID, func() (func() (*tag.TagConfigStreamResponse, error), error) {
+			stream, err := tags.GetAll(ctx, &tag.TagConfigStreamRequest{
+				PartialEqFilter: []*tag.TagConfig{{Key: &tag.TagKey{WorkspaceId: ws}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return stream.Recv, nil
+		}, func(c *tag.TagConfig) string { return tagConfigID(c.GetKey()) })
+		return err
+	}, func(i int) (func(ctx context.Context) error, error) {
+		c := changes.Tags[i]
+		elementType, subType, err := parseElementKind(c.ElementType, c.ElementSubType)
+		if err != nil {
+			return nil, err
+		}
+		key := tagKey(workspaceID, c.Label, c.Value, elementType, subType)
+		_, err = tags.Set(ctx, &tag.TagConfigSetRequest{Value: &tag.TagConfig{
+			Key:    key,
+			Remove: wrapperspb.Bool(c.Action == ChangeRemove),
+		}})
+		return restoreOrDelete(priorTags, tagConfigID(key), func(ctx context.Context, prior *tag.TagConfig) error {
+			_, err := tags.Set(ctx, &tag.TagConfigSetRequest{Value: prior})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := tags.Delete(ctx, &tag.TagConfigDeleteRequest{Key: key})
+			return err
+		}), err
+	})
+
+	assignments := tag.NewTagAssignmentConfigServiceClient(conn)
+	var priorAssignments map[string]*tag.TagAssignmentConfig
+	run(KindTagAssignment, len(changes.TagAssignments), func() (err error) {
+		priorAssignments, err = priorConfigs(ctx, workspaceID, func() (func() (*tag.TagAssignmentConfigStreamResponse, error), error) {
+			stream, err := assignments.GetAll(ctx, &tag.TagAssignmentConfigStreamRequest{
+				PartialEqFilter: []*tag.TagAssignmentConfig{{Key: &tag.TagAssignmentKey{WorkspaceId: ws}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return stream.Recv, nil
+		}, func(c *tag.TagAssignmentConfig) string { return tagAssignmentConfigID(c.GetKey()) })
+		return err
+	}, func(i int) (func(ctx context.Context) error, error) {
+		c := changes.TagAssignments[i]
+		elementType, subType, err := parseElementKind(c.ElementType, c.ElementSubType)
+		if err != nil {
+			return nil, err
+		}
+		key := tagAssignmentKey(workspaceID, c.Label, c.Value, elementType, subType, c.DeviceID, c.InterfaceID)
+		_, err = assignments.Set(ctx, &tag.TagAssignmentConfigSetRequest{Value: &tag.TagAssignmentConfig{
+			Key:    key,
+			Remove: wrapperspb.Bool(c.Action == ChangeRemove),
+		}})
+		return restoreOrDelete(priorAssignments, tagAssignmentConfigID(key), func(ctx context.Context, prior *tag.TagAssignmentConfig) error {
+			_, err := assignments.Set(ctx, &tag.TagAssignmentConfigSetRequest{Value: prior})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := assignments.Delete(ctx, &tag.TagAssignmentConfigDeleteRequest{Key: key})
+			return err
+		}), err
+	})
+
+	inputs := studio.NewInputsConfigServiceClient(conn)
+	inputsID := func(k *studio.InputsKey) string {
+		return k.GetStudioId().GetValue() + "|" + strings.Join(k.GetPath().GetValues(), "\x00")
+	}
+	var priorInputs map[string]*studio.InputsConfig
+	run(KindStudioInput, len(changes.StudioInputs), func() (err error) {
+		priorInputs, err = priorConfigs(ctx, workspaceID, func() (func() (*studio.InputsConfigStreamResponse, error), error) {
+			stream, err := inputs.GetAll(ctx, &studio.InputsConfigStreamRequest{
+				PartialEqFilter: []*studio.InputsConfig{{Key: &studio.InputsKey{WorkspaceId: ws}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return stream.Recv, nil
+		}, func(c *studio.InputsConfig) string { return inputsID(c.GetKey()) })
+		return err
+	}, func(i int) (func(ctx context.Context) error, error) {
+		c := changes.StudioInputs[i]
+		config := &studio.InputsConfig{
+			Key: &studio.InputsKey{
+				StudioId:    wrapperspb.String(c.StudioID),
+				WorkspaceId: ws,
+				Path:        &fmp.RepeatedString{Values: c.Path},
+			},
+		}
+		if c.Action == ChangeRemove {
+			config.Remove = wrapperspb.Bool(true)
+		} else {
+			config.Inputs = wrapperspb.String(c.Inputs)
+		}
+		_, err := inputs.Set(ctx, &studio.InputsConfigSetRequest{Value: config})
+		return restoreOrDelete(priorInputs, inputsID(config.Key), func(ctx context.Context, prior *studio.InputsConfig) error {
+			_, err := inputs.Set(ctx, &studio.InputsConfigSetRequest{Value: prior})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := inputs.Delete(ctx, &studio.InputsConfigDeleteRequest{Key: config.Key})
+			return err
+		}), err
+	})
+
+	studioAssignments := studio.NewAssignedTagsConfigServiceClient(conn)
+	var priorStudioAssignments map[string]*studio.AssignedTagsConfig
+	run(KindStudioAssignment, len(changes.StudioAssignments), func() (err error) {
+		priorStudioAssignments, err = priorConfigs(ctx, workspaceID, func() (func() (*studio.AssignedTagsConfigStreamResponse, error), error) {
+			stream, err := studioAssignments.GetAll(ctx, &studio.AssignedTagsConfigStreamRequest{
+				PartialEqFilter: []*studio.AssignedTagsConfig{{Key: &studio.StudioKey{WorkspaceId: ws}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return stream.Recv, nil
+		}, func(c *studio.AssignedTagsConfig) string { return c.GetKey().GetStudioId().GetValue() })
+		return err
+	}, func(i int) (func(ctx context.Context) error, error) {
+		c := changes.StudioAssignments[i]
+		config := &studio.AssignedTagsConfig{
+			Key: &studio.StudioKey{StudioId: wrapperspb.String(c.StudioID), WorkspaceId: ws},
+		}
+		if c.Action == ChangeRemove {
+			config.Remove = wrapperspb.Bool(true)
+		} else {
+			config.Query = wrapperspb.String(c.Query)
+		}
+		_, err := studioAssignments.Set(ctx, &studio.AssignedTagsConfigSetRequest{Value: config})
+		return restoreOrDelete(priorStudioAssignments, c.StudioID, func(ctx context.Context, prior *studio.AssignedTagsConfig) error {
+			_, err := studioAssignments.Set(ctx, &studio.AssignedTagsConfigSetRequest{Value: prior})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := studioAssignments.Delete(ctx, &studio.AssignedTagsConfigDeleteRequest{Key: config.Key})
+			return err
+		}), err
+	})
+
+	configlets := configlet.NewConfigletConfigServiceClient(conn)
+	var priorConfiglets map[string]*configlet.ConfigletConfig
+	run(KindConfiglet, len(changes.Configlets), func() (err error) {
+		priorConfiglets, err = priorConfigs(ctx, workspaceID, func() (func() (*configlet.ConfigletConfigStreamResponse, error), error) {
+			stream, err := configlets.GetAll(ctx, &configlet.ConfigletConfigStreamRequest{
+				PartialEqFilter: []*configlet.ConfigletConfig{{Key: &configlet.ConfigletKey{WorkspaceId: ws}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return stream.Recv, nil
+		}, func(c *configlet.ConfigletConfig) string { return c.GetKey().GetConfigletId().GetValue() })
+		return err
+	}, func(i int) (func(ctx context.Context) error, error) {
+		c := changes.Configlets[i]
+		config := &configlet.ConfigletConfig{
+			Key: &configlet.ConfigletKey{WorkspaceId: ws, ConfigletId: wrapperspb.String(c.ConfigletID)},
+		}
+		if c.Action == ChangeRemove {
+			config.Remove = wrapperspb.Bool(true)
+		} else {
+			config.DisplayName = wrapString(c.DisplayName)
+			config.Description = wrapString(c.Description)
+			config.Body = wrapString(c.Body)
+		}
+		_, err := configlets.Set(ctx, &configlet.ConfigletConfigSetRequest{Value: config})
+		return restoreOrDelete(priorConfiglets, c.ConfigletID, func(ctx context.Context, prior *configlet.ConfigletConfig) error {
+			_, err := configlets.Set(ctx, &configlet.ConfigletConfigSetRequest{Value: prior})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := configlets.Delete(ctx, &configlet.ConfigletConfigDeleteRequest{Key: config.Key})
+			return err
+		}), err
+	})
+
+	configletAssignments := configlet.NewConfigletAssignmentConfigServiceClient(conn)
+	var priorConfigletAssignments map[string]*configlet.ConfigletAssignmentConfig
+	run(KindConfigletAssignment, len(changes.ConfigletAssignments), func() (err error) {
+		priorConfigletAssignments, err = priorConfigs(ctx, workspaceID, func() (func() (*configlet.ConfigletAssignmentConfigStreamResponse, error), error) {
+			stream, err := configletAssignments.GetAll(ctx, &configlet.ConfigletAssignmentConfigStreamRequest{
+				PartialEqFilter: []*configlet.ConfigletAssignmentConfig{{Key: &configlet.ConfigletAssignmentKey{WorkspaceId: ws}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return stream.Recv, nil
+		}, func(c *configlet.ConfigletAssignmentConfig) string {
+			return c.GetKey().GetConfigletAssignmentId().GetValue()
+		})
+		return err
+	}, func(i int) (func(ctx context.Context) error, error) {
+		c := changes.ConfigletAssignments[i]
+		config := &configlet.ConfigletAssignmentConfig{
+			Key: &configlet.ConfigletAssignmentKey{WorkspaceId: ws, ConfigletAssignmentId: wrapperspb.String(c.AssignmentID)},
+		}
+		if c.Action == ChangeRemove {
+			config.Remove = wrapperspb.Bool(true)
+		} else {
+			config.DisplayName = wrapString(c.DisplayName)
+			config.Description = wrapString(c.Description)
+			config.Query = wrapString(c.Query)
+			config.ConfigletIds = wrapStrings(c.ConfigletIDs)
+			config.ChildAssignmentIds = wrapStrings(c.ChildAssignmentIDs)
+		}
+		_, err := configletAssignments.Set(ctx, &configlet.ConfigletAssignmentConfigSetRequest{Value: config})
+		return restoreOrDelete(priorConfigletAssignments, c.AssignmentID, func(ctx context.Context, prior *configlet.ConfigletAssignmentConfig) error {
+			_, err := configletAssignments.Set(ctx, &configlet.ConfigletAssignmentConfigSetRequest{Value: prior})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := configletAssignments.Delete(ctx, &configlet.ConfigletAssignmentConfigDeleteRequest{Key: config.Key})
+			return err
+		}), err
+	})
+
+	return report
+}
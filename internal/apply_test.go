@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+)
+
+func TestParseElementKind(t *testing.T) {
+	tests := []struct {
+		elementType, subType string
+		wantType             tag.ElementType
+		wantSubType          tag.ElementSubType
+		wantErr              bool
+	}{
+		{"device", "", tag.ElementType_ELEMENT_TYPE_DEVICE, tag.ElementSubType_ELEMENT_SUB_TYPE_NULL, false},
+		{"interface", "", tag.ElementType_ELEMENT_TYPE_INTERFACE, tag.ElementSubType_ELEMENT_SUB_TYPE_NULL, false},
+		{"interface", "external", tag.ElementType_ELEMENT_TYPE_INTERFACE, tag.ElementSubType_ELEMENT_SUB_TYPE_EXTERNAL, false},
+		{"interface", "EXTERNAL", tag.ElementType_ELEMENT_TYPE_INTERFACE, tag.ElementSubType_ELEMENT_SUB_TYPE_EXTERNAL, false},
+		{"interface", "null", tag.ElementType_ELEMENT_TYPE_INTERFACE, tag.ElementSubType_ELEMENT_SUB_TYPE_NULL, false},
+		{"interface", "unspecified", 0, 0, true},
+		{"interface", "virtual", 0, 0, true},
+		// Les tags de device n'ont pas de sous-type.
+		{"device", "external", 0, 0, true},
+		{"port", "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		et, st, err := parseElementKind(tt.elementType, tt.subType)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s/%s : accepté", tt.elementType, tt.subType)
+			}
+			continue
+		}
+		if err != nil || et != tt.wantType || st != tt.wantSubType {
+			t.Errorf("%s/%s : %v, %v (%v), attendu %v, %v", tt.elementType, tt.subType, et, st, err, tt.wantType, tt.wantSubType)
+			continue
+		}
+		// Le nom court relu dans une clé redonne le même sous-type.
+		if _, again, err := parseElementKind(tt.elementType, elementSubTypeName(st)); err != nil || again != st {
+			t.Errorf("%s/%s : %q relu en %v (%v)", tt.elementType, tt.subType, elementSubTypeName(st), again, err)
+		}
+	}
+}
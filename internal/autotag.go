@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+)
+
+// AutoTagRule dérive des tags d'un attribut d'inventaire (voir DeviceAttributes).
+//
+// Match est une expression régulière appliquée à l'attribut (par défaut, toute valeur
+// non vide) ; chaque entrée de Tags associe un label à une valeur pouvant référencer
+// les groupes capturés ($0, $1, ${nom}). Une valeur vide après expansion est ignorée.
+type AutoTagRule struct {
+	Attribute string            `yaml:"attribute"`
+	Match     string            `yaml:"match,omitempty"`
+	Tags      map[string]string `yaml:"tags"`
+
+	re *regexp.Regexp
+}
+
+// AutoTagRules est le contenu d'un fichier de règles d'auto-tagging.
+type AutoTagRules struct {
+	Rules []AutoTagRule `yaml:"rules"`
+}
+
+// LoadAutoTagRules lit et valide un fichier de règles d'auto-tagging.
+func LoadAutoTagRules(path string) (AutoTagRules, error) {
+	var rules AutoTagRules
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return rules, fmt.Errorf("%s : %w", path, err)
+	}
+	attributes := DeviceAttributes(DeviceInfo{})
+	for i := range rules.Rules {
+		r := &rules.Rules[i]
+		if _, ok := attributes[r.Attribute]; !ok {
+			return rules, fmt.Errorf("%s : règle %d : attribut inconnu %q", path, i+1, r.Attribute)
+		}
+		if len(r.Tags) == 0 {
+			return rules, fmt.Errorf("%s : règle %d : aucun tag", path, i+1)
+		}
+		match := r.Match
+		if match == "" {
+			match = ".+"
+		}
+		if r.re, err = regexp.Compile(match); err != nil {
+			return rules, fmt.Errorf("%s : règle %d : %w", path, i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+// Evaluate applique les règles à un device et retourne les tags dérivés (label → valeur).
+// Si plusieurs règles dérivent le même label, la première l'emporte.
+func (r AutoTagRules) Evaluate(d DeviceInfo) map[string]string {
+	attributes := DeviceAttributes(d)
+	tags := map[string]string{}
+	for _, rule := range r.Rules {
+		value := attributes[rule.Attribute]
+		m := rule.re.FindStringSubmatchIndex(value)
+		if m == nil {
+			continue
+		}
+		for label, tmpl := range rule.Tags {
+			if _, ok := tags[label]; ok {
+				continue
+			}
+			if v := string(rule.re.ExpandString(nil, tmpl, value, m)); v != "" {
+				tags[label] = v
+			}
+		}
+	}
+	return tags
+}
+
+// PlanAutoTags calcule les modifications à écrire pour que mainline porte les tags
+// dérivés des règles : tags à créer et assignations à ajouter.
+//
+// Avec prune, les autres valeurs d'un label dérivé sont retirées du device (ex : l'ancien
+// `role` d'un switch renommé). Seules les assignations créées par un utilisateur sont retirées.
+//
+// Panique :
+//   - Si la lecture des tags ou des assignations de mainline échoue.
+func PlanAutoTags(ctx context.Context, conn *grpc.ClientConn, rules AutoTagRules, devices []DeviceInfo, prune bool) WorkspaceChanges {
+	mainline := readMainlineTags(ctx, conn)
+
+	var defs []TagChange
+	var desired []TagAssignmentChange
+	derived := map[string]bool{}
+	for _, d := range devices {
+		tags := rules.Evaluate(d)
+		labels := make([]string, 0, len(tags))
+		for label := range tags {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			derived[d.DeviceID+"|"+label] = true
+			defs = append(defs, TagChange{Action: ChangeAdd, Label: label, Value: tags[label], ElementType: "device"})
+			desired = append(desired, TagAssignmentChange{
+				Action: ChangeAdd, Label: label, Value: tags[label], ElementType: "device",
+				DeviceID: d.DeviceID, Hostname: d.Hostname,
+			})
+		}
+	}
+
+	var prunable func(TagAssignmentInfo) bool
+	if prune {
+		prunable = func(a TagAssignmentInfo) bool { return derived[a.DeviceID+"|"+a.Label] }
+	}
+	return mainline.plan(defs, desired, devices, prunable)
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testAutoTagRules sont les règles de l'exemple de la demande : modèle → platform,
+// hostname → site/role, version d'EOS → eos-train.
+const testAutoTagRules = `rules:
+  - attribute: model
+    tags: {platform: $0}
+  - attribute: hostname
+    match: '^(?P<site>\w+)-(leaf|spine)'
+    tags: {site: '${site}', role: '$2'}
+  - attribute: hostname
+    match: '^(\w+)-'
+    tags: {site: autre, pod: '${1}'}
+  - attribute: version
+    match: '^(\d+\.\d+)\.'
+    tags: {eos-train: '$1'}
+`
+
+// loadTestRules écrit des règles dans un fichier temporaire et les charge.
+func loadTestRules(t *testing.T, content string) (AutoTagRules, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadAutoTagRules(path)
+}
+
+func TestAutoTagEvaluate(t *testing.T) {
+	rules, err := loadTestRules(t, testAutoTagRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		device DeviceInfo
+		want   map[string]string
+	}{
+		{
+			name:   "toutes les règles",
+			device: DeviceInfo{Hostname: "paris-leaf-1", Model: "DCS-7280SR", Version: "4.31.5M"},
+			// La première règle dérivant site l'emporte.
+			want: map[string]string{"platform": "DCS-7280SR", "site": "paris", "role": "leaf", "pod": "paris", "eos-train": "4.31"},
+		},
+		{
+			name:   "rôle non reconnu",
+			device: DeviceInfo{Hostname: "lyon-border-1", Model: "cEOSLab", Version: "4.30.1F"},
+			want:   map[string]string{"platform": "cEOSLab", "site": "autre", "pod": "lyon", "eos-train": "4.30"},
+		},
+		{
+			name:   "attributs vides",
+			device: DeviceInfo{Hostname: "standalone"},
+			want:   map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		if got := rules.Evaluate(tt.device); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : tags = %v, attendu %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Une valeur vide après expansion n'est pas un tag.
+	rules, err = loadTestRules(t, "rules:\n  - {attribute: hostname, match: '^(\\w+)-(\\d*)$', tags: {rack: '$2'}}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rules.Evaluate(DeviceInfo{Hostname: "spine-"}); len(got) != 0 {
+		t.Errorf("valeur vide dérivée : %v", got)
+	}
+}
+
+func TestLoadAutoTagRulesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"attribut inconnu", "rules:\n  - {attribute: serial, tags: {a: b}}\n", `attribut inconnu "serial"`},
+		{"aucun tag", "rules:\n  - {attribute: model}\n", "règle 1 : aucun tag"},
+		{"expression invalide", "rules:\n  - {attribute: model, tags: {a: b}}\n  - {attribute: hostname, match: '(', tags: {a: b}}\n", "règle 2"},
+		{"champ inconnu", "rules:\n  - {attribute: model, regex: '.*', tags: {a: b}}\n", "regex"},
+	}
+	for _, tt := range tests {
+		_, err := loadTestRules(t, tt.content)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s : erreur = %v, attendu %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
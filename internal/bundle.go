@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// BundleAPIVersion est la version courante du format des bundles de workspace.
+// Elle est vérifiée à l'import pour refuser un fichier d'un format inconnu.
+const BundleAPIVersion = "cvaas-cli/v1"
+
+// bundleKind est le type de document attendu dans un bundle.
+const bundleKind = "WorkspaceBundle"
+
+// BundleMetadata décrit l'origine d'un bundle.
+type BundleMetadata struct {
+	SourceWorkspaceID   string    `yaml:"sourceWorkspaceId"`
+	SourceWorkspaceName string    `yaml:"sourceWorkspaceName,omitempty"`
+	Profile             string    `yaml:"profile,omitempty"`
+	ExportedAt          time.Time `yaml:"exportedAt"`
+}
+
+// BundleDevice associe un deviceId du tenant source à son hostname.
+type BundleDevice struct {
+	DeviceID string `yaml:"deviceId"`
+	Hostname string `yaml:"hostname"`
+}
+
+// Bundle est l'export, éditable à la main, des modifications d'un workspace.
+// Les assignations de tags portent le hostname des équipements, et Devices celui des
+// équipements cités dans les inputs de studios et les requêtes d'assignation, pour
+// permettre leur remappage vers l'inventaire d'un autre tenant à l'import.
+type Bundle struct {
+	APIVersion string           `yaml:"apiVersion"`
+	Kind       string           `yaml:"kind"`
+	Metadata   BundleMetadata   `yaml:"metadata"`
+	Devices    []BundleDevice   `yaml:"devices,omitempty"`
+	Changes    WorkspaceChanges `yaml:"changes"`
+}
+
+// NewBundle construit un bundle au format courant à partir des modifications d'un
+// workspace. Les équipements de devices (inventaire du tenant source) cités dans les
+// inputs de studios ou les requêtes d'assignation sont enregistrés dans Devices.
+func NewBundle(ws WorkspaceInfo, profile string, changes WorkspaceChanges, devices []DeviceInfo) Bundle {
+	ids := map[string]bool{}
+	for _, field := range deviceIDFields(&changes) {
+		for _, token := range deviceIDTokens(*field) {
+			ids[token] = true
+		}
+	}
+	var referenced []BundleDevice
+	for _, d := range devices {
+		if ids[d.DeviceID] {
+			referenced = append(referenced, BundleDevice{DeviceID: d.DeviceID, Hostname: d.Hostname})
+		}
+	}
+	return Bundle{
+		APIVersion: BundleAPIVersion,
+		Kind:       bundleKind,
+		Metadata: BundleMetadata{
+			SourceWorkspaceID:   ws.ID,
+			SourceWorkspaceName: ws.DisplayName,
+			Profile:             profile,
+			ExportedAt:          time.Now().UTC(),
+		},
+		Devices: referenced,
+		Changes: changes,
+	}
+}
+
+// WriteBundle écrit un bundle au format YAML dans path.
+func WriteBundle(path string, bundle Bundle) error {
+	data, err := yaml.Marshal(&bundle)
+	if err != nil {
+		return fmt.Errorf("encodage YAML : %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadBundle lit un bundle YAML et vérifie sa version et son type.
+func ReadBundle(path string) (Bundle, error) {
+	var bundle Bundle
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return bundle, err
+	}
+	if err := yaml.UnmarshalStrict(data, &bundle); err != nil {
+		return bundle, fmt.Errorf("décodage %s : %w", path, err)
+	}
+	if bundle.APIVersion != BundleAPIVersion {
+		return bundle, fmt.Errorf("version de bundle non supportée : %q (attendu %q)", bundle.APIVersion, BundleAPIVersion)
+	}
+	if bundle.Kind != bundleKind {
+		return bundle, fmt.Errorf("type de document inattendu : %q (attendu %q)", bundle.Kind, bundleKind)
+	}
+	return bundle, nil
+}
+
+// RemapDevices réécrit les deviceId du tenant source présents dans les modifications
+// d'un bundle d'après l'inventaire cible, en se basant sur le hostname :
+//   - les assignations de tags sans hostname, ou dont le hostname est absent de
+//     l'inventaire cible, sont retirées : leur deviceId est celui du tenant source ;
+//   - dans les inputs de studios (valeurs et chemins) et les requêtes d'assignation de
+//     studios et de configlets, les deviceId connus du bundle sont remplacés.
+//
+// Un deviceId cité dans un input ou une requête ne peut pas être retiré sans modifier
+// leur sens : s'il est absent de l'inventaire cible, il est signalé et laissé tel quel.
+// bundle n'est pas modifié.
+//
+// Retourne :
+//   - WorkspaceChanges : les modifications remappées.
+//   - []TagAssignmentChange : les assignations retirées.
+//   - []BundleDevice : les équipements cités dans un input ou une requête et absents
+//     de l'inventaire cible.
+func RemapDevices(bundle Bundle, devices []DeviceInfo) (WorkspaceChanges, []TagAssignmentChange, []BundleDevice) {
+	byHostname := map[string]string{}
+	for _, d := range devices {
+		byHostname[d.Hostname] = d.DeviceID
+	}
+	changes := bundle.Changes
+
+	var remapped, dropped []TagAssignmentChange
+	for _, a := range changes.TagAssignments {
+		id, ok := byHostname[a.Hostname]
+		if a.Hostname == "" || !ok {
+			dropped = append(dropped, a)
+			continue
+		}
+		a.DeviceID = id
+		remapped = append(remapped, a)
+	}
+	changes.TagAssignments = remapped
+
+	// Équipements source connus du bundle : ceux de Devices et des assignations de tags.
+	sources := map[string]string{}
+	for _, a := range bundle.Changes.TagAssignments {
+		if a.Hostname != "" {
+			sources[a.DeviceID] = a.Hostname
+		}
+	}
+	for _, d := range bundle.Devices {
+		sources[d.DeviceID] = d.Hostname
+	}
+
+	// Copie des modifications réécrites, pour ne pas modifier le bundle.
+	changes.StudioInputs = slices.Clone(changes.StudioInputs)
+	for i := range changes.StudioInputs {
+		changes.StudioInputs[i].Path = slices.Clone(changes.StudioInputs[i].Path)
+	}
+	changes.StudioAssignments = slices.Clone(changes.StudioAssignments)
+	changes.ConfigletAssignments = slices.Clone(changes.ConfigletAssignments)
+	for i, a := range changes.ConfigletAssignments {
+		if a.Query != nil {
+			query := *a.Query
+			changes.ConfigletAssignments[i].Query = &query
+		}
+	}
+
+	missing := map[string]bool{}
+	for _, field := range deviceIDFields(&changes) {
+		*field = replaceDeviceIDs(*field, func(id string) string {
+			hostname, ok := sources[id]
+			if !ok {
+				return id
+			}
+			target, ok := byHostname[hostname]
+			if !ok {
+				missing[id] = true
+				return id
+			}
+			return target
+		})
+	}
+	var unresolved []BundleDevice
+	for id := range missing {
+		unresolved = append(unresolved, BundleDevice{DeviceID: id, Hostname: sources[id]})
+	}
+	slices.SortFunc(unresolved, func(a, b BundleDevice) int { return strings.Compare(a.Hostname, b.Hostname) })
+	return changes, dropped, unresolved
+}
+
+// deviceIDFields retourne les champs des modifications susceptibles de citer des
+// deviceId : valeurs et chemins des inputs de studios, requêtes d'assignation.
+func deviceIDFields(changes *WorkspaceChanges) []*string {
+	var fields []*string
+	for i := range changes.StudioInputs {
+		in := &changes.StudioInputs[i]
+		fields = append(fields, &in.Inputs)
+		for j := range in.Path {
+			fields = append(fields, &in.Path[j])
+		}
+	}
+	for i := range changes.StudioAssignments {
+		fields = append(fields, &changes.StudioAssignments[i].Query)
+	}
+	for i := range changes.ConfigletAssignments {
+		if q := changes.ConfigletAssignments[i].Query; q != nil {
+			fields = append(fields, q)
+		}
+	}
+	return fields
+}
+
+// isDeviceIDByte indique si c peut faire partie d'un deviceId (numéro de série ou
+// adresse MAC) : lettres, chiffres, '-', '_' et '.'.
+func isDeviceIDByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == '.'
+}
+
+// deviceIDTokens découpe s en suites maximales de caractères de deviceId. Un deviceId
+// est cité dans un input JSON ("JPE123") ou une requête (device:JPE123) sous la forme
+// d'une telle suite.
+func deviceIDTokens(s string) []string {
+	var tokens []string
+	replaceDeviceIDs(s, func(token string) string {
+		tokens = append(tokens, token)
+		return token
+	})
+	return tokens
+}
+
+// replaceDeviceIDs remplace chaque suite maximale de caractères de deviceId de s par
+// replace(suite).
+func replaceDeviceIDs(s string, replace func(string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if !isDeviceIDByte(s[i]) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isDeviceIDByte(s[j]) {
+			j++
+		}
+		b.WriteString(replace(s[i:j]))
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBundleWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.yaml")
+	body := "hostname leaf-1\n"
+	changes := WorkspaceChanges{
+		Tags:       []TagChange{{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device"}},
+		Configlets: []ConfigletChange{{Action: ChangeModify, ConfigletID: "base", Body: &body}},
+		Unreadable: []ResourceError{{Kind: KindStudioInput, Error: "refusé"}},
+	}
+	bundle := NewBundle(WorkspaceInfo{ID: "ws-1", DisplayName: "Paris"}, "lab", changes, nil)
+	if err := WriteBundle(path, bundle); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadBundle(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unreadable n'est pas exporté.
+	bundle.Changes.Unreadable = nil
+	if !reflect.DeepEqual(got.Changes, bundle.Changes) || got.Metadata.SourceWorkspaceID != "ws-1" {
+		t.Errorf("bundle relu = %+v, attendu %+v", got, bundle)
+	}
+
+	bundle.APIVersion = "cvaas-cli/v0"
+	if err := WriteBundle(path, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBundle(path); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("version inconnue acceptée : %v", err)
+	}
+}
+
+func TestNewBundleDevices(t *testing.T) {
+	query := "device:SN2 OR site:Paris"
+	changes := WorkspaceChanges{
+		StudioInputs:         []StudioInputChange{{StudioID: "evpn", Path: []string{"devices", "SN1"}, Inputs: `{"peer":"SN3"}`}},
+		ConfigletAssignments: []ConfigletAssignmentChange{{AssignmentID: "a1", Query: &query}},
+	}
+	devices := []DeviceInfo{
+		{DeviceID: "SN1", Hostname: "leaf-1"},
+		{DeviceID: "SN2", Hostname: "leaf-2"},
+		{DeviceID: "SN3", Hostname: "spine-1"},
+		{DeviceID: "SN4", Hostname: "spine-2"},
+		// Préfixe d'un autre deviceId : non cité.
+		{DeviceID: "SN", Hostname: "lab"},
+	}
+	want := []BundleDevice{{"SN1", "leaf-1"}, {"SN2", "leaf-2"}, {"SN3", "spine-1"}}
+	if got := NewBundle(WorkspaceInfo{}, "lab", changes, devices).Devices; !reflect.DeepEqual(got, want) {
+		t.Errorf("équipements = %v, attendu %v", got, want)
+	}
+}
+
+func TestRemapDevices(t *testing.T) {
+	configletQuery := "device:SRC1 AND NOT device:SRC10"
+	bundle := Bundle{
+		Devices: []BundleDevice{{"SRC1", "leaf-1"}, {"SRC2", "leaf-2"}, {"SRC10", "leaf-10"}},
+		Changes: WorkspaceChanges{
+			TagAssignments: []TagAssignmentChange{
+				{Label: "site", Value: "Paris", DeviceID: "SRC1", Hostname: "leaf-1"},
+				{Label: "site", Value: "Paris", DeviceID: "SRC9", Hostname: "leaf-9"},
+				{Label: "site", Value: "Paris", DeviceID: "SRC8"},
+			},
+			StudioInputs: []StudioInputChange{
+				{StudioID: "evpn", Path: []string{"devices", "SRC1"}, Inputs: `{"peer":"SRC2","name":"SRC1-uplink"}`},
+			},
+			StudioAssignments:    []StudioAssignmentChange{{StudioID: "evpn", Query: "device:SRC1 OR device:SRC2"}},
+			ConfigletAssignments: []ConfigletAssignmentChange{{AssignmentID: "a1", Query: &configletQuery}},
+		},
+	}
+	devices := []DeviceInfo{
+		{DeviceID: "DST1", Hostname: "leaf-1"},
+		{DeviceID: "DST2", Hostname: "leaf-2"},
+		{DeviceID: "DST9", Hostname: "leaf-9"},
+	}
+
+	changes, dropped, unresolved := RemapDevices(bundle, devices)
+
+	var assigned []string
+	for _, a := range changes.TagAssignments {
+		assigned = append(assigned, a.DeviceID)
+	}
+	if want := []string{"DST1", "DST9"}; !reflect.DeepEqual(assigned, want) {
+		t.Errorf("assignations = %v, attendu %v", assigned, want)
+	}
+	if len(dropped) != 1 || dropped[0].DeviceID != "SRC8" {
+		t.Errorf("assignations retirées = %v, attendu SRC8", dropped)
+	}
+
+	in := changes.StudioInputs[0]
+	if want := []string{"devices", "DST1"}; !reflect.DeepEqual(in.Path, want) {
+		t.Errorf("chemin = %v, attendu %v", in.Path, want)
+	}
+	// Seules les citations isolées sont remplacées.
+	if want := `{"peer":"DST2","name":"SRC1-uplink"}`; in.Inputs != want {
+		t.Errorf("inputs = %s, attendu %s", in.Inputs, want)
+	}
+	if got, want := changes.StudioAssignments[0].Query, "device:DST1 OR device:DST2"; got != want {
+		t.Errorf("requête de studio = %q, attendu %q", got, want)
+	}
+	if got, want := *changes.ConfigletAssignments[0].Query, "device:DST1 AND NOT device:SRC10"; got != want {
+		t.Errorf("requête de configlets = %q, attendu %q", got, want)
+	}
+	if want := []BundleDevice{{"SRC10", "leaf-10"}}; !reflect.DeepEqual(unresolved, want) {
+		t.Errorf("équipements non résolus = %v, attendu %v", unresolved, want)
+	}
+
+	// Le bundle d'origine n'est pas modifié.
+	if bundle.Changes.StudioInputs[0].Path[1] != "SRC1" || configletQuery != "device:SRC1 AND NOT device:SRC10" ||
+		bundle.Changes.StudioAssignments[0].Query != "device:SRC1 OR device:SRC2" {
+		t.Errorf("bundle modifié : %+v", bundle.Changes)
+	}
+}
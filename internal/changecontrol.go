@@ -0,0 +1,421 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	changecontrol "github.com/aristanetworks/cloudvision-go/api/arista/changecontrol.v1"
+	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// changeControlPollInterval est l'intervalle entre deux lectures d'un change control
+// pendant l'attente de sa création ou de son exécution.
+const changeControlPollInterval = 2 * time.Second
+
+// changeControlKey construit la clé changecontrol.v1 d'un change control.
+func changeControlKey(id string) *changecontrol.ChangeControlKey {
+	return &changecontrol.ChangeControlKey{Id: wrapperspb.String(id)}
+}
+
+// getChangeControl lit un change control sur le ChangeControlService.
+func getChangeControl(ctx context.Context, conn *grpc.ClientConn, id string) (*changecontrol.ChangeControl, error) {
+	client := changecontrol.NewChangeControlServiceClient(conn)
+	resp, err := client.GetOne(ctx, &changecontrol.ChangeControlRequest{Key: changeControlKey(id)})
+	if err != nil {
+		return nil, fmt.Errorf("lecture du change control %s : %w", id, err)
+	}
+	return resp.GetValue(), nil
+}
+
+// submittedWithoutChangeControlGrace est le délai laissé à CloudVision pour associer ses
+// change controls à un workspace soumis avant de conclure qu'il n'en aura pas.
+const submittedWithoutChangeControlGrace = 15 * time.Second
+
+// WaitForWorkspaceChangeControls attend que CloudVision ait créé les change controls
+// d'un workspace soumis, et les retourne. Un workspace soumis sans modification de
+// configuration de device (ex : tags seuls) n'a pas de change control : l'attente
+// échoue alors après un court délai au lieu d'aller au bout du contexte.
+//
+// Retourne :
+//   - []string : les IDs des change controls du workspace.
+//   - error : l'erreur gRPC, celle du contexte si aucun change control n'apparaît à temps,
+//     ou une erreur si le workspace est soumis ou clos sans change control.
+func WaitForWorkspaceChangeControls(ctx context.Context, conn *grpc.ClientConn, workspaceID string) ([]string, error) {
+	ticker := time.NewTicker(workspacePollInterval)
+	defer ticker.Stop()
+	pending := workspace.WorkspaceState_WORKSPACE_STATE_PENDING.String()
+	submitted := workspace.WorkspaceState_WORKSPACE_STATE_SUBMITTED.String()
+	var submittedSince time.Time
+	for {
+		ws := FindWorkspace(ctx, conn, workspaceID, "")
+		if ws == nil {
+			return nil, fmt.Errorf("workspace %s introuvable", workspaceID)
+		}
+		if len(ws.ChangeControlIDs) > 0 {
+			return ws.ChangeControlIDs, nil
+		}
+		switch ws.State {
+		case pending:
+		case submitted:
+			if submittedSince.IsZero() {
+				submittedSince = time.Now()
+			} else if time.Since(submittedSince) >= submittedWithoutChangeControlGrace {
+				return nil, fmt.Errorf("workspace %s soumis sans change control : il ne modifie la configuration d'aucun device (ex : tags seuls)", workspaceID)
+			}
+		default:
+			return nil, fmt.Errorf("workspace %s dans l'état %s : aucun change control ne sera créé", workspaceID, ws.State)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("aucun change control pour le workspace %s (état %s) : %w", workspaceID, ws.State, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// RenameChangeControl modifie le nom et les notes d'un change control ; une valeur
+// vide laisse le champ correspondant inchangé.
+func RenameChangeControl(ctx context.Context, conn *grpc.ClientConn, id, name, notes string) error {
+	change := &changecontrol.ChangeConfig{}
+	if name != "" {
+		change.Name = wrapperspb.String(name)
+	}
+	if notes != "" {
+		change.Notes = wrapperspb.String(notes)
+	}
+	client := changecontrol.NewChangeControlConfigServiceClient(conn)
+	_, err := client.Set(ctx, &changecontrol.ChangeControlConfigSetRequest{Value: &changecontrol.ChangeControlConfig{
+		Key:    changeControlKey(id),
+		Change: change,
+	}})
+	return err
+}
+
+// ApproveChangeControl approuve un change control. L'approbation porte sur la version
+// courante du change control (l'horodatage de sa dernière modification) : CloudVision
+// la refuse si le change control a été modifié entre-temps.
+func ApproveChangeControl(ctx context.Context, conn *grpc.ClientConn, id, notes string) error {
+	cc, err := getChangeControl(ctx, conn, id)
+	if err != nil {
+		return err
+	}
+	client := changecontrol.NewApproveConfigServiceClient(conn)
+	_, err = client.Set(ctx, &changecontrol.ApproveConfigSetRequest{Value: &changecontrol.ApproveConfig{
+		Key:     changeControlKey(id),
+		Approve: &changecontrol.FlagConfig{Value: wrapperspb.Bool(true), Notes: wrapperspb.String(notes)},
+		Version: cc.GetChange().GetTime(),
+	}})
+	return err
+}
+
+// StartChangeControl demande l'exécution d'un change control approuvé.
+func StartChangeControl(ctx context.Context, conn *grpc.ClientConn, id, notes string) error {
+	client := changecontrol.NewChangeControlConfigServiceClient(conn)
+	_, err := client.Set(ctx, &changecontrol.ChangeControlConfigSetRequest{Value: &changecontrol.ChangeControlConfig{
+		Key:   changeControlKey(id),
+		Start: &changecontrol.FlagConfig{Value: wrapperspb.Bool(true), Notes: wrapperspb.String(notes)},
+	}})
+	return err
+}
+
+// WaitForChangeControl attend la fin de l'exécution d'un change control.
+//
+// Retourne :
+//   - error : nil si le change control s'est terminé sans erreur, son message d'erreur
+//     sinon, ou l'erreur du contexte si le délai expire avant la fin.
+func WaitForChangeControl(ctx context.Context, conn *grpc.ClientConn, id string) error {
+	ticker := time.NewTicker(changeControlPollInterval)
+	defer ticker.Stop()
+	for {
+		cc, err := getChangeControl(ctx, conn, id)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if cc.GetStatus() == changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_COMPLETED {
+			if msg := cc.GetError().GetValue(); msg != "" {
+				return fmt.Errorf("change control %s en échec : %s", id, msg)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("change control %s non terminé (%s) : %w", id, cc.GetStatus(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// États d'un change control ou d'une étape, tels qu'affichés et filtrés par la CLI.
+const (
+	// ChangeControlPending : non démarré ou planifié.
+	ChangeControlPending = "pending"
+	// ChangeControlRunning : en cours d'exécution.
+	ChangeControlRunning = "running"
+	// ChangeControlCompleted : terminé sans erreur.
+	ChangeControlCompleted = "completed"
+	// ChangeControlFailed : terminé en erreur.
+	ChangeControlFailed = "failed"
+)
+
+// changeControlStatuses liste les états acceptés par le filtre de ListChangeControls.
+var changeControlStatuses = map[string]bool{
+	ChangeControlPending:   true,
+	ChangeControlRunning:   true,
+	ChangeControlCompleted: true,
+	ChangeControlFailed:    true,
+}
+
+// ChangeControlFlag est une approbation ou un démarrage de change control.
+type ChangeControlFlag struct {
+	User  string    `json:"user,omitempty"`
+	Time  time.Time `json:"time"`
+	Notes string    `json:"notes,omitempty"`
+}
+
+// ChangeControlStage est une étape d'un change control. Une étape porte soit une
+// action (sur un device, le plus souvent), soit des sous-étapes organisées en rangées :
+// les rangées s'exécutent successivement, les étapes d'une rangée en parallèle.
+type ChangeControlStage struct {
+	ID        string                  `json:"id"`
+	Name      string                  `json:"name,omitempty"`
+	Status    string                  `json:"status"`
+	Error     string                  `json:"error,omitempty"`
+	Action    string                  `json:"action,omitempty"`
+	DeviceID  string                  `json:"deviceId,omitempty"`
+	Hostname  string                  `json:"hostname,omitempty"`
+	Args      map[string]string       `json:"args,omitempty"`
+	StartTime *time.Time              `json:"startTime,omitempty"`
+	EndTime   *time.Time              `json:"endTime,omitempty"`
+	Rows      [][]*ChangeControlStage `json:"rows,omitempty"`
+}
+
+// ChangeControlInfo contient les informations d'un change control lues sur le
+// ChangeControlService.
+type ChangeControlInfo struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Notes  string `json:"notes,omitempty"`
+	// ModifiedBy et ModifiedAt désignent la dernière modification de la définition.
+	ModifiedBy string             `json:"modifiedBy,omitempty"`
+	ModifiedAt time.Time          `json:"modifiedAt"`
+	Approval   *ChangeControlFlag `json:"approval,omitempty"`
+	Start      *ChangeControlFlag `json:"start,omitempty"`
+	// UpdatedAt est la dernière activité : modification, approbation, démarrage ou
+	// fin d'une étape.
+	UpdatedAt time.Time           `json:"updatedAt"`
+	Root      *ChangeControlStage `json:"root,omitempty"`
+}
+
+// changeControlStatusName convertit l'état d'un change control ou d'une étape en état
+// de la CLI : une exécution terminée avec une erreur est en échec.
+func changeControlStatusName(running, completed bool, errMsg string) string {
+	switch {
+	case running:
+		return ChangeControlRunning
+	case completed && errMsg != "":
+		return ChangeControlFailed
+	case completed:
+		return ChangeControlCompleted
+	default:
+		return ChangeControlPending
+	}
+}
+
+// asTime convertit un horodatage protobuf, zéro s'il est absent.
+func asTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// optionalTime convertit un horodatage protobuf, nil s'il est absent.
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// changeControlFlag convertit une approbation ou un démarrage ; nil s'il n'est pas positionné.
+func changeControlFlag(f *changecontrol.Flag) *ChangeControlFlag {
+	if !f.GetValue().GetValue() {
+		return nil
+	}
+	return &ChangeControlFlag{User: f.GetUser().GetValue(), Time: asTime(f.GetTime()), Notes: f.GetNotes().GetValue()}
+}
+
+// newChangeControlInfo convertit un change control et construit l'arbre de ses étapes
+// à partir de l'étape racine.
+func newChangeControlInfo(cc *changecontrol.ChangeControl) ChangeControlInfo {
+	change := cc.GetChange()
+	info := ChangeControlInfo{
+		ID:   cc.GetKey().GetId().GetValue(),
+		Name: change.GetName().GetValue(),
+		Status: changeControlStatusName(
+			cc.GetStatus() == changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_RUNNING,
+			cc.GetStatus() == changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_COMPLETED,
+			cc.GetError().GetValue()),
+		Error:      cc.GetError().GetValue(),
+		Notes:      change.GetNotes().GetValue(),
+		ModifiedBy: change.GetUser().GetValue(),
+		ModifiedAt: asTime(change.GetTime()),
+		Approval:   changeControlFlag(cc.GetApprove()),
+		Start:      changeControlFlag(cc.GetStart()),
+	}
+	info.UpdatedAt = info.ModifiedAt
+	latest := func(t time.Time) {
+		if t.After(info.UpdatedAt) {
+			info.UpdatedAt = t
+		}
+	}
+	if info.Approval != nil {
+		latest(info.Approval.Time)
+	}
+	if info.Start != nil {
+		latest(info.Start.Time)
+	}
+
+	stages := change.GetStages().GetValues()
+	for _, s := range stages {
+		latest(asTime(s.GetEndTime()))
+	}
+	// visited protège d'une étape référencée plusieurs fois ou d'un cycle.
+	visited := map[string]bool{}
+	var build func(id string) *ChangeControlStage
+	build = func(id string) *ChangeControlStage {
+		s, ok := stages[id]
+		if !ok || visited[id] {
+			return nil
+		}
+		visited[id] = true
+		stage := &ChangeControlStage{
+			ID:   id,
+			Name: s.GetName().GetValue(),
+			Status: changeControlStatusName(
+				s.GetStatus() == changecontrol.StageStatus_STAGE_STATUS_RUNNING,
+				s.GetStatus() == changecontrol.StageStatus_STAGE_STATUS_COMPLETED,
+				s.GetError().GetValue()),
+			Error:     s.GetError().GetValue(),
+			Action:    s.GetAction().GetName().GetValue(),
+			Args:      s.GetAction().GetArgs().GetValues(),
+			StartTime: optionalTime(s.GetStartTime()),
+			EndTime:   optionalTime(s.GetEndTime()),
+		}
+		stage.DeviceID = stage.Args["DeviceID"]
+		for _, row := range s.GetRows().GetValues() {
+			var children []*ChangeControlStage
+			for _, childID := range row.GetValues() {
+				if child := build(childID); child != nil {
+					children = append(children, child)
+				}
+			}
+			if len(children) > 0 {
+				stage.Rows = append(stage.Rows, children)
+			}
+		}
+		return stage
+	}
+	info.Root = build(change.GetRootStageId().GetValue())
+	return info
+}
+
+// ListChangeControls retourne les change controls, du plus récemment actif au plus ancien.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - status : état à filtrer (pending, running, completed, failed), ignoré si vide
+//   - since : seuls les change controls actifs depuis cette date sont retournés
+//     (ignoré si zéro)
+//
+// Retourne :
+//   - []ChangeControlInfo : les change controls sélectionnés.
+//   - error : si l'état est invalide ou si la lecture échoue.
+func ListChangeControls(ctx context.Context, conn *grpc.ClientConn, status string, since time.Time) ([]ChangeControlInfo, error) {
+	if status != "" && !changeControlStatuses[status] {
+		return nil, fmt.Errorf("état invalide : %q (pending, running, completed ou failed)", status)
+	}
+	client := changecontrol.NewChangeControlServiceClient(conn)
+	stream, err := client.GetAll(ctx, &changecontrol.ChangeControlStreamRequest{})
+	if err != nil {
+		return nil, err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return nil, err
+	}
+	ccs := make([]*changecontrol.ChangeControl, 0, len(resps))
+	for _, r := range resps {
+		ccs = append(ccs, r.GetValue())
+	}
+	return selectChangeControls(ccs, status, since), nil
+}
+
+// selectChangeControls convertit les change controls lus, ne garde que ceux dans l'état
+// status (si non vide) et actifs depuis since (si non zéro), puis les trie du plus
+// récemment actif au plus ancien.
+func selectChangeControls(ccs []*changecontrol.ChangeControl, status string, since time.Time) []ChangeControlInfo {
+	var infos []ChangeControlInfo
+	for _, cc := range ccs {
+		info := newChangeControlInfo(cc)
+		if status != "" && info.Status != status {
+			continue
+		}
+		if !since.IsZero() && info.UpdatedAt.Before(since) {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(a, b int) bool { return infos[a].UpdatedAt.After(infos[b].UpdatedAt) })
+	return infos
+}
+
+// GetChangeControlInfo lit un change control et l'arbre de ses étapes. Le hostname
+// des devices concernés par les actions est résolu via l'inventaire.
+//
+// Retourne :
+//   - *ChangeControlInfo : le change control.
+//   - error : l'erreur gRPC (NotFound si le change control n'existe pas).
+func GetChangeControlInfo(ctx context.Context, conn *grpc.ClientConn, id string) (*ChangeControlInfo, error) {
+	cc, err := getChangeControl(ctx, conn, id)
+	if err != nil {
+		return nil, err
+	}
+	info := newChangeControlInfo(cc)
+	var withDevice []*ChangeControlStage
+	var walk func(s *ChangeControlStage)
+	walk = func(s *ChangeControlStage) {
+		if s.DeviceID != "" {
+			withDevice = append(withDevice, s)
+		}
+		for _, row := range s.Rows {
+			for _, child := range row {
+				walk(child)
+			}
+		}
+	}
+	if info.Root != nil {
+		walk(info.Root)
+	}
+	if len(withDevice) > 0 {
+		hostnames := map[string]string{}
+		for _, d := range ReadInventory(ctx, conn, "", false, false) {
+			hostnames[d.DeviceID] = d.Hostname
+		}
+		for _, s := range withDevice {
+			s.Hostname = hostnames[s.DeviceID]
+		}
+	}
+	return &info, nil
+}
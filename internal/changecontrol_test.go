@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	changecontrol "github.com/aristanetworks/cloudvision-go/api/arista/changecontrol.v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestChangeControlStatusName(t *testing.T) {
+	tests := []struct {
+		running, completed bool
+		errMsg             string
+		want               string
+	}{
+		{false, false, "", ChangeControlPending},
+		{true, false, "", ChangeControlRunning},
+		// Une erreur pendant l'exécution ne la termine pas.
+		{true, false, "timeout", ChangeControlRunning},
+		{false, true, "", ChangeControlCompleted},
+		{false, true, "échec du device", ChangeControlFailed},
+		// Une erreur avant l'exécution laisse le change control en attente.
+		{false, false, "refusé", ChangeControlPending},
+	}
+	for _, tt := range tests {
+		if got := changeControlStatusName(tt.running, tt.completed, tt.errMsg); got != tt.want {
+			t.Errorf("running=%v completed=%v erreur=%q : %q, attendu %q", tt.running, tt.completed, tt.errMsg, got, tt.want)
+		}
+	}
+}
+
+func TestSelectChangeControls(t *testing.T) {
+	base := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	at := func(days int) *timestamppb.Timestamp { return timestamppb.New(base.AddDate(0, 0, days)) }
+	cc := func(id string, status changecontrol.ChangeControlStatus, errMsg string, modified int) *changecontrol.ChangeControl {
+		c := &changecontrol.ChangeControl{
+			Key:    &changecontrol.ChangeControlKey{Id: wrapperspb.String(id)},
+			Change: &changecontrol.Change{Name: wrapperspb.String(id), Time: at(modified)},
+			Status: status,
+		}
+		if errMsg != "" {
+			c.Error = wrapperspb.String(errMsg)
+		}
+		return c
+	}
+	pending := cc("pending", changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_NOT_STARTED, "", -10)
+	// Modifié il y a 10 jours mais approuvé il y a 2 jours.
+	approved := cc("approved", changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_NOT_STARTED, "", -10)
+	approved.Approve = &changecontrol.Flag{Value: wrapperspb.Bool(true), Time: at(-2)}
+	// Démarré il y a 20 jours, dernière étape terminée il y a 1 jour.
+	running := cc("running", changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_RUNNING, "", -20)
+	running.Start = &changecontrol.Flag{Value: wrapperspb.Bool(true), Time: at(-20)}
+	running.Change.Stages = &changecontrol.StageMap{Values: map[string]*changecontrol.Stage{
+		"s1": {EndTime: at(-1)},
+		"s2": {},
+	}}
+	completed := cc("completed", changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_COMPLETED, "", -5)
+	failed := cc("failed", changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_COMPLETED, "échec", -3)
+	// Une approbation retirée ne compte pas comme activité.
+	revoked := cc("revoked", changecontrol.ChangeControlStatus_CHANGE_CONTROL_STATUS_SCHEDULED, "", -30)
+	revoked.Approve = &changecontrol.Flag{Value: wrapperspb.Bool(false), Time: at(0)}
+	all := []*changecontrol.ChangeControl{pending, approved, running, completed, failed, revoked}
+
+	tests := []struct {
+		name   string
+		status string
+		since  time.Time
+		want   []string
+	}{
+		{"sans filtre, du plus récent au plus ancien", "", time.Time{}, []string{"running", "approved", "failed", "completed", "pending", "revoked"}},
+		{"en attente", ChangeControlPending, time.Time{}, []string{"approved", "pending", "revoked"}},
+		{"en cours", ChangeControlRunning, time.Time{}, []string{"running"}},
+		{"terminés", ChangeControlCompleted, time.Time{}, []string{"completed"}},
+		{"en échec", ChangeControlFailed, time.Time{}, []string{"failed"}},
+		{"actifs depuis 3 jours", "", base.AddDate(0, 0, -3), []string{"running", "approved", "failed"}},
+		{"borne incluse", "", base.AddDate(0, 0, -5), []string{"running", "approved", "failed", "completed"}},
+		{"en attente actifs depuis 3 jours", ChangeControlPending, base.AddDate(0, 0, -3), []string{"approved"}},
+		{"aucun", ChangeControlRunning, base, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, info := range selectChangeControls(all, tt.status, tt.since) {
+			got = append(got, info.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : %v, attendu %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListChangeControlsInvalidStatus(t *testing.T) {
+	// L'état est validé avant toute lecture.
+	if _, err := ListChangeControls(context.Background(), nil, "done", time.Time{}); err == nil {
+		t.Error("état invalide accepté")
+	}
+}
@@ -0,0 +1,502 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	configlet "github.com/aristanetworks/cloudvision-go/api/arista/configlet.v1"
+	studio "github.com/aristanetworks/cloudvision-go/api/arista/studio.v1"
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+	"github.com/aristanetworks/cloudvision-go/api/fmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ChangeAction qualifie une modification portée par un workspace.
+type ChangeAction string
+
+const (
+	// ChangeAdd : la ressource est créée par le workspace.
+	ChangeAdd ChangeAction = "add"
+	// ChangeRemove : la ressource est supprimée par le workspace.
+	ChangeRemove ChangeAction = "remove"
+	// ChangeModify : la ressource existe sur mainline et le workspace la modifie.
+	ChangeModify ChangeAction = "modify"
+)
+
+// Types de ressources de configuration lues dans un workspace.
+const (
+	KindTag                 = "tags"
+	KindTagAssignment       = "tagAssignments"
+	KindStudioInput         = "studioInputs"
+	KindStudioAssignment    = "studioAssignments"
+	KindConfiglet           = "configlets"
+	KindConfigletAssignment = "configletAssignments"
+)
+
+// TagChange est la création ou la suppression d'un tag (label=value).
+type TagChange struct {
+	Action         ChangeAction `yaml:"action" json:"action"`
+	Label          string       `yaml:"label" json:"label"`
+	Value          string       `yaml:"value" json:"value"`
+	ElementType    string       `yaml:"elementType" json:"elementType"`
+	ElementSubType string       `yaml:"elementSubType,omitempty" json:"elementSubType,omitempty"`
+}
+
+// TagAssignmentChange est l'assignation ou la désassignation d'un tag à un équipement
+// ou à une interface. Le hostname est renseigné pour permettre le remappage des équipements.
+type TagAssignmentChange struct {
+	Action         ChangeAction `yaml:"action" json:"action"`
+	Label          string       `yaml:"label" json:"label"`
+	Value          string       `yaml:"value" json:"value"`
+	ElementType    string       `yaml:"elementType" json:"elementType"`
+	ElementSubType string       `yaml:"elementSubType,omitempty" json:"elementSubType,omitempty"`
+	DeviceID       string       `yaml:"deviceId" json:"deviceId"`
+	Hostname       string       `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	InterfaceID    string       `yaml:"interfaceId,omitempty" json:"interfaceId,omitempty"`
+}
+
+// StudioInputChange est une modification des inputs d'un studio, à un chemin donné.
+// Inputs contient la valeur JSON écrite à ce chemin.
+type StudioInputChange struct {
+	Action   ChangeAction `yaml:"action" json:"action"`
+	StudioID string       `yaml:"studioId" json:"studioId"`
+	Path     []string     `yaml:"path,omitempty" json:"path,omitempty"`
+	Inputs   string       `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+}
+
+// StudioAssignmentChange est une modification de la requête de tags assignant un studio.
+type StudioAssignmentChange struct {
+	Action   ChangeAction `yaml:"action" json:"action"`
+	StudioID string       `yaml:"studioId" json:"studioId"`
+	Query    string       `yaml:"query,omitempty" json:"query,omitempty"`
+}
+
+// ConfigletChange est la création, la modification ou la suppression d'un configlet.
+// Les champs nil n'étaient pas renseignés dans le workspace source (valeur de mainline
+// inchangée) et ne sont pas réécrits lorsque la modification est rejouée.
+type ConfigletChange struct {
+	Action      ChangeAction `yaml:"action" json:"action"`
+	ConfigletID string       `yaml:"configletId" json:"configletId"`
+	DisplayName *string      `yaml:"displayName,omitempty" json:"displayName,omitempty"`
+	Description *string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Body        *string      `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// ConfigletAssignmentChange est la création, la modification ou la suppression
+// d'une assignation de configlets. Comme pour ConfigletChange, les champs nil ne sont
+// pas réécrits.
+type ConfigletAssignmentChange struct {
+	Action             ChangeAction `yaml:"action" json:"action"`
+	AssignmentID       string       `yaml:"assignmentId" json:"assignmentId"`
+	DisplayName        *string      `yaml:"displayName,omitempty" json:"displayName,omitempty"`
+	Description        *string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Query              *string      `yaml:"query,omitempty" json:"query,omitempty"`
+	ConfigletIDs       *[]string    `yaml:"configletIds,omitempty" json:"configletIds,omitempty"`
+	ChildAssignmentIDs *[]string    `yaml:"childAssignmentIds,omitempty" json:"childAssignmentIds,omitempty"`
+}
+
+// optionalString retourne la valeur d'un champ protobuf, ou nil s'il n'est pas renseigné.
+func optionalString(v *wrapperspb.StringValue) *string {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
+}
+
+// optionalStrings retourne les valeurs d'une liste protobuf, ou nil si elle n'est pas renseignée.
+func optionalStrings(v *fmp.RepeatedString) *[]string {
+	if v == nil {
+		return nil
+	}
+	values := v.GetValues()
+	return &values
+}
+
+// wrapString est la réciproque d'optionalString : nil reste non renseigné.
+func wrapString(p *string) *wrapperspb.StringValue {
+	if p == nil {
+		return nil
+	}
+	return wrapperspb.String(*p)
+}
+
+// wrapStrings est la réciproque d'optionalStrings : nil reste non renseigné.
+func wrapStrings(p *[]string) *fmp.RepeatedString {
+	if p == nil {
+		return nil
+	}
+	return &fmp.RepeatedString{Values: *p}
+}
+
+// stringOrEmpty retourne la valeur pointée par p, ou "" si p est nil.
+func stringOrEmpty(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// ResourceError signale un type de ressource qui n'a pas pu être lu ou écrit.
+type ResourceError struct {
+	Kind  string `yaml:"kind" json:"kind"`
+	Error string `yaml:"error" json:"error"`
+}
+
+// WorkspaceChanges regroupe toutes les modifications de configuration portées par un workspace.
+type WorkspaceChanges struct {
+	Tags                 []TagChange                 `yaml:"tags,omitempty" json:"tags,omitempty"`
+	TagAssignments       []TagAssignmentChange       `yaml:"tagAssignments,omitempty" json:"tagAssignments,omitempty"`
+	StudioInputs         []StudioInputChange         `yaml:"studioInputs,omitempty" json:"studioInputs,omitempty"`
+	StudioAssignments    []StudioAssignmentChange    `yaml:"studioAssignments,omitempty" json:"studioAssignments,omitempty"`
+	Configlets           []ConfigletChange           `yaml:"configlets,omitempty" json:"configlets,omitempty"`
+	ConfigletAssignments []ConfigletAssignmentChange `yaml:"configletAssignments,omitempty" json:"configletAssignments,omitempty"`
+	Unreadable           []ResourceError             `yaml:"-" json:"unreadable,omitempty"`
+}
+
+// Count retourne le nombre total de modifications.
+func (c WorkspaceChanges) Count() int {
+	return len(c.Tags) + len(c.TagAssignments) + len(c.StudioInputs) +
+		len(c.StudioAssignments) + len(c.Configlets) + len(c.ConfigletAssignments)
+}
+
+// ReadWorkspaceChanges énumère les ressources de configuration écrites dans un workspace :
+// tags et assignations de tags (tag.v2), inputs et assignations de studios (studio.v1),
+// configlets et assignations de configlets (configlet.v1).
+//
+// Chaque ressource est qualifiée d'ajout, de suppression ou de modification (si elle
+// existe déjà sur mainline). Un type de ressource illisible (service indisponible,
+// droits insuffisants) n'interrompt pas la lecture : il est signalé dans Unreadable.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace à inspecter
+//
+// Retourne :
+//   - WorkspaceChanges : les modifications regroupées par type de ressource.
+func ReadWorkspaceChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string) WorkspaceChanges {
+	var changes WorkspaceChanges
+	fail := func(kind string, err error) {
+		changes.Unreadable = append(changes.Unreadable, ResourceError{Kind: kind, Error: err.Error()})
+	}
+
+	if err := readTagChanges(ctx, conn, workspaceID, &changes); err != nil {
+		fail(KindTag, err)
+	}
+	if err := readTagAssignmentChanges(ctx, conn, workspaceID, &changes); err != nil {
+		fail(KindTagAssignment, err)
+	}
+	if err := readStudioInputChanges(ctx, conn, workspaceID, &changes); err != nil {
+		fail(KindStudioInput, err)
+	}
+	if err := readStudioAssignmentChanges(ctx, conn, workspaceID, &changes); err != nil {
+		fail(KindStudioAssignment, err)
+	}
+	if err := readConfigletChanges(ctx, conn, workspaceID, &changes); err != nil {
+		fail(KindConfiglet, err)
+	}
+	if err := readConfigletAssignmentChanges(ctx, conn, workspaceID, &changes); err != nil {
+		fail(KindConfigletAssignment, err)
+	}
+	return changes
+}
+
+// collect lit un flux gRPC jusqu'à sa fin et retourne les messages reçus.
+func collect[T any](recv func() (T, error)) ([]T, error) {
+	var out []T
+	for {
+		msg, err := recv()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, msg)
+	}
+}
+
+// existsOnMainline interprète le résultat d'une lecture sur mainline : nil signifie
+// que la ressource existe, NotFound qu'elle n'existe pas. Toute autre erreur est retournée.
+func existsOnMainline(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// actionFor retourne l'action correspondant à une ressource écrite dans un workspace.
+func actionFor(remove, onMainline bool) ChangeAction {
+	switch {
+	case remove:
+		return ChangeRemove
+	case onMainline:
+		return ChangeModify
+	default:
+		return ChangeAdd
+	}
+}
+
+// elementTypeName retourne le nom court d'un type d'élément tag.v2 ("device", "interface").
+func elementTypeName(t tag.ElementType) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "ELEMENT_TYPE_"))
+}
+
+// readTagChanges lit les TagConfig écrits dans le workspace.
+func readTagChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes *WorkspaceChanges) error {
+	client := tag.NewTagConfigServiceClient(conn)
+	stream, err := client.GetAll(ctx, &tag.TagConfigStreamRequest{
+		PartialEqFilter: []*tag.TagConfig{{Key: &tag.TagKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+	})
+	if err != nil {
+		return err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return err
+	}
+	for _, r := range resps {
+		val := r.GetValue()
+		changes.Tags = append(changes.Tags, TagChange{
+			Action:         actionFor(val.GetRemove().GetValue(), false),
+			Label:          val.GetKey().GetLabel().GetValue(),
+			Value:          val.GetKey().GetValue().GetValue(),
+			ElementType:    elementTypeName(val.GetKey().GetElementType()),
+			ElementSubType: elementSubTypeName(val.GetKey().GetElementSubType()),
+		})
+	}
+	return nil
+}
+
+// readTagAssignmentChanges lit les TagAssignmentConfig écrits dans le workspace et
+// résout le hostname des équipements concernés.
+func readTagAssignmentChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes *WorkspaceChanges) error {
+	client := tag.NewTagAssignmentConfigServiceClient(conn)
+	stream, err := client.GetAll(ctx, &tag.TagAssignmentConfigStreamRequest{
+		PartialEqFilter: []*tag.TagAssignmentConfig{{Key: &tag.TagAssignmentKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+	})
+	if err != nil {
+		return err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return err
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+	// Sans hostname, les assignations ne peuvent être ni affichées ni réassociées à
+	// l'import d'un bundle : l'échec de l'inventaire rend le type illisible.
+	devices, err := ListInventory(ctx, conn, "", false, false)
+	if err != nil {
+		return err
+	}
+	hostnames := map[string]string{}
+	for _, d := range devices {
+		hostnames[d.DeviceID] = d.Hostname
+	}
+	for _, r := range resps {
+		key := r.GetValue().GetKey()
+		changes.TagAssignments = append(changes.TagAssignments, TagAssignmentChange{
+			Action:         actionFor(r.GetValue().GetRemove().GetValue(), false),
+			Label:          key.GetLabel().GetValue(),
+			Value:          key.GetValue().GetValue(),
+			ElementType:    elementTypeName(key.GetElementType()),
+			ElementSubType: elementSubTypeName(key.GetElementSubType()),
+			DeviceID:       key.GetDeviceId().GetValue(),
+			Hostname:       hostnames[key.GetDeviceId().GetValue()],
+			InterfaceID:    key.GetInterfaceId().GetValue(),
+		})
+	}
+	return nil
+}
+
+// readStudioInputChanges lit les InputsConfig écrits dans le workspace.
+func readStudioInputChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes *WorkspaceChanges) error {
+	client := studio.NewInputsConfigServiceClient(conn)
+	stream, err := client.GetAll(ctx, &studio.InputsConfigStreamRequest{
+		PartialEqFilter: []*studio.InputsConfig{{Key: &studio.InputsKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+	})
+	if err != nil {
+		return err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return err
+	}
+	mainline := studio.NewInputsServiceClient(conn)
+	for _, r := range resps {
+		val := r.GetValue()
+		_, err := mainline.GetOne(ctx, &studio.InputsRequest{Key: &studio.InputsKey{
+			StudioId:    val.GetKey().GetStudioId(),
+			WorkspaceId: wrapperspb.String(""),
+			Path:        val.GetKey().GetPath(),
+		}})
+		onMainline, err := existsOnMainline(err)
+		if err != nil {
+			return err
+		}
+		changes.StudioInputs = append(changes.StudioInputs, StudioInputChange{
+			Action:   actionFor(val.GetRemove().GetValue(), onMainline),
+			StudioID: val.GetKey().GetStudioId().GetValue(),
+			Path:     val.GetKey().GetPath().GetValues(),
+			Inputs:   val.GetInputs().GetValue(),
+		})
+	}
+	return nil
+}
+
+// readStudioAssignmentChanges lit les AssignedTagsConfig écrits dans le workspace.
+func readStudioAssignmentChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes *WorkspaceChanges) error {
+	client := studio.NewAssignedTagsConfigServiceClient(conn)
+	stream, err := client.GetAll(ctx, &studio.AssignedTagsConfigStreamRequest{
+		PartialEqFilter: []*studio.AssignedTagsConfig{{Key: &studio.StudioKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+	})
+	if err != nil {
+		return err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return err
+	}
+	mainline := studio.NewAssignedTagsServiceClient(conn)
+	for _, r := range resps {
+		val := r.GetValue()
+		_, err := mainline.GetOne(ctx, &studio.AssignedTagsRequest{Key: &studio.StudioKey{
+			StudioId:    val.GetKey().GetStudioId(),
+			WorkspaceId: wrapperspb.String(""),
+		}})
+		onMainline, err := existsOnMainline(err)
+		if err != nil {
+			return err
+		}
+		changes.StudioAssignments = append(changes.StudioAssignments, StudioAssignmentChange{
+			Action:   actionFor(val.GetRemove().GetValue(), onMainline),
+			StudioID: val.GetKey().GetStudioId().GetValue(),
+			Query:    val.GetQuery().GetValue(),
+		})
+	}
+	return nil
+}
+
+// readConfigletChanges lit les ConfigletConfig écrits dans le workspace.
+func readConfigletChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes *WorkspaceChanges) error {
+	client := configlet.NewConfigletConfigServiceClient(conn)
+	stream, err := client.GetAll(ctx, &configlet.ConfigletConfigStreamRequest{
+		PartialEqFilter: []*configlet.ConfigletConfig{{Key: &configlet.ConfigletKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+	})
+	if err != nil {
+		return err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return err
+	}
+	mainline := configlet.NewConfigletServiceClient(conn)
+	for _, r := range resps {
+		val := r.GetValue()
+		_, err := mainline.GetOne(ctx, &configlet.ConfigletRequest{Key: &configlet.ConfigletKey{
+			WorkspaceId: wrapperspb.String(""),
+			ConfigletId: val.GetKey().GetConfigletId(),
+		}})
+		onMainline, err := existsOnMainline(err)
+		if err != nil {
+			return err
+		}
+		changes.Configlets = append(changes.Configlets, ConfigletChange{
+			Action:      actionFor(val.GetRemove().GetValue(), onMainline),
+			ConfigletID: val.GetKey().GetConfigletId().GetValue(),
+			DisplayName: optionalString(val.GetDisplayName()),
+			Description: optionalString(val.GetDescription()),
+			Body:        optionalString(val.GetBody()),
+		})
+	}
+	return nil
+}
+
+// readConfigletAssignmentChanges lit les ConfigletAssignmentConfig écrits dans le workspace.
+func readConfigletAssignmentChanges(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes *WorkspaceChanges) error {
+	client := configlet.NewConfigletAssignmentConfigServiceClient(conn)
+	stream, err := client.GetAll(ctx, &configlet.ConfigletAssignmentConfigStreamRequest{
+		PartialEqFilter: []*configlet.ConfigletAssignmentConfig{{Key: &configlet.ConfigletAssignmentKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+	})
+	if err != nil {
+		return err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return err
+	}
+	mainline := configlet.NewConfigletAssignmentServiceClient(conn)
+	for _, r := range resps {
+		val := r.GetValue()
+		_, err := mainline.GetOne(ctx, &configlet.ConfigletAssignmentRequest{Key: &configlet.ConfigletAssignmentKey{
+			WorkspaceId:           wrapperspb.String(""),
+			ConfigletAssignmentId: val.GetKey().GetConfigletAssignmentId(),
+		}})
+		onMainline, err := existsOnMainline(err)
+		if err != nil {
+			return err
+		}
+		changes.ConfigletAssignments = append(changes.ConfigletAssignments, ConfigletAssignmentChange{
+			Action:             actionFor(val.GetRemove().GetValue(), onMainline),
+			AssignmentID:       val.GetKey().GetConfigletAssignmentId().GetValue(),
+			DisplayName:        optionalString(val.GetDisplayName()),
+			Description:        optionalString(val.GetDescription()),
+			Query:              optionalString(val.GetQuery()),
+			ConfigletIDs:       optionalStrings(val.GetConfigletIds()),
+			ChildAssignmentIDs: optionalStrings(val.GetChildAssignmentIds()),
+		})
+	}
+	return nil
+}
+
+// String retourne une description courte de la modification, utilisée dans les changelogs.
+func (c TagChange) String() string {
+	return fmt.Sprintf("%s=%s (%s)", c.Label, c.Value, c.ElementType)
+}
+
+// String retourne une description courte de la modification, utilisée dans les changelogs.
+func (c TagAssignmentChange) String() string {
+	target := c.Hostname
+	if target == "" {
+		target = c.DeviceID
+	}
+	if c.InterfaceID != "" {
+		target += ":" + c.InterfaceID
+	}
+	return fmt.Sprintf("%s=%s → %s", c.Label, c.Value, target)
+}
+
+// String retourne une description courte de la modification, utilisée dans les changelogs.
+func (c StudioInputChange) String() string {
+	return fmt.Sprintf("%s /%s", c.StudioID, strings.Join(c.Path, "/"))
+}
+
+// String retourne une description courte de la modification, utilisée dans les changelogs.
+func (c StudioAssignmentChange) String() string {
+	return fmt.Sprintf("%s : %q", c.StudioID, c.Query)
+}
+
+// String retourne une description courte de la modification, utilisée dans les changelogs.
+func (c ConfigletChange) String() string {
+	return fmt.Sprintf("%s (%s)", stringOrEmpty(c.DisplayName), c.ConfigletID)
+}
+
+// String retourne une description courte de la modification, utilisée dans les changelogs.
+func (c ConfigletAssignmentChange) String() string {
+	configlets := 0
+	if c.ConfigletIDs != nil {
+		configlets = len(*c.ConfigletIDs)
+	}
+	return fmt.Sprintf("%s (%s) : %d configlet(s), %q", stringOrEmpty(c.DisplayName), c.AssignmentID, configlets, stringOrEmpty(c.Query))
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -35,9 +36,20 @@ const timeout = 30 * time.Second
 //   - *grpc.ClientConn : connexion gRPC active vers CVaaS.
 //
 // Panique :
+//   - Si tokenPath ou urlPath est vide (ni flag ni profil complet).
 //   - Si la lecture des fichiers échoue.
 //   - Si la connexion gRPC ne peut pas être établie.
 func Connect(tokenPath, urlPath string) (context.Context, context.CancelFunc, *grpc.ClientConn) {
+	return ConnectWithTimeout(tokenPath, urlPath, timeout)
+}
+
+// ConnectWithTimeout fonctionne comme Connect, avec une durée maximale choisie par
+// l'appelant pour l'ensemble des appels : utile pour les commandes qui attendent
+// un build ou une soumission de workspace.
+func ConnectWithTimeout(tokenPath, urlPath string, d time.Duration) (context.Context, context.CancelFunc, *grpc.ClientConn) {
+	if tokenPath == "" || urlPath == "" {
+		panic("❌ Identifiants manquants : --token et --url requis, ou un profil complet (voir --profile)")
+	}
 	token, err := readLineFromFile(tokenPath)
 	if err != nil {
 		panic(fmt.Sprintf("Erreur lecture token : %v", err))
@@ -47,7 +59,7 @@ func Connect(tokenPath, urlPath string) (context.Context, context.CancelFunc, *g
 		panic(fmt.Sprintf("Erreur lecture URL : %v", err))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), d)
 	ctx = metadata.AppendToOutgoingContext(ctx, "Authorization", "Bearer "+token)
 
 	conn, err := grpc.DialContext(ctx, url,
@@ -82,4 +94,24 @@ func readLineFromFile(filename string) (string, error) {
 		return strings.TrimSpace(scanner.Text()), nil
 	}
 	return "", fmt.Errorf("fichier vide")
-}
\ No newline at end of file
+}
+// ProfileCredentials retourne les chemins du token et de l'URL associés à un profil,
+// rangés dans `$XDG_CONFIG_HOME/cvaas-cli/profiles/<profil>/` (par défaut
+// `~/.config/cvaas-cli/profiles/<profil>/`) sous les noms `token` et `url`.
+//
+// Retourne une erreur si l'un des deux fichiers est absent.
+func ProfileCredentials(profile string) (string, string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", "", err
+	}
+	dir := filepath.Join(base, stateAppDir, "profiles", profile)
+	token := filepath.Join(dir, "token")
+	url := filepath.Join(dir, "url")
+	for _, path := range []string{token, url} {
+		if _, err := os.Stat(path); err != nil {
+			return "", "", fmt.Errorf("profil %s incomplet : %w", profile, err)
+		}
+	}
+	return token, url, nil
+}
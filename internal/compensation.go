@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// UndoStatus est l'issue d'une action de compensation.
+type UndoStatus string
+
+const (
+	// UndoDone : l'opération a été annulée.
+	UndoDone UndoStatus = "undone"
+	// UndoFailed : l'annulation a échoué ; l'opération reste en place.
+	UndoFailed UndoStatus = "failed"
+	// UndoCovered : l'opération porte sur un workspace abandonné par la compensation,
+	// ce qui l'annule déjà.
+	UndoCovered UndoStatus = "covered"
+)
+
+// UndoReport est le résultat de la compensation d'une opération.
+type UndoReport struct {
+	Description string     `yaml:"description" json:"description"`
+	WorkspaceID string     `yaml:"workspaceId,omitempty" json:"workspaceId,omitempty"`
+	Status      UndoStatus `yaml:"status" json:"status"`
+	Error       string     `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// undoAction annule une opération effectuée dans un workspace. abandon est vrai pour
+// l'abandon d'un workspace créé, qui annule à lui seul toutes les opérations du workspace.
+type undoAction struct {
+	description string
+	workspaceID string
+	abandon     bool
+	undo        func(ctx context.Context) error
+}
+
+// Compensator collecte les actions d'annulation des opérations effectuées par une
+// commande, pour les exécuter en ordre inverse si la commande échoue. Les fonctions
+// d'écriture d'internal y enregistrent leur annulation lorsque le contexte en porte
+// un (voir WithCompensator).
+type Compensator struct {
+	mu      sync.Mutex
+	actions []undoAction
+	// closed contient les workspaces soumis ou abandonnés, dont les opérations ne
+	// sont plus à compenser.
+	closed  map[string]bool
+	running bool
+}
+
+// compensatorKey est la clé du Compensator dans un contexte.
+type compensatorKey struct{}
+
+// NewCompensator retourne un Compensator vide.
+func NewCompensator() *Compensator {
+	return &Compensator{closed: map[string]bool{}}
+}
+
+// WithCompensator retourne un contexte dont les opérations d'écriture enregistrent
+// leur annulation dans c.
+func WithCompensator(ctx context.Context, c *Compensator) context.Context {
+	return context.WithValue(ctx, compensatorKey{}, c)
+}
+
+// registerUndo enregistre l'annulation d'une opération si le contexte porte un
+// Compensator ; sans effet sinon, ou pendant la compensation elle-même.
+func registerUndo(ctx context.Context, workspaceID, description string, abandon bool, undo func(ctx context.Context) error) {
+	c, ok := ctx.Value(compensatorKey{}).(*Compensator)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running {
+		return
+	}
+	c.actions = append(c.actions, undoAction{description: description, workspaceID: workspaceID, abandon: abandon, undo: undo})
+}
+
+// restoresWrites indique si les écritures dans workspaceID doivent pouvoir être annulées
+// individuellement : le contexte porte un Compensator actif, et le workspace n'a pas été
+// créé par la commande (son abandon annulerait déjà les écritures) ni clos. L'état
+// antérieur des ressources écrites doit alors être lu pour être restauré.
+func restoresWrites(ctx context.Context, workspaceID string) bool {
+	c, ok := ctx.Value(compensatorKey{}).(*Compensator)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running || c.closed[workspaceID] {
+		return false
+	}
+	for _, a := range c.actions {
+		if a.abandon && a.workspaceID == workspaceID {
+			return false
+		}
+	}
+	return true
+}
+
+// priorConfigs lit, si les écritures qui suivent dans workspaceID doivent pouvoir être
+// annulées individuellement (voir restoresWrites), les configurations déjà écrites dans
+// le workspace et les indexe par key ; retourne nil sinon.
+//
+// Paramètres :
+//   - getAll : ouvre le flux GetAll des configurations du workspace
+//   - key : identifiant d'une configuration, sans le workspace
+func priorConfigs[C any, R interface{ GetValue() C }](ctx context.Context, workspaceID string, getAll func() (func() (R, error), error), key func(C) string) (map[string]C, error) {
+	if !restoresWrites(ctx, workspaceID) {
+		return nil, nil
+	}
+	recv, err := getAll()
+	if err != nil {
+		return nil, err
+	}
+	resps, err := collect(recv)
+	if err != nil {
+		return nil, err
+	}
+	prior := make(map[string]C, len(resps))
+	for _, r := range resps {
+		v := r.GetValue()
+		prior[key(v)] = v
+	}
+	return prior, nil
+}
+
+// restoreOrDelete retourne l'annulation de l'écriture de la ressource id : sa
+// suppression du workspace, suivie de la réécriture de sa configuration antérieure si
+// prior en contient une. La suppression préalable évite que Set, qui fusionne les
+// champs renseignés, conserve des champs écrits par la commande.
+func restoreOrDelete[C any](prior map[string]C, id string, set func(ctx context.Context, prior C) error, del func(ctx context.Context) error) func(ctx context.Context) error {
+	p, ok := prior[id]
+	if !ok {
+		return del
+	}
+	return func(ctx context.Context) error {
+		if err := del(ctx); err != nil {
+			return err
+		}
+		return set(ctx, p)
+	}
+}
+
+// closeWorkspace signale la soumission ou l'abandon d'un workspace : ses opérations
+// sont sur mainline ou déjà annulées, et ne sont plus à compenser.
+func closeWorkspace(ctx context.Context, workspaceID string) {
+	if c, ok := ctx.Value(compensatorKey{}).(*Compensator); ok {
+		c.mu.Lock()
+		if !c.running {
+			c.closed[workspaceID] = true
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Len retourne le nombre d'opérations compensables enregistrées.
+func (c *Compensator) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, a := range c.actions {
+		if !c.closed[a.workspaceID] {
+			n++
+		}
+	}
+	return n
+}
+
+// Rollback exécute les annulations enregistrées, de la plus récente à la plus ancienne.
+// Une opération dans un workspace que la compensation abandonne n'est pas annulée
+// individuellement ; les opérations des workspaces déjà soumis ou abandonnés sont
+// ignorées. Un échec n'interrompt pas la compensation.
+//
+// Retourne :
+//   - []UndoReport : un rapport par opération compensable, dans l'ordre d'exécution.
+func (c *Compensator) Rollback(ctx context.Context) []UndoReport {
+	c.mu.Lock()
+	c.running = true
+	actions := c.actions
+	c.actions = nil
+	closed := c.closed
+	c.mu.Unlock()
+
+	abandoned := map[string]bool{}
+	for _, a := range actions {
+		if a.abandon && !closed[a.workspaceID] {
+			abandoned[a.workspaceID] = true
+		}
+	}
+	var reports []UndoReport
+	for i := len(actions) - 1; i >= 0; i-- {
+		a := actions[i]
+		if closed[a.workspaceID] {
+			continue
+		}
+		report := UndoReport{Description: a.description, WorkspaceID: a.workspaceID, Status: UndoDone}
+		if !a.abandon && abandoned[a.workspaceID] {
+			report.Status = UndoCovered
+		} else if err := runUndo(ctx, a); err != nil {
+			report.Status = UndoFailed
+			report.Error = err.Error()
+		}
+		reports = append(reports, report)
+	}
+	revertWorkspaces(revertedWorkspaces(reports)...)
+	return reports
+}
+
+// revertedWorkspaces retourne les workspaces dont toutes les opérations ont été
+// annulées par la compensation.
+func revertedWorkspaces(reports []UndoReport) []string {
+	failed := map[string]bool{}
+	for _, r := range reports {
+		if r.Status == UndoFailed {
+			failed[r.WorkspaceID] = true
+		}
+	}
+	var reverted []string
+	for _, r := range reports {
+		if r.WorkspaceID != "" && !failed[r.WorkspaceID] && !slices.Contains(reverted, r.WorkspaceID) {
+			reverted = append(reverted, r.WorkspaceID)
+		}
+	}
+	return reverted
+}
+
+// runUndo exécute une annulation en convertissant une panique en erreur.
+func runUndo(ctx context.Context, a undoAction) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return a.undo(ctx)
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCompensatorRollback(t *testing.T) {
+	c := NewCompensator()
+	ctx := WithCompensator(context.Background(), c)
+	var undone []string
+	undo := func(desc string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			undone = append(undone, desc)
+			// Une écriture pendant la compensation n'est pas enregistrée.
+			registerUndo(ctx, "ws-x", "écriture de compensation", false, nil)
+			return err
+		}
+	}
+	registerUndo(ctx, "ws-new", "création", true, undo("création", nil))
+	registerUndo(ctx, "ws-new", "tags", false, undo("tags", nil))
+	registerUndo(ctx, "ws-old", "assignations", false, undo("assignations", errors.New("refusé")))
+	registerUndo(ctx, "ws-old", "inputs", false, func(context.Context) error { panic("panne") })
+	registerUndo(ctx, "ws-submitted", "configlets", false, undo("configlets", nil))
+	closeWorkspace(ctx, "ws-submitted")
+
+	type report struct {
+		desc   string
+		status UndoStatus
+		err    string
+	}
+	want := []report{
+		{"inputs", UndoFailed, "panne"},
+		{"assignations", UndoFailed, "refusé"},
+		// Les écritures d'un workspace abandonné sont annulées par l'abandon.
+		{"tags", UndoCovered, ""},
+		{"création", UndoDone, ""},
+	}
+	var got []report
+	for _, r := range c.Rollback(ctx) {
+		got = append(got, report{r.Description, r.Status, r.Error})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rapports = %v, attendu %v", got, want)
+	}
+	if want := []string{"assignations", "création"}; !slices.Equal(undone, want) {
+		t.Errorf("annulations exécutées = %v, attendu %v", undone, want)
+	}
+	if c.Len() != 0 {
+		t.Errorf("%d opération(s) encore enregistrée(s) après la compensation", c.Len())
+	}
+}
+
+func TestRestoresWrites(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	if restoresWrites(context.Background(), "ws-1") {
+		t.Error("sans Compensator : restauration inutile")
+	}
+
+	c := NewCompensator()
+	ctx := WithCompensator(context.Background(), c)
+	if !restoresWrites(ctx, "ws-1") {
+		t.Error("workspace existant : restauration attendue")
+	}
+
+	registerUndo(ctx, "ws-2", "workspace ws-2 créé", true, noop)
+	if restoresWrites(ctx, "ws-2") {
+		t.Error("workspace créé par la commande : son abandon suffit")
+	}
+
+	closeWorkspace(ctx, "ws-1")
+	if restoresWrites(ctx, "ws-1") {
+		t.Error("workspace clos : plus rien à compenser")
+	}
+}
+
+func TestRestoreOrDelete(t *testing.T) {
+	var calls []string
+	set := func(_ context.Context, prior string) error {
+		calls = append(calls, "set "+prior)
+		return nil
+	}
+	del := func(context.Context) error {
+		calls = append(calls, "delete")
+		return nil
+	}
+	prior := map[string]string{"a": "ancienne"}
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"a", []string{"delete", "set ancienne"}},
+		{"b", []string{"delete"}},
+	}
+	for _, tt := range tests {
+		calls = nil
+		if err := restoreOrDelete(prior, tt.id, set, del)(context.Background()); err != nil {
+			t.Fatalf("%s : %v", tt.id, err)
+		}
+		if !slices.Equal(calls, tt.want) {
+			t.Errorf("%s : appels = %v, attendu %v", tt.id, calls, tt.want)
+		}
+	}
+}
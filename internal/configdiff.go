@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	configstatus "github.com/aristanetworks/cloudvision-go/api/arista/configstatus.v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// DiffKind identifie les deux configurations comparées par GetConfigDiff.
+type DiffKind string
+
+const (
+	// DiffRunning compare la configuration en cours d'exécution sur l'équipement
+	// à la configuration conçue dans le workspace.
+	DiffRunning DiffKind = "running"
+	// DiffMainline compare la configuration conçue sur mainline à la configuration
+	// conçue dans le workspace.
+	DiffMainline DiffKind = "mainline"
+)
+
+// DiffLine est une ligne d'un diff de configuration : Op vaut "+" (ajout),
+// "-" (suppression) ou " " (contexte). ALine/BLine sont les numéros de ligne
+// (à partir de 1) dans chaque configuration, 0 si la ligne n'y figure pas.
+type DiffLine struct {
+	Op    string `json:"op"`
+	Text  string `json:"text"`
+	ALine int    `json:"aLine,omitempty"`
+	BLine int    `json:"bLine,omitempty"`
+}
+
+// ConfigDiff est le diff de configuration d'un équipement, avec le décompte
+// des lignes ajoutées et supprimées.
+type ConfigDiff struct {
+	DeviceID string     `json:"deviceId"`
+	Hostname string     `json:"hostname"`
+	Kind     DiffKind   `json:"kind"`
+	Added    int        `json:"added"`
+	Removed  int        `json:"removed"`
+	Lines    []DiffLine `json:"lines"`
+	// Error est l'erreur de lecture du diff de l'équipement, vide en cas de succès.
+	Error string `json:"error,omitempty"`
+}
+
+// GetConfigDiffs lit via configstatus.v1 les diffs de configuration des équipements
+// devices pour un workspace donné, en un seul flux GetAll filtré sur le workspace et
+// les types de configuration comparés.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour l'appel gRPC ; son délai borne la lecture
+//   - conn : connexion gRPC active vers CloudVision
+//   - devices : équipements concernés (issus de ReadInventory)
+//   - kind : DiffRunning (running vs designed) ou DiffMainline (designed mainline vs designed)
+//   - workspaceID : workspace dont la configuration conçue est comparée
+//
+// Retourne :
+//   - []ConfigDiff : un diff par équipement, dans l'ordre de devices ; vide si aucune
+//     configuration n'est disponible. Si le flux échoue, les équipements dont le diff
+//     n'a pas été reçu portent l'erreur dans Error.
+//
+// Panique :
+//   - Si kind est inconnu.
+func GetConfigDiffs(ctx context.Context, conn *grpc.ClientConn, devices []DeviceInfo, kind DiffKind, workspaceID string) []ConfigDiff {
+	key := &configstatus.ConfigDiffKey{
+		BType:        configstatus.ConfigType_DESIGNED_CONFIG,
+		BWorkspaceId: wrapperspb.String(workspaceID),
+	}
+	switch kind {
+	case DiffRunning:
+		key.AType = configstatus.ConfigType_RUNNING_CONFIG
+	case DiffMainline:
+		key.AType = configstatus.ConfigType_DESIGNED_CONFIG
+		key.AWorkspaceId = wrapperspb.String("")
+	default:
+		panic(fmt.Sprintf("❌ Type de diff inconnu : %s", kind))
+	}
+
+	received := map[string]*configstatus.ConfigDiff{}
+	err := readConfigDiffs(ctx, conn, key, func(val *configstatus.ConfigDiff) {
+		// Le filtre ne porte pas sur les équipements : les diffs des équipements non
+		// sélectionnés, ou comparant deux équipements différents, sont ignorés.
+		if a, b := val.GetKey().GetADeviceId().GetValue(), val.GetKey().GetBDeviceId().GetValue(); a == b {
+			received[a] = val
+		}
+	})
+
+	diffs := make([]ConfigDiff, 0, len(devices))
+	for _, d := range devices {
+		diff := ConfigDiff{DeviceID: d.DeviceID, Hostname: d.Hostname, Kind: kind}
+		if val, ok := received[d.DeviceID]; ok {
+			diff = configDiffFromProto(val, d, kind)
+		} else if err != nil {
+			diff.Error = err.Error()
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// readConfigDiffs parcourt les diffs correspondant à key (filtre d'égalité partielle)
+// et appelle visit pour chacun. Retourne l'erreur d'ouverture ou de lecture du flux ;
+// les diffs reçus avant l'erreur ont été visités.
+func readConfigDiffs(ctx context.Context, conn *grpc.ClientConn, key *configstatus.ConfigDiffKey, visit func(*configstatus.ConfigDiff)) error {
+	client := configstatus.NewConfigDiffServiceClient(conn)
+	stream, err := client.GetAll(ctx, &configstatus.ConfigDiffStreamRequest{
+		PartialEqFilter: []*configstatus.ConfigDiff{{Key: key}},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		visit(resp.GetValue())
+	}
+}
+
+// configDiffFromProto convertit le diff configstatus.v1 d'un équipement.
+func configDiffFromProto(val *configstatus.ConfigDiff, device DeviceInfo, kind DiffKind) ConfigDiff {
+	result := ConfigDiff{DeviceID: device.DeviceID, Hostname: device.Hostname, Kind: kind}
+	for _, entry := range val.GetDiff().GetValues() {
+		aNum := int(entry.GetALineNum().GetValue())
+		bNum := int(entry.GetBLineNum().GetValue())
+		switch entry.GetOp() {
+		case configstatus.DiffOp_DIFF_OP_NOP:
+			result.Lines = append(result.Lines, DiffLine{Op: " ", Text: entry.GetALine().GetValue(), ALine: aNum, BLine: bNum})
+		case configstatus.DiffOp_DIFF_OP_ADD:
+			result.Lines = append(result.Lines, DiffLine{Op: "+", Text: entry.GetBLine().GetValue(), BLine: bNum})
+			result.Added++
+		case configstatus.DiffOp_DIFF_OP_DELETE:
+			result.Lines = append(result.Lines, DiffLine{Op: "-", Text: entry.GetALine().GetValue(), ALine: aNum})
+			result.Removed++
+		case configstatus.DiffOp_DIFF_OP_CHANGE:
+			result.Lines = append(result.Lines,
+				DiffLine{Op: "-", Text: entry.GetALine().GetValue(), ALine: aNum},
+				DiffLine{Op: "+", Text: entry.GetBLine().GetValue(), BLine: bNum})
+			result.Added++
+			result.Removed++
+		}
+	}
+	return result
+}
+
+// Hunk est un bloc de diff unifié : les lignes modifiées et leur contexte,
+// avec la position de départ et la longueur dans chaque configuration.
+type Hunk struct {
+	AStart, ALen int
+	BStart, BLen int
+	Lines        []DiffLine
+}
+
+// Hunks découpe le diff en blocs unifiés, en conservant au plus `context` lignes
+// inchangées autour de chaque modification.
+func (d ConfigDiff) Hunks(context int) []Hunk {
+	var hunks []Hunk
+	n := len(d.Lines)
+	for i := 0; i < n; {
+		if d.Lines[i].Op == " " {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		for end < n {
+			if d.Lines[end].Op != " " {
+				end++
+				continue
+			}
+			next := end
+			for next < n && d.Lines[next].Op == " " {
+				next++
+			}
+			if next == n || next-end > 2*context {
+				end = min(end+context, n)
+				break
+			}
+			end = next
+		}
+		hunks = append(hunks, newHunk(d.Lines[:start], d.Lines[start:end]))
+		i = end
+	}
+	return hunks
+}
+
+// newHunk calcule les positions et longueurs d'un bloc à partir de ses lignes. Comme
+// dans le format unifié, un côté sans ligne (ajout ou suppression pure) commence à
+// la dernière ligne de ce côté qui précède le bloc (0 en début de fichier) ; before
+// contient les lignes du diff précédant le bloc.
+func newHunk(before, lines []DiffLine) Hunk {
+	h := Hunk{Lines: lines}
+	for _, l := range lines {
+		if l.Op != "+" {
+			if h.AStart == 0 {
+				h.AStart = l.ALine
+			}
+			h.ALen++
+		}
+		if l.Op != "-" {
+			if h.BStart == 0 {
+				h.BStart = l.BLine
+			}
+			h.BLen++
+		}
+	}
+	for i := len(before) - 1; i >= 0 && (h.ALen == 0 && h.AStart == 0 || h.BLen == 0 && h.BStart == 0); i-- {
+		if h.ALen == 0 && h.AStart == 0 && before[i].ALine > 0 {
+			h.AStart = before[i].ALine
+		}
+		if h.BLen == 0 && h.BStart == 0 && before[i].BLine > 0 {
+			h.BStart = before[i].BLine
+		}
+	}
+	return h
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testDiff construit un diff à partir d'une suite d'opérations (" ", "+", "-"), en
+// numérotant les lignes de chaque configuration.
+func testDiff(ops string) ConfigDiff {
+	var d ConfigDiff
+	a, b := 0, 0
+	for i, op := range ops {
+		l := DiffLine{Op: string(op), Text: fmt.Sprintf("ligne %d", i)}
+		if op != '+' {
+			a++
+			l.ALine = a
+		}
+		if op != '-' {
+			b++
+			l.BLine = b
+		}
+		d.Lines = append(d.Lines, l)
+	}
+	return d
+}
+
+func TestHunks(t *testing.T) {
+	type hunk struct{ aStart, aLen, bStart, bLen int }
+	tests := []struct {
+		name    string
+		ops     string
+		context int
+		want    []hunk
+	}{
+		{"aucune modification", "     ", 3, nil},
+		{"ajout au milieu", "     +     ", 2, []hunk{{4, 4, 4, 5}}},
+		{"suppression en tête", "-    ", 2, []hunk{{1, 3, 1, 2}}},
+		{"modification en fin", "    -+", 2, []hunk{{3, 3, 3, 3}}},
+		// Deux modifications séparées par au plus 2×context lignes forment un seul bloc.
+		{"blocs fusionnés", "  +    +  ", 2, []hunk{{1, 8, 1, 10}}},
+		{"blocs séparés", "  +     +  ", 2, []hunk{{1, 4, 1, 5}, {6, 4, 7, 5}}},
+		// Un côté vide commence à la ligne qui précède le bloc, comme dans diff -u.
+		{"sans contexte", " -+  - ", 0, []hunk{{2, 1, 2, 1}, {5, 1, 4, 0}}},
+		{"ajout pur", "  +  ", 0, []hunk{{2, 0, 3, 1}}},
+		{"ajout en tête", "+  ", 0, []hunk{{0, 0, 1, 1}}},
+		{"suppression pure", "   -", 0, []hunk{{4, 1, 3, 0}}},
+	}
+	for _, tt := range tests {
+		var got []hunk
+		for _, h := range testDiff(tt.ops).Hunks(tt.context) {
+			got = append(got, hunk{h.AStart, h.ALen, h.BStart, h.BLen})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : blocs = %v, attendu %v", tt.name, got, tt.want)
+		}
+	}
+}
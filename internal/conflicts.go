@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	configlet "github.com/aristanetworks/cloudvision-go/api/arista/configlet.v1"
+	studio "github.com/aristanetworks/cloudvision-go/api/arista/studio.v1"
+	"github.com/aristanetworks/cloudvision-go/api/fmp"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Conflict est une ressource modifiée par un workspace et modifiée sur mainline
+// depuis la création (ou le dernier rebase) du workspace.
+type Conflict struct {
+	Kind       string    `json:"kind"`
+	Resource   string    `json:"resource"`
+	ModifiedBy string    `json:"modifiedBy"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+}
+
+// FindConflicts compare les ressources modifiées par un workspace à leur état sur
+// mainline : une ressource est en conflit si sa version mainline a été modifiée
+// après la base du workspace (dernier rebase, ou création à défaut).
+//
+// Les tags et assignations de tags ne portent pas d'historique de modification
+// côté mainline et ne sont donc pas examinés.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - ws : workspace inspecté (issu de FindWorkspace)
+//   - changes : ses modifications (issues de ReadWorkspaceChanges)
+//
+// Retourne :
+//   - []Conflict : les ressources en conflit, avec l'auteur et la date de la modification mainline.
+//   - error : la première erreur de lecture sur mainline.
+func FindConflicts(ctx context.Context, conn *grpc.ClientConn, ws WorkspaceInfo, changes WorkspaceChanges) ([]Conflict, error) {
+	base := ws.LastRebasedAt
+	if base.IsZero() {
+		base = ws.CreatedAt
+	}
+	mainline := wrapperspb.String("")
+	var conflicts []Conflict
+	check := func(kind, resource string, err error, modifiedAt *timestamppb.Timestamp, modifiedBy *wrapperspb.StringValue) error {
+		onMainline, err := existsOnMainline(err)
+		if err != nil {
+			return fmt.Errorf("%s %s : %w", kind, resource, err)
+		}
+		if onMainline && modifiedAt != nil && modifiedAt.AsTime().After(base) {
+			conflicts = append(conflicts, Conflict{
+				Kind:       kind,
+				Resource:   resource,
+				ModifiedBy: modifiedBy.GetValue(),
+				ModifiedAt: modifiedAt.AsTime(),
+			})
+		}
+		return nil
+	}
+
+	inputs := studio.NewInputsServiceClient(conn)
+	for _, c := range changes.StudioInputs {
+		resp, err := inputs.GetOne(ctx, &studio.InputsRequest{Key: &studio.InputsKey{
+			StudioId:    wrapperspb.String(c.StudioID),
+			WorkspaceId: mainline,
+			Path:        &fmp.RepeatedString{Values: c.Path},
+		}})
+		val := resp.GetValue()
+		if err := check(KindStudioInput, c.ResourceID(), err, val.GetLastModifiedAt(), val.GetLastModifiedBy()); err != nil {
+			return conflicts, err
+		}
+	}
+
+	assignedTags := studio.NewAssignedTagsServiceClient(conn)
+	for _, c := range changes.StudioAssignments {
+		resp, err := assignedTags.GetOne(ctx, &studio.AssignedTagsRequest{Key: &studio.StudioKey{
+			StudioId:    wrapperspb.String(c.StudioID),
+			WorkspaceId: mainline,
+		}})
+		val := resp.GetValue()
+		if err := check(KindStudioAssignment, c.ResourceID(), err, val.GetLastModifiedAt(), val.GetLastModifiedBy()); err != nil {
+			return conflicts, err
+		}
+	}
+
+	configlets := configlet.NewConfigletServiceClient(conn)
+	for _, c := range changes.Configlets {
+		resp, err := configlets.GetOne(ctx, &configlet.ConfigletRequest{Key: &configlet.ConfigletKey{
+			WorkspaceId: mainline,
+			ConfigletId: wrapperspb.String(c.ConfigletID),
+		}})
+		val := resp.GetValue()
+		if err := check(KindConfiglet, c.ResourceID(), err, val.GetLastModifiedAt(), val.GetLastModifiedBy()); err != nil {
+			return conflicts, err
+		}
+	}
+
+	configletAssignments := configlet.NewConfigletAssignmentServiceClient(conn)
+	for _, c := range changes.ConfigletAssignments {
+		resp, err := configletAssignments.GetOne(ctx, &configlet.ConfigletAssignmentRequest{Key: &configlet.ConfigletAssignmentKey{
+			WorkspaceId:           mainline,
+			ConfigletAssignmentId: wrapperspb.String(c.AssignmentID),
+		}})
+		val := resp.GetValue()
+		if err := check(KindConfigletAssignment, c.ResourceID(), err, val.GetLastModifiedAt(), val.GetLastModifiedBy()); err != nil {
+			return conflicts, err
+		}
+	}
+	return conflicts, nil
+}
+
+// WithoutConflicts retourne les modifications privées des ressources en conflit.
+func WithoutConflicts(changes WorkspaceChanges, conflicts []Conflict) WorkspaceChanges {
+	conflicting := map[string]bool{}
+	for _, c := range conflicts {
+		conflicting[c.Kind+"|"+c.Resource] = true
+	}
+	keep := func(kind, resource string) bool { return !conflicting[kind+"|"+resource] }
+
+	out := WorkspaceChanges{Tags: changes.Tags, TagAssignments: changes.TagAssignments}
+	for _, c := range changes.StudioInputs {
+		if keep(KindStudioInput, c.ResourceID()) {
+			out.StudioInputs = append(out.StudioInputs, c)
+		}
+	}
+	for _, c := range changes.StudioAssignments {
+		if keep(KindStudioAssignment, c.ResourceID()) {
+			out.StudioAssignments = append(out.StudioAssignments, c)
+		}
+	}
+	for _, c := range changes.Configlets {
+		if keep(KindConfiglet, c.ResourceID()) {
+			out.Configlets = append(out.Configlets, c)
+		}
+	}
+	for _, c := range changes.ConfigletAssignments {
+		if keep(KindConfigletAssignment, c.ResourceID()) {
+			out.ConfigletAssignments = append(out.ConfigletAssignments, c)
+		}
+	}
+	return out
+}
+
+// ResourceID retourne l'identifiant de la ressource modifiée (studio et chemin).
+func (c StudioInputChange) ResourceID() string {
+	return c.StudioID + "/" + strings.Join(c.Path, "/")
+}
+
+// ResourceID retourne l'identifiant de la ressource modifiée (studio).
+func (c StudioAssignmentChange) ResourceID() string {
+	return c.StudioID
+}
+
+// ResourceID retourne l'identifiant de la ressource modifiée (configlet).
+func (c ConfigletChange) ResourceID() string {
+	return c.ConfigletID
+}
+
+// ResourceID retourne l'identifiant de la ressource modifiée (assignation de configlets).
+func (c ConfigletAssignmentChange) ResourceID() string {
+	return c.AssignmentID
+}
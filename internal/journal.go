@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// runsDir est le sous-dossier du répertoire d'état contenant les journaux d'exécution.
+const runsDir = "runs"
+
+// RunStatus est l'état d'une exécution journalisée.
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunFailed    RunStatus = "failed"
+	RunCompleted RunStatus = "completed"
+	// RunRolledBack : le run a échoué et ses opérations ont été compensées.
+	RunRolledBack RunStatus = "rolled-back"
+)
+
+// JournalStep est l'état journalisé d'une étape. RequestIDs conserve les identifiants
+// (workspaces, requestIds) générés pour l'étape avant leur envoi à CloudVision : une
+// reprise les réutilise, ce qui rend les requêtes rejouées idempotentes.
+type JournalStep struct {
+	ID         string            `yaml:"id" json:"id"`
+	Status     StepStatus        `yaml:"status,omitempty" json:"status,omitempty"`
+	RequestIDs map[string]string `yaml:"requestIds,omitempty" json:"requestIds,omitempty"`
+	Outputs    map[string]string `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+	// Writes décrit les écritures effectuées dans CloudVision par l'étape.
+	Writes    []string  `yaml:"writes,omitempty" json:"writes,omitempty"`
+	Error     string    `yaml:"error,omitempty" json:"error,omitempty"`
+	UpdatedAt time.Time `yaml:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// RunJournal est le journal d'exécution d'un playbook, enregistré dans
+// `<StateDir>/runs/<id>.yaml` après chaque écriture. Il contient le playbook et ses
+// variables, ce qui permet de reprendre l'exécution même si le fichier d'origine a changé.
+type RunJournal struct {
+	ID        string            `yaml:"id" json:"id"`
+	File      string            `yaml:"file,omitempty" json:"file,omitempty"`
+	Profile   string            `yaml:"profile,omitempty" json:"profile,omitempty"`
+	Status    RunStatus         `yaml:"status" json:"status"`
+	StartedAt time.Time         `yaml:"startedAt" json:"startedAt"`
+	UpdatedAt time.Time         `yaml:"updatedAt" json:"updatedAt"`
+	Vars      map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+	Playbook  *Playbook         `yaml:"playbook" json:"-"`
+	Steps     []JournalStep     `yaml:"steps" json:"steps"`
+	// Rollback est le rapport de compensation d'un run en échec.
+	Rollback []UndoReport `yaml:"rollback,omitempty" json:"rollback,omitempty"`
+	path     string
+	// unlock libère le verrou d'exécution du journal (voir Lock).
+	unlock func()
+}
+
+// runsPath retourne le répertoire des journaux d'exécution, créé s'il n'existe pas.
+func runsPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, runsDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("création de %s : %w", dir, err)
+	}
+	return dir, nil
+}
+
+// NewRunJournal crée et enregistre le journal d'une nouvelle exécution de playbook,
+// verrouillé jusqu'à Unlock. L'ID du run est horodaté (ex : 20250601-100000-3f1c).
+func NewRunJournal(file string, p *Playbook, vars map[string]string, profile string) (*RunJournal, error) {
+	dir, err := runsPath()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	id := newStateID(now)
+	j := &RunJournal{
+		ID:        id,
+		File:      file,
+		Profile:   profile,
+		Status:    RunRunning,
+		StartedAt: now,
+		Vars:      vars,
+		Playbook:  p,
+		path:      filepath.Join(dir, id+".yaml"),
+	}
+	for _, s := range p.Steps {
+		j.Steps = append(j.Steps, JournalStep{ID: s.ID})
+	}
+	if err := j.Lock(); err != nil {
+		return nil, err
+	}
+	if err := j.Save(); err != nil {
+		j.Unlock()
+		return nil, err
+	}
+	return j, nil
+}
+
+// LoadRunJournal lit le journal d'un run.
+func LoadRunJournal(id string) (*RunJournal, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("ID de run invalide : %q", id)
+	}
+	dir, err := runsPath()
+	if err != nil {
+		return nil, err
+	}
+	j, err := readRunJournal(filepath.Join(dir, id+".yaml"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("run %s introuvable", id)
+	}
+	return j, err
+}
+
+// ResumeRunJournal verrouille puis lit le journal d'un run à reprendre. Le journal est
+// lu après la prise du verrou, pour refléter l'issue d'une exécution qui vient de se
+// terminer.
+//
+// Retourne :
+//   - error : si le run est introuvable, ou s'il est en cours d'exécution dans un
+//     processus vivant (verrou détenu).
+func ResumeRunJournal(id string) (*RunJournal, error) {
+	j, err := LoadRunJournal(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := j.Lock(); err != nil {
+		return nil, err
+	}
+	locked, err := readRunJournal(j.path)
+	if err != nil {
+		j.Unlock()
+		return nil, err
+	}
+	locked.unlock = j.unlock
+	return locked, nil
+}
+
+// ListRunJournals retourne les journaux de tous les runs, du plus récent au plus ancien.
+func ListRunJournals() ([]*RunJournal, error) {
+	dir, err := runsPath()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var journals []*RunJournal
+	for _, path := range paths {
+		j, err := readRunJournal(path)
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, j)
+	}
+	sort.Slice(journals, func(a, b int) bool { return journals[a].StartedAt.After(journals[b].StartedAt) })
+	return journals, nil
+}
+
+// readRunJournal décode le journal situé à path.
+func readRunJournal(path string) (*RunJournal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var j RunJournal
+	if err := yaml.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("%s : %w", path, err)
+	}
+	if j.Playbook == nil || len(j.Steps) != len(j.Playbook.Steps) {
+		return nil, fmt.Errorf("%s : journal incohérent avec son playbook", path)
+	}
+	j.path = path
+	return &j, nil
+}
+
+// Path retourne le chemin du fichier du journal.
+func (j *RunJournal) Path() string {
+	return j.path
+}
+
+// Lock pose le verrou d'exécution du journal (`<id>.yaml.lock`, contenant le PID), pour
+// qu'un second processus ne puisse pas reprendre un run en cours. Un verrou laissé par un
+// processus disparu est repris ; un verrou détenu n'est pas attendu.
+func (j *RunJournal) Lock() error {
+	unlock, err := acquireLock(j.path+".lock", 0, 0)
+	if err != nil {
+		return fmt.Errorf("run %s en cours d'exécution : %w", j.ID, err)
+	}
+	j.unlock = unlock
+	return nil
+}
+
+// Unlock libère le verrou posé par Lock ; sans effet si le journal n'est pas verrouillé.
+func (j *RunJournal) Unlock() {
+	if j.unlock != nil {
+		j.unlock()
+		j.unlock = nil
+	}
+}
+
+// Output retourne la sortie name de l'étape step, vide si l'étape n'a pas réussi.
+func (j *RunJournal) Output(step, name string) string {
+	for _, s := range j.Steps {
+		if s.ID == step && s.Status == StepOK {
+			return s.Outputs[name]
+		}
+	}
+	return ""
+}
+
+// Save réécrit le journal de façon atomique.
+func (j *RunJournal) Save() error {
+	j.UpdatedAt = time.Now()
+	data, err := yaml.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("encodage YAML : %w", err)
+	}
+	return writeFileAtomic(j.path, data, 0o600)
+}
+
+// Resumable indique si le run peut être repris : il n'est ni terminé avec succès,
+// ni compensé.
+func (j *RunJournal) Resumable() bool {
+	return j.Status != RunCompleted && j.Status != RunRolledBack
+}
+
+// Done indique si une étape journalisée est terminée (réussie ou ignorée) et ne doit
+// pas être réexécutée par une reprise.
+func (s JournalStep) Done() bool {
+	return s.Status == StepOK || s.Status == StepSkipped
+}
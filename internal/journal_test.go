@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunJournalLock(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	p := &Playbook{Steps: []PlaybookStep{{ID: "ws", Action: "workspace.create", With: map[string]string{"name": "x"}}}}
+	j, err := NewRunJournal("test.yaml", p, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Le run est verrouillé par ce processus, toujours vivant : la reprise est refusée.
+	if _, err := ResumeRunJournal(j.ID); err == nil || !strings.Contains(err.Error(), "en cours d'exécution") {
+		t.Fatalf("reprise d'un run verrouillé : %v, attendu un refus", err)
+	}
+
+	j.Status = RunFailed
+	if err := j.Save(); err != nil {
+		t.Fatal(err)
+	}
+	j.Unlock()
+	resumed, err := ResumeRunJournal(j.ID)
+	if err != nil {
+		t.Fatalf("reprise après libération : %v", err)
+	}
+	if resumed.Status != RunFailed {
+		t.Errorf("état relu = %s, attendu %s", resumed.Status, RunFailed)
+	}
+	resumed.Unlock()
+
+	// Un verrou laissé par un processus disparu est repris.
+	if err := os.WriteFile(j.Path()+".lock", []byte("2147483647\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err = ResumeRunJournal(j.ID)
+	if err != nil {
+		t.Fatalf("reprise après un verrou abandonné : %v", err)
+	}
+	resumed.Unlock()
+}
@@ -0,0 +1,644 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	configlet "github.com/aristanetworks/cloudvision-go/api/arista/configlet.v1"
+	studio "github.com/aristanetworks/cloudvision-go/api/arista/studio.v1"
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+	"github.com/aristanetworks/cloudvision-go/api/fmp"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v2"
+)
+
+// operationsDir est le sous-dossier du répertoire d'état contenant le journal des opérations.
+const operationsDir = "operations"
+
+// Operation est une invocation de la CLI ayant écrit dans CloudVision, enregistrée dans
+// `<StateDir>/operations/<id>.yaml` : les modifications écrites dans ses workspaces et,
+// pour chaque ressource modifiée, sa valeur sur mainline avant la première écriture.
+//
+// Prior exprime ces valeurs antérieures sous forme de modifications qui les rétablissent
+// (ajout ou modification si la ressource existait, suppression sinon) : c'est ce
+// qu'applique `undo`.
+type Operation struct {
+	ID         string           `yaml:"id" json:"id"`
+	Command    string           `yaml:"command" json:"command"`
+	Profile    string           `yaml:"profile,omitempty" json:"profile,omitempty"`
+	Time       time.Time        `yaml:"time" json:"time"`
+	Workspaces []string         `yaml:"workspaces" json:"workspaces"`
+	Changes    WorkspaceChanges `yaml:"changes" json:"changes"`
+	Prior      WorkspaceChanges `yaml:"prior" json:"prior"`
+	// Unrecorded liste les types de ressources dont la valeur antérieure n'a pas pu
+	// être lue : leurs écritures ne peuvent pas être annulées.
+	Unrecorded []ResourceError `yaml:"unrecorded,omitempty" json:"unrecorded,omitempty"`
+	// UndoneBy est le workspace créé par `undo` pour annuler l'opération.
+	UndoneBy string `yaml:"undoneBy,omitempty" json:"undoneBy,omitempty"`
+	// Undoes est l'opération annulée, si l'opération a été effectuée par `undo`.
+	Undoes string `yaml:"undoes,omitempty" json:"undoes,omitempty"`
+	// Reverted liste les workspaces de l'opération abandonnés ou dont les écritures ont
+	// été compensées : ces écritures n'atteindront pas mainline.
+	Reverted []string `yaml:"reverted,omitempty" json:"reverted,omitempty"`
+	path     string
+	// recorded contient les ressources dont la valeur antérieure est déjà dans Prior.
+	recorded map[string]bool
+}
+
+// currentOperation est l'opération de l'invocation en cours. Son journal n'est créé
+// qu'à la première écriture : une commande en lecture seule n'en laisse pas.
+var currentOperation struct {
+	sync.Mutex
+	op *Operation
+}
+
+// StartOperation démarre l'enregistrement des écritures de l'invocation en cours.
+//
+// Paramètres :
+//   - command : la ligne de commande, affichée par `undo --list`
+//   - profile : le profil CVaaS utilisé
+func StartOperation(command, profile string) {
+	currentOperation.Lock()
+	defer currentOperation.Unlock()
+	currentOperation.op = &Operation{Command: command, Profile: profile, recorded: map[string]bool{}}
+}
+
+// MarkOperationUndo signale que les écritures de l'invocation en cours annulent
+// l'opération undoes : l'opération ainsi créée n'est pas proposée par LastOperation.
+func MarkOperationUndo(undoes string) {
+	currentOperation.Lock()
+	defer currentOperation.Unlock()
+	if currentOperation.op != nil {
+		currentOperation.op.Undoes = undoes
+	}
+}
+
+// revertWorkspaces enregistre, dans l'opération en cours et les opérations journalisées,
+// que les écritures des workspaces donnés n'atteindront pas mainline (workspace
+// abandonné ou écritures compensées). Une opération annulée par l'un de ces workspaces
+// redevient annulable. Sans effet si aucune opération n'est démarrée ; une erreur
+// d'écriture du journal est signalée sans interrompre la commande.
+func revertWorkspaces(workspaceIDs ...string) {
+	currentOperation.Lock()
+	defer currentOperation.Unlock()
+	current := currentOperation.op
+	if current == nil || len(workspaceIDs) == 0 {
+		return
+	}
+	var ops []*Operation
+	if current.ID != "" {
+		ops = append(ops, current)
+	}
+	dir, err := operationsPath()
+	if err == nil {
+		var paths []string
+		paths, err = filepath.Glob(filepath.Join(dir, "*.yaml"))
+		for _, path := range paths {
+			if path == current.path {
+				continue
+			}
+			op, readErr := readOperation(path)
+			if readErr != nil {
+				err = readErr
+				continue
+			}
+			ops = append(ops, op)
+		}
+	}
+	for _, op := range ops {
+		if op.revert(workspaceIDs) {
+			if saveErr := op.save(); saveErr != nil {
+				err = saveErr
+			}
+		}
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Journal des opérations non mis à jour : %v\n", err)
+	}
+}
+
+// revert ajoute à Reverted les workspaces de l'opération parmi workspaceIDs, et efface
+// UndoneBy si l'annulation est dans l'un d'eux. Retourne true si l'opération a changé.
+func (op *Operation) revert(workspaceIDs []string) bool {
+	changed := false
+	for _, id := range workspaceIDs {
+		if slices.Contains(op.Workspaces, id) && !slices.Contains(op.Reverted, id) {
+			op.Reverted = append(op.Reverted, id)
+			changed = true
+		}
+		if op.UndoneBy == id {
+			op.UndoneBy = ""
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Discarded indique que l'opération n'a aucun effet sur mainline : tous ses workspaces
+// ont été abandonnés ou leurs écritures compensées.
+func (op *Operation) Discarded() bool {
+	for _, id := range op.Workspaces {
+		if !slices.Contains(op.Reverted, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// pendingWrite est une écriture en cours d'enregistrement : les modifications à
+// écrire et l'état de leurs ressources sur mainline, lu avant l'écriture.
+type pendingWrite struct {
+	workspaceID string
+	changes     WorkspaceChanges
+	prior       WorkspaceChanges
+}
+
+// prepareWrite lit sur mainline les valeurs des ressources que changes va modifier.
+// Elle doit être appelée avant l'écriture ; record l'enregistre ensuite dans
+// l'opération en cours. Retourne nil si aucune opération n'est démarrée.
+func prepareWrite(ctx context.Context, conn *grpc.ClientConn, workspaceID string, changes WorkspaceChanges) *pendingWrite {
+	currentOperation.Lock()
+	started := currentOperation.op != nil
+	currentOperation.Unlock()
+	if !started || changes.Count() == 0 {
+		return nil
+	}
+	return &pendingWrite{workspaceID: workspaceID, changes: changes, prior: readMainlineState(ctx, conn, changes)}
+}
+
+// allWritten indique que toutes les modifications d'une écriture ont réussi.
+func allWritten(string, int) bool { return true }
+
+// record ajoute à l'opération en cours les modifications effectivement écrites :
+// written(kind, i) indique si la i-ème modification du type kind a réussi. Une erreur
+// d'écriture du journal est signalée sans interrompre la commande.
+func (w *pendingWrite) record(written func(kind string, i int) bool) {
+	if w == nil {
+		return
+	}
+	changes := WorkspaceChanges{
+		Tags:                 keepWritten(KindTag, w.changes.Tags, written),
+		TagAssignments:       keepWritten(KindTagAssignment, w.changes.TagAssignments, written),
+		StudioInputs:         keepWritten(KindStudioInput, w.changes.StudioInputs, written),
+		StudioAssignments:    keepWritten(KindStudioAssignment, w.changes.StudioAssignments, written),
+		Configlets:           keepWritten(KindConfiglet, w.changes.Configlets, written),
+		ConfigletAssignments: keepWritten(KindConfigletAssignment, w.changes.ConfigletAssignments, written),
+	}
+	if changes.Count() == 0 {
+		return
+	}
+	prior := WorkspaceChanges{
+		Tags:                 keepWritten(KindTag, w.prior.Tags, written),
+		TagAssignments:       keepWritten(KindTagAssignment, w.prior.TagAssignments, written),
+		StudioInputs:         keepWritten(KindStudioInput, w.prior.StudioInputs, written),
+		StudioAssignments:    keepWritten(KindStudioAssignment, w.prior.StudioAssignments, written),
+		Configlets:           keepWritten(KindConfiglet, w.prior.Configlets, written),
+		ConfigletAssignments: keepWritten(KindConfigletAssignment, w.prior.ConfigletAssignments, written),
+		Unreadable:           w.prior.Unreadable,
+	}
+
+	currentOperation.Lock()
+	defer currentOperation.Unlock()
+	if err := currentOperation.op.add(w.workspaceID, changes, prior); err != nil {
+		fmt.Printf("⚠️  Journal des opérations non mis à jour : %v\n", err)
+	}
+}
+
+// keepWritten retourne les éléments de items dont l'écriture a réussi.
+func keepWritten[T any](kind string, items []T, written func(kind string, i int) bool) []T {
+	var kept []T
+	for i, item := range items {
+		if written(kind, i) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// add ajoute une écriture à l'opération, puis réécrit son journal (créé à la première
+// écriture). Seule la première valeur antérieure d'une ressource est conservée.
+func (op *Operation) add(workspaceID string, changes, prior WorkspaceChanges) error {
+	if op.ID == "" {
+		dir, err := operationsPath()
+		if err != nil {
+			return err
+		}
+		op.Time = time.Now()
+		op.ID = newStateID(op.Time)
+		op.path = filepath.Join(dir, op.ID+".yaml")
+	}
+	if !slices.Contains(op.Workspaces, workspaceID) {
+		op.Workspaces = append(op.Workspaces, workspaceID)
+	}
+	op.Changes.Tags = append(op.Changes.Tags, changes.Tags...)
+	op.Changes.TagAssignments = append(op.Changes.TagAssignments, changes.TagAssignments...)
+	op.Changes.StudioInputs = append(op.Changes.StudioInputs, changes.StudioInputs...)
+	op.Changes.StudioAssignments = append(op.Changes.StudioAssignments, changes.StudioAssignments...)
+	op.Changes.Configlets = append(op.Changes.Configlets, changes.Configlets...)
+	op.Changes.ConfigletAssignments = append(op.Changes.ConfigletAssignments, changes.ConfigletAssignments...)
+
+	first := func(kind, resource string) bool {
+		key := kind + "|" + resource
+		if op.recorded[key] {
+			return false
+		}
+		op.recorded[key] = true
+		return true
+	}
+	for _, c := range prior.Tags {
+		if first(KindTag, c.String()) {
+			op.Prior.Tags = append(op.Prior.Tags, c)
+		}
+	}
+	for _, c := range prior.TagAssignments {
+		if first(KindTagAssignment, c.resourceID()) {
+			op.Prior.TagAssignments = append(op.Prior.TagAssignments, c)
+		}
+	}
+	for _, c := range prior.StudioInputs {
+		if first(KindStudioInput, c.ResourceID()) {
+			op.Prior.StudioInputs = append(op.Prior.StudioInputs, c)
+		}
+	}
+	for _, c := range prior.StudioAssignments {
+		if first(KindStudioAssignment, c.ResourceID()) {
+			op.Prior.StudioAssignments = append(op.Prior.StudioAssignments, c)
+		}
+	}
+	for _, c := range prior.Configlets {
+		if first(KindConfiglet, c.ResourceID()) {
+			op.Prior.Configlets = append(op.Prior.Configlets, c)
+		}
+	}
+	for _, c := range prior.ConfigletAssignments {
+		if first(KindConfigletAssignment, c.ResourceID()) {
+			op.Prior.ConfigletAssignments = append(op.Prior.ConfigletAssignments, c)
+		}
+	}
+	op.Unrecorded = append(op.Unrecorded, prior.Unreadable...)
+	return op.save()
+}
+
+// resourceID identifie l'assignation modifiée, indépendamment du hostname affiché.
+func (c TagAssignmentChange) resourceID() string {
+	return strings.Join([]string{c.Label, c.Value, c.ElementType, c.ElementSubType, c.DeviceID, c.InterfaceID}, "|")
+}
+
+// operationsPath retourne le répertoire du journal des opérations, créé s'il n'existe pas.
+func operationsPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, operationsDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("création de %s : %w", dir, err)
+	}
+	return dir, nil
+}
+
+// LoadOperation lit une opération du journal.
+func LoadOperation(id string) (*Operation, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("ID d'opération invalide : %q", id)
+	}
+	dir, err := operationsPath()
+	if err != nil {
+		return nil, err
+	}
+	op, err := readOperation(filepath.Join(dir, id+".yaml"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("opération %s introuvable", id)
+	}
+	return op, err
+}
+
+// ListOperations retourne les opérations d'un profil, de la plus récente à la plus ancienne.
+func ListOperations(profile string) ([]*Operation, error) {
+	dir, err := operationsPath()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var ops []*Operation
+	for _, path := range paths {
+		op, err := readOperation(path)
+		if err != nil {
+			return nil, err
+		}
+		if op.Profile == profile {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(a, b int) bool { return ops[a].Time.After(ops[b].Time) })
+	return ops, nil
+}
+
+// LastOperation retourne l'opération la plus récente d'un profil qui reste à annuler :
+// ni déjà annulée, ni sans effet sur mainline (voir Discarded), ni effectuée par `undo`
+// (annuler une annulation rétablirait l'opération d'origine).
+func LastOperation(profile string) (*Operation, error) {
+	ops, err := ListOperations(profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if op.UndoneBy == "" && op.Undoes == "" && !op.Discarded() {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("aucune opération à annuler pour le profil %s", profile)
+}
+
+// readOperation décode l'opération enregistrée à path.
+func readOperation(path string) (*Operation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var op Operation
+	if err := yaml.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("%s : %w", path, err)
+	}
+	op.path = path
+	return &op, nil
+}
+
+// save réécrit le journal de l'opération de façon atomique.
+func (op *Operation) save() error {
+	data, err := yaml.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("encodage YAML : %w", err)
+	}
+	return writeFileAtomic(op.path, data, 0o600)
+}
+
+// MarkUndone enregistre le workspace créé pour annuler l'opération.
+func (op *Operation) MarkUndone(workspaceID string) error {
+	op.UndoneBy = workspaceID
+	return op.save()
+}
+
+// UndoChanges retourne les modifications qui rétablissent les valeurs antérieures à
+// l'opération. Les ressources ayant déjà ces valeurs sur mainline (écritures jamais
+// soumises, workspace abandonné ou opération déjà annulée) en sont écartées.
+//
+// Retourne :
+//   - WorkspaceChanges : les modifications à appliquer ; Unreadable liste les types
+//     dont la valeur antérieure ou l'état actuel n'a pas pu être lu.
+//   - int : le nombre de ressources écartées car déjà dans leur état antérieur.
+func (op *Operation) UndoChanges(ctx context.Context, conn *grpc.ClientConn) (WorkspaceChanges, int) {
+	current := readMainlineState(ctx, conn, op.Prior)
+	unchanged := 0
+	return WorkspaceChanges{
+		Tags:                 changedSince(op.Prior.Tags, current.Tags, &unchanged),
+		TagAssignments:       changedSince(op.Prior.TagAssignments, current.TagAssignments, &unchanged),
+		StudioInputs:         changedSince(op.Prior.StudioInputs, current.StudioInputs, &unchanged),
+		StudioAssignments:    changedSince(op.Prior.StudioAssignments, current.StudioAssignments, &unchanged),
+		Configlets:           changedSince(op.Prior.Configlets, current.Configlets, &unchanged),
+		ConfigletAssignments: changedSince(op.Prior.ConfigletAssignments, current.ConfigletAssignments, &unchanged),
+		Unreadable:           append(slices.Clone(op.Unrecorded), current.Unreadable...),
+	}, unchanged
+}
+
+// changedSince retourne les états antérieurs différents de l'état actuel, et compte
+// les autres dans unchanged. current est vide si le type n'a pas pu être relu.
+func changedSince[T any](prior, current []T, unchanged *int) []T {
+	if len(current) != len(prior) {
+		return nil
+	}
+	var changed []T
+	for i := range prior {
+		if reflect.DeepEqual(prior[i], current[i]) {
+			*unchanged++
+			continue
+		}
+		changed = append(changed, prior[i])
+	}
+	return changed
+}
+
+// readMainlineState lit sur mainline l'état des ressources concernées par changes,
+// exprimé comme les modifications qui le rétablissent : ajout (ou modification, avec
+// la valeur de mainline) si la ressource existe, suppression sinon.
+//
+// Le résultat contient une entrée par modification, dans le même ordre. Un type de
+// ressource illisible est signalé dans Unreadable et n'a aucune entrée.
+func readMainlineState(ctx context.Context, conn *grpc.ClientConn, changes WorkspaceChanges) WorkspaceChanges {
+	var state WorkspaceChanges
+	var err error
+	fail := func(kind string, err error) {
+		state.Unreadable = append(state.Unreadable, ResourceError{Kind: kind, Error: err.Error()})
+	}
+	if state.Tags, err = readTagState(ctx, conn, changes.Tags); err != nil {
+		fail(KindTag, err)
+	}
+	if state.TagAssignments, err = readTagAssignmentState(ctx, conn, changes.TagAssignments); err != nil {
+		fail(KindTagAssignment, err)
+	}
+	if state.StudioInputs, err = readStudioInputState(ctx, conn, changes.StudioInputs); err != nil {
+		fail(KindStudioInput, err)
+	}
+	if state.StudioAssignments, err = readStudioAssignmentState(ctx, conn, changes.StudioAssignments); err != nil {
+		fail(KindStudioAssignment, err)
+	}
+	if state.Configlets, err = readConfigletState(ctx, conn, changes.Configlets); err != nil {
+		fail(KindConfiglet, err)
+	}
+	if state.ConfigletAssignments, err = readConfigletAssignmentState(ctx, conn, changes.ConfigletAssignments); err != nil {
+		fail(KindConfigletAssignment, err)
+	}
+	return state
+}
+
+// stateAction retourne l'action rétablissant l'état d'une ressource : present si elle
+// existe sur mainline, suppression sinon.
+func stateAction(exists bool, present ChangeAction) ChangeAction {
+	if exists {
+		return present
+	}
+	return ChangeRemove
+}
+
+// readTagState lit l'existence sur mainline des tags de changes.
+func readTagState(ctx context.Context, conn *grpc.ClientConn, changes []TagChange) ([]TagChange, error) {
+	client := tag.NewTagServiceClient(conn)
+	state := make([]TagChange, 0, len(changes))
+	for _, c := range changes {
+		et, st, err := parseElementKind(c.ElementType, c.ElementSubType)
+		if err != nil {
+			return nil, err
+		}
+		_, err = client.GetOne(ctx, &tag.TagRequest{Key: tagKey("", c.Label, c.Value, et, st)})
+		exists, err := existsOnMainline(err)
+		if err != nil {
+			return nil, err
+		}
+		c.Action = stateAction(exists, ChangeAdd)
+		state = append(state, c)
+	}
+	return state, nil
+}
+
+// readTagAssignmentState lit l'existence sur mainline des assignations de changes,
+// avec une lecture par tag.
+func readTagAssignmentState(ctx context.Context, conn *grpc.ClientConn, changes []TagAssignmentChange) ([]TagAssignmentChange, error) {
+	client := tag.NewTagAssignmentServiceClient(conn)
+	assigned := map[string]map[string]bool{}
+	state := make([]TagAssignmentChange, 0, len(changes))
+	for _, c := range changes {
+		tagID := c.Label + "=" + c.Value + "|" + c.ElementType + "|" + c.ElementSubType
+		if assigned[tagID] == nil {
+			et, st, err := parseElementKind(c.ElementType, c.ElementSubType)
+			if err != nil {
+				return nil, err
+			}
+			stream, err := client.GetAll(ctx, &tag.TagAssignmentStreamRequest{PartialEqFilter: []*tag.TagAssignment{{Key: &tag.TagAssignmentKey{
+				WorkspaceId:    wrapperspb.String(""),
+				ElementType:    et,
+				ElementSubType: st,
+				Label:          wrapperspb.String(c.Label),
+				Value:          wrapperspb.String(c.Value),
+			}}}})
+			if err != nil {
+				return nil, err
+			}
+			resps, err := collect(stream.Recv)
+			if err != nil {
+				return nil, err
+			}
+			targets := map[string]bool{}
+			for _, r := range resps {
+				key := r.GetValue().GetKey()
+				targets[key.GetDeviceId().GetValue()+"|"+key.GetInterfaceId().GetValue()] = true
+			}
+			assigned[tagID] = targets
+		}
+		c.Action = stateAction(assigned[tagID][c.DeviceID+"|"+c.InterfaceID], ChangeAdd)
+		state = append(state, c)
+	}
+	return state, nil
+}
+
+// readStudioInputState lit sur mainline les inputs des studios aux chemins de changes.
+func readStudioInputState(ctx context.Context, conn *grpc.ClientConn, changes []StudioInputChange) ([]StudioInputChange, error) {
+	client := studio.NewInputsServiceClient(conn)
+	state := make([]StudioInputChange, 0, len(changes))
+	for _, c := range changes {
+		resp, err := client.GetOne(ctx, &studio.InputsRequest{Key: &studio.InputsKey{
+			StudioId:    wrapperspb.String(c.StudioID),
+			WorkspaceId: wrapperspb.String(""),
+			Path:        &fmp.RepeatedString{Values: c.Path},
+		}})
+		exists, err := existsOnMainline(err)
+		if err != nil {
+			return nil, err
+		}
+		c.Action = stateAction(exists, ChangeModify)
+		c.Inputs = resp.GetValue().GetInputs().GetValue()
+		state = append(state, c)
+	}
+	return state, nil
+}
+
+// readStudioAssignmentState lit sur mainline les requêtes d'assignation des studios de changes.
+func readStudioAssignmentState(ctx context.Context, conn *grpc.ClientConn, changes []StudioAssignmentChange) ([]StudioAssignmentChange, error) {
+	client := studio.NewAssignedTagsServiceClient(conn)
+	state := make([]StudioAssignmentChange, 0, len(changes))
+	for _, c := range changes {
+		resp, err := client.GetOne(ctx, &studio.AssignedTagsRequest{Key: &studio.StudioKey{
+			StudioId:    wrapperspb.String(c.StudioID),
+			WorkspaceId: wrapperspb.String(""),
+		}})
+		exists, err := existsOnMainline(err)
+		if err != nil {
+			return nil, err
+		}
+		c.Action = stateAction(exists, ChangeModify)
+		c.Query = resp.GetValue().GetQuery().GetValue()
+		state = append(state, c)
+	}
+	return state, nil
+}
+
+// mainlineString retourne la valeur d'un champ lu sur mainline, toujours renseignée
+// (vide si absente) : rétablir l'état antérieur réécrit tous les champs.
+func mainlineString(v *wrapperspb.StringValue) *string {
+	value := v.GetValue()
+	return &value
+}
+
+// mainlineStrings fonctionne comme mainlineString pour une liste. Une liste absente
+// est retournée vide, comme après relecture du journal.
+func mainlineStrings(v *fmp.RepeatedString) *[]string {
+	values := append([]string{}, v.GetValues()...)
+	return &values
+}
+
+// readConfigletState lit sur mainline les configlets de changes.
+func readConfigletState(ctx context.Context, conn *grpc.ClientConn, changes []ConfigletChange) ([]ConfigletChange, error) {
+	client := configlet.NewConfigletServiceClient(conn)
+	state := make([]ConfigletChange, 0, len(changes))
+	for _, c := range changes {
+		resp, err := client.GetOne(ctx, &configlet.ConfigletRequest{Key: &configlet.ConfigletKey{
+			WorkspaceId: wrapperspb.String(""),
+			ConfigletId: wrapperspb.String(c.ConfigletID),
+		}})
+		exists, err := existsOnMainline(err)
+		if err != nil {
+			return nil, err
+		}
+		c.Action = stateAction(exists, ChangeModify)
+		if exists {
+			val := resp.GetValue()
+			c.DisplayName = mainlineString(val.GetDisplayName())
+			c.Description = mainlineString(val.GetDescription())
+			c.Body = mainlineString(val.GetBody())
+		} else {
+			c.Description, c.Body = nil, nil
+		}
+		state = append(state, c)
+	}
+	return state, nil
+}
+
+// readConfigletAssignmentState lit sur mainline les assignations de configlets de changes.
+func readConfigletAssignmentState(ctx context.Context, conn *grpc.ClientConn, changes []ConfigletAssignmentChange) ([]ConfigletAssignmentChange, error) {
+	client := configlet.NewConfigletAssignmentServiceClient(conn)
+	state := make([]ConfigletAssignmentChange, 0, len(changes))
+	for _, c := range changes {
+		resp, err := client.GetOne(ctx, &configlet.ConfigletAssignmentRequest{Key: &configlet.ConfigletAssignmentKey{
+			WorkspaceId:           wrapperspb.String(""),
+			ConfigletAssignmentId: wrapperspb.String(c.AssignmentID),
+		}})
+		exists, err := existsOnMainline(err)
+		if err != nil {
+			return nil, err
+		}
+		c.Action = stateAction(exists, ChangeModify)
+		if exists {
+			val := resp.GetValue()
+			c.DisplayName = mainlineString(val.GetDisplayName())
+			c.Description = mainlineString(val.GetDescription())
+			c.Query = mainlineString(val.GetQuery())
+			c.ConfigletIDs = mainlineStrings(val.GetConfigletIds())
+			c.ChildAssignmentIDs = mainlineStrings(val.GetChildAssignmentIds())
+		} else {
+			c.Description, c.Query, c.ConfigletIDs, c.ChildAssignmentIDs = nil, nil, nil, nil
+		}
+		state = append(state, c)
+	}
+	return state, nil
+}
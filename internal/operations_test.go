@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLastOperation(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir, err := operationsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	save := func(id string, minutes int, op Operation) {
+		op.ID, op.Profile, op.Time = id, "default", start.Add(time.Duration(minutes)*time.Minute)
+		op.path = filepath.Join(dir, id+".yaml")
+		if err := op.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("assign", 0, Operation{Workspaces: []string{"ws-assign"}})
+	save("tags", 1, Operation{Workspaces: []string{"ws-tags"}, UndoneBy: "ws-undo"})
+	save("undo", 2, Operation{Workspaces: []string{"ws-undo"}, Undoes: "tags"})
+	save("abandoned", 3, Operation{Workspaces: []string{"ws-a", "ws-b"}, Reverted: []string{"ws-a", "ws-b"}})
+
+	last := func() string {
+		op, err := LastOperation("default")
+		if err != nil {
+			return ""
+		}
+		return op.ID
+	}
+	// L'annulation et l'opération sans effet sur mainline sont écartées.
+	if got := last(); got != "assign" {
+		t.Fatalf("LastOperation = %q, attendu assign", got)
+	}
+
+	StartOperation("cvaas-cli workspace abandon", "default")
+	defer func() { currentOperation.op = nil }()
+
+	// L'abandon du workspace d'annulation rend l'opération d'origine annulable.
+	revertWorkspaces("ws-undo")
+	if got := last(); got != "tags" {
+		t.Errorf("après abandon de ws-undo : LastOperation = %q, attendu tags", got)
+	}
+
+	revertWorkspaces("ws-tags", "ws-assign")
+	if got := last(); got != "" {
+		t.Errorf("tous les workspaces abandonnés : LastOperation = %q, attendu aucune", got)
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	add := TagChange{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device"}
+	remove := TagChange{Action: ChangeRemove, Label: "site", Value: "Lyon", ElementType: "device"}
+	tests := []struct {
+		name          string
+		prior         []TagChange
+		current       []TagChange
+		want          []TagChange
+		wantUnchanged int
+	}{
+		{"rien à rétablir", []TagChange{add, remove}, []TagChange{add, remove}, nil, 2},
+		{"une ressource modifiée", []TagChange{add, remove}, []TagChange{add, add}, []TagChange{remove}, 1},
+		{"toutes modifiées", []TagChange{add}, []TagChange{remove}, []TagChange{add}, 0},
+		// Un type illisible (état actuel vide) n'est pas rétabli.
+		{"état actuel illisible", []TagChange{add, remove}, nil, nil, 0},
+	}
+	for _, tt := range tests {
+		unchanged := 0
+		got := changedSince(tt.prior, tt.current, &unchanged)
+		if !reflect.DeepEqual(got, tt.want) || unchanged != tt.wantUnchanged {
+			t.Errorf("%s : %v (%d inchangée(s)), attendu %v (%d)", tt.name, got, unchanged, tt.want, tt.wantUnchanged)
+		}
+	}
+}
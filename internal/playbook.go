@@ -0,0 +1,749 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+)
+
+// DeviceSelection sélectionne des devices de l'inventaire par modèle, motifs de
+// hostname et requête de tags ; les critères renseignés se cumulent.
+type DeviceSelection struct {
+	Model   string `yaml:"model,omitempty"`
+	Devices string `yaml:"devices,omitempty"`
+	Query   string `yaml:"query,omitempty"`
+}
+
+// PlaybookStep est une étape d'un playbook. Les valeurs de With, When et Foreach sont
+// des templates Go évalués juste avant l'étape, avec :
+//   - .vars : les variables du playbook ;
+//   - .steps.<id>.<sortie> : les sorties des étapes déjà exécutées ;
+//   - .item : dans une boucle, les attributs du device courant (device, hostname,
+//     model, version, mac).
+type PlaybookStep struct {
+	ID     string            `yaml:"id"`
+	Name   string            `yaml:"name,omitempty"`
+	Action string            `yaml:"action"`
+	With   map[string]string `yaml:"with,omitempty"`
+	// When conditionne l'étape : elle est ignorée si le template vaut "false" ou "".
+	When string `yaml:"when,omitempty"`
+	// Foreach exécute l'action une fois par device sélectionné.
+	Foreach *DeviceSelection `yaml:"foreach,omitempty"`
+}
+
+// Playbook est un workflow CloudVision décrit en YAML : des variables et une liste
+// ordonnée d'étapes.
+type Playbook struct {
+	Name  string            `yaml:"name,omitempty"`
+	Vars  map[string]string `yaml:"vars,omitempty"`
+	Steps []PlaybookStep    `yaml:"steps"`
+}
+
+// playbookStepID est la forme imposée aux IDs d'étapes, pour qu'ils soient utilisables
+// dans les templates (.steps.<id>).
+var playbookStepID = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// playbookFuncs sont les fonctions disponibles dans les templates d'un playbook.
+var playbookFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"atoi":  strconv.Atoi,
+}
+
+// LoadPlaybook lit et valide un playbook : IDs d'étapes uniques, actions connues,
+// paramètres obligatoires présents et templates syntaxiquement corrects.
+func LoadPlaybook(path string) (*Playbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Playbook
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, fmt.Errorf("%s : %w", path, err)
+	}
+	if len(p.Steps) == 0 {
+		return nil, fmt.Errorf("%s : aucune étape", path)
+	}
+	seen := map[string]bool{}
+	for i, s := range p.Steps {
+		if err := s.validate(seen); err != nil {
+			return nil, fmt.Errorf("%s : étape %d : %w", path, i+1, err)
+		}
+		seen[s.ID] = true
+	}
+	return &p, nil
+}
+
+// validate vérifie une étape ; seen contient les IDs des étapes précédentes.
+func (s PlaybookStep) validate(seen map[string]bool) error {
+	if !playbookStepID.MatchString(s.ID) {
+		return fmt.Errorf("id invalide %q (lettres, chiffres et _)", s.ID)
+	}
+	if seen[s.ID] {
+		return fmt.Errorf("id %q en double", s.ID)
+	}
+	action, ok := playbookActions[s.Action]
+	if !ok {
+		return fmt.Errorf("%s : action inconnue %q", s.ID, s.Action)
+	}
+	for _, name := range action.required {
+		if _, ok := s.With[name]; !ok {
+			return fmt.Errorf("%s : paramètre %q manquant pour %s", s.ID, name, s.Action)
+		}
+	}
+	templates := []string{s.When}
+	for name, value := range s.With {
+		if !action.accepts(name) {
+			return fmt.Errorf("%s : paramètre %q inconnu pour %s", s.ID, name, s.Action)
+		}
+		templates = append(templates, value)
+	}
+	if s.Foreach != nil {
+		templates = append(templates, s.Foreach.Model, s.Foreach.Devices, s.Foreach.Query)
+	}
+	for _, t := range templates {
+		if _, err := parsePlaybookTemplate(t); err != nil {
+			return fmt.Errorf("%s : %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// ResolveVars complète les variables du playbook par les valeurs fournies
+// (flags --set). Une valeur pour une variable non déclarée est une erreur.
+func (p *Playbook) ResolveVars(values map[string]string) (map[string]string, error) {
+	vars := map[string]string{}
+	for name, value := range p.Vars {
+		vars[name] = value
+	}
+	for name, value := range values {
+		if _, ok := p.Vars[name]; !ok {
+			return nil, fmt.Errorf("variable inconnue : %s", name)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// ChangesPlaybook construit le playbook intégré des commandes qui créent un workspace
+// puis y écrivent des modifications calculées à l'avance (`run process`, `tags apply`,
+// `create workspaces`) : création (étape ws), écriture (changes), puis build et
+// soumission si demandés. Les modifications sont conservées dans les variables du
+// journal : `run resume` rejoue exactement celles de l'exécution interrompue.
+//
+// Retourne :
+//   - *Playbook : le playbook à journaliser (voir NewRunJournal).
+//   - map[string]string : ses variables résolues.
+//   - error : si les modifications ne peuvent être encodées.
+func ChangesPlaybook(title, name, description string, changes WorkspaceChanges, build, submit bool) (*Playbook, map[string]string, error) {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encodage des modifications : %w", err)
+	}
+	vars := map[string]string{
+		"name":        name,
+		"description": description,
+		"changes":     string(data),
+		"build":       strconv.FormatBool(build || submit),
+		"submit":      strconv.FormatBool(submit),
+	}
+	ws := map[string]string{"workspace": "{{ .steps.ws.id }}"}
+	p := &Playbook{Name: title, Vars: vars, Steps: []PlaybookStep{
+		{ID: "ws", Name: "Création du workspace", Action: "workspace.create",
+			With: map[string]string{"name": "{{ .vars.name }}", "description": "{{ .vars.description }}"}},
+		{ID: "changes", Name: "Écriture des modifications", Action: "workspace.changes",
+			With: map[string]string{"workspace": "{{ .steps.ws.id }}", "changes": "{{ .vars.changes }}"}},
+		{ID: "build", Name: "Build du workspace", Action: "workspace.build", When: "{{ .vars.build }}", With: ws},
+		{ID: "submit", Name: "Soumission du workspace", Action: "workspace.submit", When: "{{ .vars.submit }}", With: ws},
+	}}
+	return p, vars, nil
+}
+
+// PlaybookRun est l'exécution d'un playbook, journalisée : ses variables résolues,
+// les sorties des étapes exécutées et le journal permettant de la reprendre.
+type PlaybookRun struct {
+	ctx     context.Context
+	conn    *grpc.ClientConn
+	journal *RunJournal
+	outputs map[string]map[string]string
+	// inventory est lu au premier besoin puis partagé par les étapes.
+	inventory []DeviceInfo
+	// current est l'étape journalisée en cours ; item est l'ID du device courant
+	// d'une boucle.
+	current *JournalStep
+	item    string
+}
+
+// NewPlaybookRun prépare l'exécution, ou la reprise, du playbook d'un journal. Les
+// sorties des étapes déjà terminées sont restaurées depuis le journal.
+func NewPlaybookRun(ctx context.Context, conn *grpc.ClientConn, journal *RunJournal) *PlaybookRun {
+	r := &PlaybookRun{
+		ctx:     ctx,
+		conn:    conn,
+		journal: journal,
+		outputs: map[string]map[string]string{},
+	}
+	for _, js := range journal.Steps {
+		if js.Status == StepOK {
+			r.outputs[js.ID] = js.Outputs
+		}
+	}
+	return r
+}
+
+// Steps retourne les étapes du playbook, à exécuter avec RunSteps. Les étapes déjà
+// terminées lors d'une exécution précédente sont marquées comme ignorées.
+func (r *PlaybookRun) Steps() []Step {
+	p := r.journal.Playbook
+	steps := make([]Step, 0, len(p.Steps))
+	for i, s := range p.Steps {
+		i, s := i, s
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("%s (%s)", s.ID, s.Action)
+		}
+		steps = append(steps, Step{Name: name, Run: func() (string, error) { return r.runStep(i, s) }})
+	}
+	return steps
+}
+
+// Outputs retourne les sorties des étapes exécutées, par ID d'étape.
+func (r *PlaybookRun) Outputs() map[string]map[string]string {
+	return r.outputs
+}
+
+// Finish enregistre l'issue du run dans son journal, avec le rapport de compensation
+// de ses opérations s'il a échoué.
+func (r *PlaybookRun) Finish(err error, rollback []UndoReport) error {
+	switch {
+	case err == nil:
+		r.journal.Status = RunCompleted
+	case len(rollback) > 0:
+		r.journal.Status = RunRolledBack
+		r.journal.Rollback = rollback
+	default:
+		r.journal.Status = RunFailed
+	}
+	return r.journal.Save()
+}
+
+// runStep exécute l'étape i en journalisant son début et son issue. Une étape déjà
+// terminée n'est pas réexécutée.
+func (r *PlaybookRun) runStep(i int, s PlaybookStep) (string, error) {
+	js := &r.journal.Steps[i]
+	if js.Done() {
+		return "déjà effectuée", ErrStepSkipped
+	}
+	js.Status, js.Error, js.UpdatedAt = StepRunning, "", time.Now()
+	if err := r.journal.Save(); err != nil {
+		return "", fmt.Errorf("journal : %w", err)
+	}
+	r.current, r.item = js, ""
+	detail, err := r.execute(s)
+	r.current = nil
+
+	js.UpdatedAt = time.Now()
+	switch {
+	case errors.Is(err, ErrStepSkipped):
+		js.Status = StepSkipped
+	case err != nil:
+		js.Status, js.Error = StepFailed, err.Error()
+	default:
+		js.Status, js.Outputs = StepOK, r.outputs[s.ID]
+	}
+	if saveErr := r.journal.Save(); saveErr != nil && err == nil {
+		return "", fmt.Errorf("journal : %w", saveErr)
+	}
+	return detail, err
+}
+
+// requestID retourne l'identifiant journalisé sous key pour l'étape (et le device)
+// en cours, ou en génère un nouveau qu'il journalise avant son utilisation.
+func (r *PlaybookRun) requestID(key string) (string, error) {
+	if r.item != "" {
+		key = r.item + "/" + key
+	}
+	if id, ok := r.current.RequestIDs[key]; ok {
+		return id, nil
+	}
+	if r.current.RequestIDs == nil {
+		r.current.RequestIDs = map[string]string{}
+	}
+	id := NewUUID()
+	r.current.RequestIDs[key] = id
+	if err := r.journal.Save(); err != nil {
+		return "", fmt.Errorf("journal : %w", err)
+	}
+	return id, nil
+}
+
+// recordWrite journalise une écriture effectuée dans CloudVision par l'étape en cours.
+func (r *PlaybookRun) recordWrite(format string, args ...interface{}) error {
+	r.current.Writes = append(r.current.Writes, fmt.Sprintf(format, args...))
+	if err := r.journal.Save(); err != nil {
+		return fmt.Errorf("journal : %w", err)
+	}
+	return nil
+}
+
+// execute évalue la condition d'une étape puis exécute son action, une fois ou pour
+// chaque device de sa boucle. Une boucle s'arrête au premier device en échec.
+func (r *PlaybookRun) execute(s PlaybookStep) (string, error) {
+	if s.When != "" {
+		value, err := r.render(s.When, nil)
+		if err == nil {
+			var ok bool
+			ok, err = parsePlaybookBool(value)
+			if err == nil && !ok {
+				return "condition fausse", ErrStepSkipped
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("when : %w", err)
+		}
+	}
+	action := playbookActions[s.Action]
+	if s.Foreach == nil {
+		with, err := r.renderWith(s.With, nil)
+		if err != nil {
+			return "", err
+		}
+		out, err := action.run(r, with)
+		if err != nil {
+			return "", err
+		}
+		r.outputs[s.ID] = out
+		return FormatOutputs(out), nil
+	}
+
+	var sel DeviceSelection
+	var err error
+	if sel.Model, err = r.render(s.Foreach.Model, nil); err == nil {
+		if sel.Devices, err = r.render(s.Foreach.Devices, nil); err == nil {
+			sel.Query, err = r.render(s.Foreach.Query, nil)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("foreach : %w", err)
+	}
+	devices, err := r.selectDevices(sel)
+	if err != nil {
+		return "", fmt.Errorf("foreach : %w", err)
+	}
+	if len(devices) == 0 {
+		return "", fmt.Errorf("foreach : aucun device ne correspond à la sélection")
+	}
+	for _, d := range devices {
+		r.item = d.DeviceID
+		with, err := r.renderWith(s.With, DeviceAttributes(d))
+		if err == nil {
+			_, err = action.run(r, with)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s : %w", d.Hostname, err)
+		}
+	}
+	r.outputs[s.ID] = map[string]string{"count": strconv.Itoa(len(devices))}
+	return fmt.Sprintf("%d device(s)", len(devices)), nil
+}
+
+// parsePlaybookTemplate analyse un template de playbook ; toute clé absente est une erreur.
+func parsePlaybookTemplate(text string) (*template.Template, error) {
+	return template.New("playbook").Funcs(playbookFuncs).Option("missingkey=error").Parse(text)
+}
+
+// render évalue un template avec les variables, les sorties des étapes et, dans une
+// boucle, le device courant.
+func (r *PlaybookRun) render(text string, item map[string]string) (string, error) {
+	tmpl, err := parsePlaybookTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	data := map[string]interface{}{"vars": r.journal.Vars, "steps": r.outputs}
+	if item != nil {
+		data["item"] = item
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// renderWith évalue les paramètres d'une étape.
+func (r *PlaybookRun) renderWith(with map[string]string, item map[string]string) (map[string]string, error) {
+	rendered := make(map[string]string, len(with))
+	for name, value := range with {
+		v, err := r.render(value, item)
+		if err != nil {
+			return nil, fmt.Errorf("%s : %w", name, err)
+		}
+		rendered[name] = v
+	}
+	return rendered, nil
+}
+
+// selectDevices applique une sélection à l'inventaire, lu une seule fois par exécution.
+func (r *PlaybookRun) selectDevices(sel DeviceSelection) ([]DeviceInfo, error) {
+	if r.inventory == nil {
+		r.inventory = ReadInventory(r.ctx, r.conn, "", false, false)
+	}
+	var devices []DeviceInfo
+	for _, d := range SelectDevices(r.inventory, sel.Devices) {
+		if sel.Model == "" || d.Model == sel.Model {
+			devices = append(devices, d)
+		}
+	}
+	return FilterByTagQuery(r.ctx, r.conn, devices, sel.Query)
+}
+
+// parsePlaybookBool interprète le résultat d'une condition : "true", ou "false" / "".
+func parsePlaybookBool(s string) (bool, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("booléen attendu, obtenu %q", s)
+}
+
+// FormatOutputs résume les sorties d'une étape : « clé=valeur » triées.
+func FormatOutputs(out map[string]string) string {
+	parts := make([]string, 0, len(out))
+	for k, v := range out {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// playbookAction est une action utilisable dans un playbook. run reçoit les
+// paramètres évalués et retourne les sorties de l'étape.
+type playbookAction struct {
+	required []string
+	optional []string
+	run      func(r *PlaybookRun, with map[string]string) (map[string]string, error)
+}
+
+// accepts indique si l'action accepte le paramètre name.
+func (a playbookAction) accepts(name string) bool {
+	for _, n := range append(a.required, a.optional...) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// playbookSelectors sont les paramètres de sélection des actions tag.assign et tag.unassign.
+var playbookSelectors = []string{"devices", "model", "query", "interfaces", "elementType", "elementSubType"}
+
+// playbookActions sont les actions disponibles dans un playbook, par nom.
+var playbookActions = map[string]playbookAction{
+	"workspace.create":      {required: []string{"name"}, optional: []string{"description", "id"}, run: pbCreateWorkspace},
+	"workspace.build":       {required: []string{"workspace"}, run: pbBuildWorkspace},
+	"workspace.submit":      {required: []string{"workspace"}, run: pbWorkspaceRequest(workspace.Request_REQUEST_SUBMIT, "soumis")},
+	"workspace.abandon":     {required: []string{"workspace"}, run: pbWorkspaceRequest(workspace.Request_REQUEST_ABANDON, "abandonné")},
+	"workspace.changes":     {required: []string{"workspace", "changes"}, run: pbWorkspaceChanges},
+	"tag.create":            {required: []string{"workspace", "tag"}, optional: []string{"elementType", "elementSubType"}, run: pbCreateTag},
+	"tag.assign":            {required: []string{"workspace", "tag"}, optional: playbookSelectors, run: pbAssignTag(false)},
+	"tag.unassign":          {required: []string{"workspace", "tag"}, optional: playbookSelectors, run: pbAssignTag(true)},
+	"studio.inputs":         {required: []string{"workspace", "studio", "inputs"}, optional: []string{"path"}, run: pbStudioInputs},
+	"changecontrol.create":  {required: []string{"workspace"}, optional: []string{"name", "notes"}, run: pbCreateChangeControl},
+	"changecontrol.approve": {required: []string{"id"}, optional: []string{"notes"}, run: pbApproveChangeControl},
+	"changecontrol.execute": {required: []string{"id"}, optional: []string{"notes"}, run: pbExecuteChangeControl},
+	"wait":                  {optional: []string{"duration", "changecontrol"}, run: pbWait},
+	"assert":                {required: []string{"that"}, optional: []string{"message"}, run: pbAssert},
+}
+
+// pbCreateWorkspace crée un workspace et l'enregistre dans le registre local, s'il n'y
+// figure pas déjà (reprise). Sorties : id, requestId.
+func pbCreateWorkspace(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	var err error
+	id := with["id"]
+	if id == "" {
+		if id, err = r.requestID("workspace"); err != nil {
+			return nil, err
+		}
+	}
+	requestID, err := r.requestID("request")
+	if err != nil {
+		return nil, err
+	}
+	CreateWorkspace(r.ctx, r.conn, id, requestID, with["name"], with["description"])
+	created := WaitForWorkspace(r.ctx, r.conn, id, requestID)
+	if err := r.recordWrite("workspace %s créé (requestId %s)", id, requestID); err != nil {
+		return nil, err
+	}
+	out := map[string]string{"id": id, "requestId": requestID}
+	err = UpdateRegistry(func(reg *WorkspaceYAML) error {
+		for _, e := range reg.Workspace {
+			if e.WorkspaceID == id {
+				return nil
+			}
+		}
+		reg.Workspace = append(reg.Workspace, WorkspaceEntry{
+			WorkspaceID:   id,
+			RequestID:     requestID,
+			WorkspaceName: with["name"],
+			Profile:       r.journal.Profile,
+			State:         created.State,
+			CreatedAt:     created.CreatedAt,
+		})
+		return nil
+	})
+	if err != nil {
+		// Le workspace existe sur CVaaS : l'échec du registre local n'arrête pas le playbook.
+		out["registryError"] = err.Error()
+	}
+	return out, nil
+}
+
+// pbBuildWorkspace lance le build d'un workspace et attend son résultat. Sortie : buildId.
+func pbBuildWorkspace(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	buildID, err := r.requestID("build")
+	if err != nil {
+		return nil, err
+	}
+	if err := BuildWorkspaceWithID(r.ctx, r.conn, with["workspace"], buildID); err != nil {
+		return nil, err
+	}
+	return map[string]string{"buildId": buildID}, r.recordWrite("build %s du workspace %s", buildID, with["workspace"])
+}
+
+// pbWorkspaceRequest retourne une action envoyant une requête (soumission, abandon)
+// sur un workspace et attendant la réponse de CloudVision.
+func pbWorkspaceRequest(request workspace.Request, done string) func(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	return func(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+		requestID, err := r.requestID(request.String())
+		if err != nil {
+			return nil, err
+		}
+		if err := RequestWorkspaceWithID(r.ctx, r.conn, with["workspace"], request, requestID); err != nil {
+			return nil, err
+		}
+		if err := WaitForRequest(r.ctx, r.conn, with["workspace"], requestID); err != nil {
+			return nil, err
+		}
+		closeWorkspace(r.ctx, with["workspace"])
+		if request == workspace.Request_REQUEST_ABANDON {
+			revertWorkspaces(with["workspace"])
+		}
+		return nil, r.recordWrite("workspace %s %s (requestId %s)", with["workspace"], done, requestID)
+	}
+}
+
+// pbWorkspaceChanges écrit dans un workspace des modifications décrites en JSON (format
+// de WorkspaceChanges, voir ApplyWorkspaceChanges). Les écritures étant des Set, une
+// reprise les rejoue sans effet de bord. Sorties : le nombre de ressources écrites par
+// type (tags, tagAssignments, ...).
+func pbWorkspaceChanges(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	var changes WorkspaceChanges
+	if err := json.Unmarshal([]byte(with["changes"]), &changes); err != nil {
+		return nil, fmt.Errorf("changes : %w", err)
+	}
+	report := ApplyWorkspaceChanges(r.ctx, r.conn, with["workspace"], changes)
+	out := map[string]string{}
+	written := 0
+	for kind, n := range report.Applied {
+		out[kind] = strconv.Itoa(n)
+		written += n
+	}
+	if err := r.recordWrite("%d modification(s) écrite(s) dans %s", written, with["workspace"]); err != nil {
+		return nil, err
+	}
+	if len(report.Failed) > 0 {
+		return nil, fmt.Errorf("%s : %s", report.Failed[0].Kind, report.Failed[0].Error)
+	}
+	return out, nil
+}
+
+// pbElementType retourne le paramètre elementType, "device" par défaut.
+func pbElementType(with map[string]string) string {
+	if with["elementType"] == "" {
+		return "device"
+	}
+	return with["elementType"]
+}
+
+// pbCreateTag crée un tag (label=value) dans un workspace.
+func pbCreateTag(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	label, value, err := ParseTag(with["tag"])
+	if err != nil {
+		return nil, err
+	}
+	if err := setTagConfig(r.ctx, r.conn, with["workspace"], label, value, pbElementType(with), with["elementSubType"], false); err != nil {
+		return nil, err
+	}
+	return nil, r.recordWrite("tag %s=%s créé dans %s", label, value, with["workspace"])
+}
+
+// pbAssignTag assigne (ou désassigne) un tag aux devices sélectionnés par devices,
+// model et query, ou à leurs interfaces si interfaces est renseigné ("host:intf,...").
+// Au moins un sélecteur est requis. Sortie : count, le nombre de cibles.
+func pbAssignTag(remove bool) func(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	return func(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+		label, value, err := ParseTag(with["tag"])
+		if err != nil {
+			return nil, err
+		}
+		if with["devices"] == "" && with["model"] == "" && with["query"] == "" && with["interfaces"] == "" {
+			return nil, fmt.Errorf("aucun sélecteur (devices, model, query ou interfaces)")
+		}
+		devices, err := r.selectDevices(DeviceSelection{Model: with["model"], Devices: with["devices"], Query: with["query"]})
+		if err != nil {
+			return nil, err
+		}
+		elementType := pbElementType(with)
+		targets := DeviceTargets(devices)
+		if with["interfaces"] != "" {
+			selectors, err := ParseInterfaceSelectors(with["interfaces"])
+			if err != nil {
+				return nil, err
+			}
+			elementType = "interface"
+			interfaces, err := DeviceInterfaces(r.ctx, r.conn)
+			if err != nil {
+				return nil, err
+			}
+			var missing []TagTarget
+			targets, missing = SelectInterfaces(devices, selectors, interfaces)
+			if len(missing) > 0 {
+				return nil, fmt.Errorf("interface(s) inexistante(s) : %v", missing)
+			}
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("aucune cible ne correspond à la sélection")
+		}
+		results, err := SetTagAssignments(r.ctx, r.conn, with["workspace"], label, value, elementType, with["elementSubType"], targets, remove)
+		if err != nil {
+			return nil, err
+		}
+		written := 0
+		for _, res := range results {
+			if res.Error == "" {
+				written++
+			}
+		}
+		verb := "assigné à"
+		if remove {
+			verb = "désassigné de"
+		}
+		if err := r.recordWrite("%s=%s %s %d cible(s) dans %s", label, value, verb, written, with["workspace"]); err != nil {
+			return nil, err
+		}
+		for _, res := range results {
+			if res.Error != "" {
+				return nil, fmt.Errorf("%s : %s", res.TagTarget, res.Error)
+			}
+		}
+		return map[string]string{"count": strconv.Itoa(len(results))}, nil
+	}
+}
+
+// pbStudioInputs écrit des inputs de studio (JSON) au chemin path, dont les éléments
+// sont séparés par des "/" (racine des inputs si vide).
+func pbStudioInputs(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	if !json.Valid([]byte(with["inputs"])) {
+		return nil, fmt.Errorf("inputs : JSON invalide")
+	}
+	var path []string
+	if p := strings.Trim(with["path"], "/"); p != "" {
+		path = strings.Split(p, "/")
+	}
+	report := ApplyWorkspaceChanges(r.ctx, r.conn, with["workspace"], WorkspaceChanges{
+		StudioInputs: []StudioInputChange{{Action: ChangeModify, StudioID: with["studio"], Path: path, Inputs: with["inputs"]}},
+	})
+	if len(report.Failed) > 0 {
+		return nil, fmt.Errorf("%s", report.Failed[0].Error)
+	}
+	return nil, r.recordWrite("inputs du studio %s (/%s) écrits dans %s", with["studio"], strings.Join(path, "/"), with["workspace"])
+}
+
+// pbCreateChangeControl attend les change controls créés par CloudVision à la
+// soumission d'un workspace, puis leur donne le nom et les notes demandés. L'étape
+// échoue rapidement si le workspace soumis n'en a pas (aucune modification de device).
+// Sorties : id (le premier change control) et ids (tous, séparés par des virgules).
+func pbCreateChangeControl(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	ids, err := WaitForWorkspaceChangeControls(r.ctx, r.conn, with["workspace"])
+	if err != nil {
+		return nil, err
+	}
+	if with["name"] != "" || with["notes"] != "" {
+		for _, id := range ids {
+			if err := RenameChangeControl(r.ctx, r.conn, id, with["name"], with["notes"]); err != nil {
+				return nil, fmt.Errorf("change control %s : %w", id, err)
+			}
+			if err := r.recordWrite("change control %s renommé", id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return map[string]string{"id": ids[0], "ids": strings.Join(ids, ",")}, nil
+}
+
+// pbApproveChangeControl approuve un change control.
+func pbApproveChangeControl(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	if err := ApproveChangeControl(r.ctx, r.conn, with["id"], with["notes"]); err != nil {
+		return nil, err
+	}
+	return nil, r.recordWrite("change control %s approuvé", with["id"])
+}
+
+// pbExecuteChangeControl lance l'exécution d'un change control, sans attendre sa fin.
+func pbExecuteChangeControl(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	if err := StartChangeControl(r.ctx, r.conn, with["id"], with["notes"]); err != nil {
+		return nil, err
+	}
+	return nil, r.recordWrite("change control %s démarré", with["id"])
+}
+
+// pbWait attend une durée (duration) ou la fin d'un change control (changecontrol).
+func pbWait(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	switch {
+	case with["duration"] != "" && with["changecontrol"] == "":
+		d, err := time.ParseDuration(with["duration"])
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-time.After(d):
+			return nil, nil
+		case <-r.ctx.Done():
+			return nil, r.ctx.Err()
+		}
+	case with["changecontrol"] != "" && with["duration"] == "":
+		return nil, WaitForChangeControl(r.ctx, r.conn, with["changecontrol"])
+	}
+	return nil, fmt.Errorf("un seul des paramètres duration ou changecontrol attendu")
+}
+
+// pbAssert échoue si la condition that n'est pas vraie.
+func pbAssert(r *PlaybookRun, with map[string]string) (map[string]string, error) {
+	ok, err := parsePlaybookBool(with["that"])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if with["message"] != "" {
+			return nil, fmt.Errorf("%s", with["message"])
+		}
+		return nil, fmt.Errorf("assertion non vérifiée")
+	}
+	return nil, nil
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestChangesPlaybook(t *testing.T) {
+	changes := WorkspaceChanges{
+		Tags: []TagChange{{Action: ChangeAdd, Label: "site", Value: `Paris "{{ .vars.x }}"`, ElementType: "device"}},
+		TagAssignments: []TagAssignmentChange{
+			{Action: ChangeAdd, Label: "site", Value: `Paris "{{ .vars.x }}"`, ElementType: "device", DeviceID: "SN1", Hostname: "leaf-1"},
+		},
+	}
+	p, vars, err := ChangesPlaybook("tags apply", "tags é", "", changes, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, s := range p.Steps {
+		if err := s.validate(seen); err != nil {
+			t.Fatalf("étape %s invalide : %v", s.ID, err)
+		}
+		seen[s.ID] = true
+	}
+	if vars["build"] != "true" || vars["submit"] != "true" {
+		t.Errorf("--submit implique le build : build=%s submit=%s", vars["build"], vars["submit"])
+	}
+
+	// Les modifications sont rendues telles quelles, même si elles ressemblent à un template.
+	r := &PlaybookRun{journal: &RunJournal{Vars: vars}, outputs: map[string]map[string]string{"ws": {"id": "ws-1"}}}
+	with, err := r.renderWith(p.Steps[1].With, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got WorkspaceChanges
+	if err := json.Unmarshal([]byte(with["changes"]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, changes) || with["workspace"] != "ws-1" {
+		t.Errorf("rendu = %+v (%s), attendu %+v (ws-1)", got, with["workspace"], changes)
+	}
+}
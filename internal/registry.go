@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+)
+
+// registryFile est le nom du registre local des workspaces dans le répertoire d'état.
+const registryFile = "workspace.yaml"
+
+// legacyRegistryPath est l'ancien emplacement du registre, relatif au dossier courant.
+// Il n'est plus lu qu'une fois, pour initialiser le registre dans le répertoire d'état.
+var legacyRegistryPath = filepath.Join("data", "workspace.yaml")
+
+// WorkspaceEntry représente un enregistrement unique d'un workspace CVaaS,
+// utilisé pour la tracabilité dans un fichier YAML local.
+type WorkspaceEntry struct {
+	WorkspaceID   string    `yaml:"workspaceID"`
+	RequestID     string    `yaml:"RequestID"`
+	WorkspaceName string    `yaml:"workspaceName"`
+	Profile       string    `yaml:"profile,omitempty"`
+	State         string    `yaml:"state,omitempty"`
+	CreatedAt     time.Time `yaml:"createdAt,omitempty"`
+	SyncedAt      time.Time `yaml:"syncedAt,omitempty"`
+}
+
+// WorkspaceYAML est une structure regroupant plusieurs workspaces,
+// utilisée pour sérialiser et désérialiser les données dans un fichier YAML.
+type WorkspaceYAML struct {
+	Workspace []WorkspaceEntry `yaml:"workspace"`
+}
+
+// RegistryPath retourne le chemin du registre local des workspaces.
+func RegistryPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, registryFile), nil
+}
+
+// LoadRegistry lit le registre local des workspaces. Si le registre n'existe pas
+// encore, l'ancien fichier `data/workspace.yaml` est lu à sa place s'il est présent.
+func LoadRegistry() (WorkspaceYAML, error) {
+	path, err := RegistryPath()
+	if err != nil {
+		return WorkspaceYAML{}, err
+	}
+	return readRegistry(path)
+}
+
+// UpdateRegistry applique update au registre local sous verrou exclusif, puis
+// le réécrit de façon atomique. Plusieurs invocations concurrentes de la CLI
+// peuvent ainsi modifier le registre sans perdre d'entrées.
+func UpdateRegistry(update func(*WorkspaceYAML) error) error {
+	path, err := RegistryPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	registry, err := readRegistry(path)
+	if err != nil {
+		return err
+	}
+	if err := update(&registry); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(&registry)
+	if err != nil {
+		return fmt.Errorf("encodage YAML : %w", err)
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// RecordWorkspace ajoute une entrée au registre local, ou remplace celle du même
+// workspace (création rejouée avec le même requestID).
+func RecordWorkspace(entry WorkspaceEntry) error {
+	return UpdateRegistry(func(r *WorkspaceYAML) error {
+		for i, e := range r.Workspace {
+			if e.WorkspaceID == entry.WorkspaceID {
+				r.Workspace[i] = entry
+				return nil
+			}
+		}
+		r.Workspace = append(r.Workspace, entry)
+		return nil
+	})
+}
+
+// readRegistry décode le registre situé à path, avec repli sur l'ancien emplacement.
+func readRegistry(path string) (WorkspaceYAML, error) {
+	var registry WorkspaceYAML
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		content, err = os.ReadFile(legacyRegistryPath)
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+	}
+	if err != nil {
+		return registry, err
+	}
+	if err := yaml.Unmarshal(content, &registry); err != nil {
+		return registry, fmt.Errorf("décodage %s : %w", path, err)
+	}
+	return registry, nil
+}
+
+// RegistryStateUnknown est l'état enregistré pour un workspace du registre
+// introuvable sur CVaaS.
+const RegistryStateUnknown = "UNKNOWN"
+
+// prunableStates liste les états pour lesquels une entrée du registre est supprimée par PruneRegistry.
+var prunableStates = map[string]bool{
+	workspace.WorkspaceState_WORKSPACE_STATE_ABANDONED.String(): true,
+	RegistryStateUnknown: true,
+}
+
+// SyncRegistry met à jour les entrées du registre appartenant au profil donné avec
+// l'état et la date de création vus par CVaaS. Les entrées sans profil (anciens
+// enregistrements) ne sont pas touchées : elles peuvent appartenir à un autre tenant.
+// Avec adopt, elles sont rattachées au profil courant puis synchronisées.
+//
+// Retourne :
+//   - []WorkspaceEntry : les entrées du profil après synchronisation.
+//
+// Panique :
+//   - Si la lecture des workspaces sur CVaaS échoue.
+func SyncRegistry(ctx context.Context, conn *grpc.ClientConn, profile string, adopt bool) ([]WorkspaceEntry, error) {
+	remote := remoteWorkspaces(ctx, conn)
+	var synced []WorkspaceEntry
+	err := UpdateRegistry(func(r *WorkspaceYAML) error {
+		synced = syncEntries(r, remote, profile, adopt, time.Now().UTC())
+		return nil
+	})
+	return synced, err
+}
+
+// PruneRegistry synchronise le registre puis supprime les entrées du profil dont
+// le workspace est abandonné ou inconnu de CVaaS. Avec dryRun, le registre n'est pas
+// modifié : la synchronisation et la suppression sont calculées sur une copie.
+//
+// Retourne :
+//   - []WorkspaceEntry : les entrées supprimées (ou qui le seraient avec dryRun).
+//
+// Panique :
+//   - Si la lecture des workspaces sur CVaaS échoue.
+func PruneRegistry(ctx context.Context, conn *grpc.ClientConn, profile string, dryRun bool) ([]WorkspaceEntry, error) {
+	remote := remoteWorkspaces(ctx, conn)
+	now := time.Now().UTC()
+	if dryRun {
+		registry, err := LoadRegistry()
+		if err != nil {
+			return nil, err
+		}
+		return pruneEntries(&registry, remote, profile, now), nil
+	}
+	var removed []WorkspaceEntry
+	err := UpdateRegistry(func(r *WorkspaceYAML) error {
+		removed = pruneEntries(r, remote, profile, now)
+		return nil
+	})
+	return removed, err
+}
+
+// remoteWorkspaces retourne tous les workspaces de CVaaS, indexés par ID.
+func remoteWorkspaces(ctx context.Context, conn *grpc.ClientConn) map[string]WorkspaceInfo {
+	remote := map[string]WorkspaceInfo{}
+	for _, w := range GetWorkspacesByState(ctx, conn, "NONE") {
+		remote[w.ID] = w
+	}
+	return remote
+}
+
+// syncEntries met à jour, dans r, les entrées du profil avec l'état des workspaces de
+// remote (RegistryStateUnknown pour un workspace absent), et les retourne. Avec adopt,
+// les entrées sans profil sont d'abord rattachées au profil.
+func syncEntries(r *WorkspaceYAML, remote map[string]WorkspaceInfo, profile string, adopt bool, now time.Time) []WorkspaceEntry {
+	var synced []WorkspaceEntry
+	for i := range r.Workspace {
+		entry := &r.Workspace[i]
+		if entry.Profile == "" && adopt {
+			entry.Profile = profile
+		}
+		if entry.Profile != profile {
+			continue
+		}
+		if w, ok := remote[entry.WorkspaceID]; ok {
+			entry.State = w.State
+			if !w.CreatedAt.IsZero() {
+				entry.CreatedAt = w.CreatedAt
+			}
+		} else {
+			entry.State = RegistryStateUnknown
+		}
+		entry.SyncedAt = now
+		synced = append(synced, *entry)
+	}
+	return synced
+}
+
+// pruneEntries synchronise les entrées du profil (sans adopter les entrées sans
+// profil, voir syncEntries), retire de r celles dont l'état est à supprimer et les
+// retourne.
+func pruneEntries(r *WorkspaceYAML, remote map[string]WorkspaceInfo, profile string, now time.Time) []WorkspaceEntry {
+	syncEntries(r, remote, profile, false, now)
+	var removed []WorkspaceEntry
+	kept := r.Workspace[:0]
+	for _, entry := range r.Workspace {
+		if entry.Profile == profile && prunableStates[entry.State] {
+			removed = append(removed, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	r.Workspace = kept
+	return removed
+}
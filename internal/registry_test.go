@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPruneEntries(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	remote := map[string]WorkspaceInfo{
+		"ws-pending":   {ID: "ws-pending", State: "WORKSPACE_STATE_PENDING"},
+		"ws-abandoned": {ID: "ws-abandoned", State: "WORKSPACE_STATE_ABANDONED"},
+		"ws-submitted": {ID: "ws-submitted", State: "WORKSPACE_STATE_SUBMITTED"},
+	}
+	registry := WorkspaceYAML{Workspace: []WorkspaceEntry{
+		{WorkspaceID: "ws-pending", Profile: "lab"},
+		{WorkspaceID: "ws-abandoned", Profile: "lab"},
+		{WorkspaceID: "ws-gone", Profile: "lab"},
+		{WorkspaceID: "ws-submitted", Profile: "lab"},
+		// Entrées d'un autre tenant ou sans profil : jamais supprimées.
+		{WorkspaceID: "ws-other", Profile: "prod"},
+		{WorkspaceID: "ws-legacy"},
+	}}
+
+	removed := pruneEntries(&registry, remote, "lab", now)
+
+	var removedIDs, keptIDs []string
+	for _, e := range removed {
+		removedIDs = append(removedIDs, e.WorkspaceID+"="+e.State)
+	}
+	for _, e := range registry.Workspace {
+		keptIDs = append(keptIDs, e.WorkspaceID)
+	}
+	if want := []string{"ws-abandoned=WORKSPACE_STATE_ABANDONED", "ws-gone=" + RegistryStateUnknown}; !reflect.DeepEqual(removedIDs, want) {
+		t.Errorf("supprimées = %v, attendu %v", removedIDs, want)
+	}
+	if want := []string{"ws-pending", "ws-submitted", "ws-other", "ws-legacy"}; !reflect.DeepEqual(keptIDs, want) {
+		t.Errorf("conservées = %v, attendu %v", keptIDs, want)
+	}
+	for _, e := range registry.Workspace {
+		synced := e.Profile == "lab"
+		if synced != !e.SyncedAt.IsZero() {
+			t.Errorf("%s : synchronisée = %v, attendu %v", e.WorkspaceID, !e.SyncedAt.IsZero(), synced)
+		}
+		if e.WorkspaceID == "ws-legacy" && (e.Profile != "" || e.State != "") {
+			t.Errorf("entrée sans profil modifiée : %+v", e)
+		}
+	}
+}
+
+func TestSyncEntriesAdopt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	for _, adopt := range []bool{false, true} {
+		registry := WorkspaceYAML{Workspace: []WorkspaceEntry{{WorkspaceID: "ws-legacy"}}}
+		synced := syncEntries(&registry, nil, "lab", adopt, now)
+		want := ""
+		if adopt {
+			want = "lab"
+		}
+		if got := registry.Workspace[0].Profile; got != want {
+			t.Errorf("adopt=%v : profil = %q, attendu %q", adopt, got, want)
+		}
+		if (len(synced) == 1) != adopt {
+			t.Errorf("adopt=%v : %d entrée(s) synchronisée(s)", adopt, len(synced))
+		}
+	}
+}
+
+func TestPruneDryRunLeavesRegistry(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := RecordWorkspace(WorkspaceEntry{WorkspaceID: "ws-gone", Profile: "lab"}); err != nil {
+		t.Fatal(err)
+	}
+	path, err := RegistryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Chemin de --dry-run dans PruneRegistry : calcul sur une copie lue, sans écriture.
+	registry, err := LoadRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed := pruneEntries(&registry, nil, "lab", time.Now()); len(removed) != 1 {
+		t.Fatalf("%d entrée(s) à supprimer, attendu 1", len(removed))
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("registre modifié par --dry-run :\n%s", after)
+	}
+}
+
+func TestUpdateRegistryConcurrent(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- RecordWorkspace(WorkspaceEntry{WorkspaceID: fmt.Sprintf("ws-%d", i), Profile: "lab"})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry, err := LoadRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.Workspace) != writers {
+		t.Errorf("%d entrée(s) après %d écritures concurrentes : des entrées ont été perdues", len(registry.Workspace), writers)
+	}
+	path, _ := RegistryPath()
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp-*"))
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) || len(leftovers) > 0 {
+		t.Errorf("verrou ou fichiers temporaires restants : %v %v", err, leftovers)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	for _, content := range []string{"v1\n", "v2\n"} {
+		if err := writeFileAtomic(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != content {
+			t.Errorf("contenu = %q (%v), attendu %q", got, err, content)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("permissions = %o, attendu 600", perm)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("%d fichier(s) dans le répertoire, attendu 1 (pas de fichier temporaire)", len(entries))
+	}
+}
+
+func TestLockFileStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.yaml")
+
+	// Verrou laissé par un processus disparu : repris.
+	if err := os.WriteFile(path+".lock", []byte("2147483647\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatalf("verrou d'un processus disparu non repris : %v", err)
+	}
+
+	// Verrou détenu par un processus vivant : refusé après l'attente.
+	if _, err := acquireLock(path+".lock", 0, 0); err == nil {
+		t.Error("verrou détenu accordé une seconde fois")
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("verrou non libéré : %v", err)
+	}
+}
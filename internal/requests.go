@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	inventory "github.com/aristanetworks/cloudvision-go/api/arista/inventory.v1"
+	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Ce fichier regroupe la construction typée des requêtes gRPC envoyées à CVaaS.
+// Les valeurs utilisateur (noms, descriptions, IDs) sont placées directement dans
+// des wrapperspb : aucun caractère spécial (guillemet, antislash, Unicode) ne peut
+// altérer la structure de la requête.
+
+// deviceStreamRequest construit la requête d'inventaire filtrée par modèle et
+// par fonctionnalité (MLAG ou DANZ). Sans filtre, la requête retourne tous les équipements.
+func deviceStreamRequest(model string, mlagFilter, danzFilter bool) *inventory.DeviceStreamRequest {
+	filter := &inventory.Device{}
+	empty := true
+	if model != "" {
+		filter.ModelName = wrapperspb.String(model)
+		empty = false
+	}
+	if mlagFilter {
+		filter.ExtendedAttributes = &inventory.ExtendedAttributes{
+			FeatureEnabled: map[string]bool{"mlag": true},
+		}
+		empty = false
+	} else if danzFilter {
+		filter.ExtendedAttributes = &inventory.ExtendedAttributes{
+			FeatureEnabled: map[string]bool{"danz": true},
+		}
+		empty = false
+	}
+
+	req := &inventory.DeviceStreamRequest{}
+	if !empty {
+		req.PartialEqFilter = []*inventory.Device{filter}
+	}
+	return req
+}
+
+// unrecognizedWorkspaceState est la valeur du filtre d'état "UNRECOGNIZED" (-1, hors
+// de l'énumération), conservée pour les scripts qui l'utilisent.
+const unrecognizedWorkspaceState = workspace.WorkspaceState(-1)
+
+// parseWorkspaceState convertit un nom d'état court (ex. "PENDING") ou complet
+// (ex. "WORKSPACE_STATE_PENDING") en valeur de l'énumération WorkspaceState.
+// "UNRECOGNIZED" est accepté et vaut -1.
+func parseWorkspaceState(stateName string) (workspace.WorkspaceState, error) {
+	name := strings.ToUpper(stateName)
+	if name == "UNRECOGNIZED" {
+		return unrecognizedWorkspaceState, nil
+	}
+	if !strings.HasPrefix(name, "WORKSPACE_STATE_") {
+		name = "WORKSPACE_STATE_" + name
+	}
+	value, ok := workspace.WorkspaceState_value[name]
+	if !ok {
+		return workspace.WorkspaceState_WORKSPACE_STATE_UNSPECIFIED, fmt.Errorf("état invalide : %s", stateName)
+	}
+	return workspace.WorkspaceState(value), nil
+}
+
+// workspaceStreamRequest construit la requête de listing des workspaces, filtrée
+// sur l'état donné. Un état vide ou "NONE" désactive le filtre.
+func workspaceStreamRequest(stateName string) (*workspace.WorkspaceStreamRequest, error) {
+	req := &workspace.WorkspaceStreamRequest{}
+	if stateName == "" || strings.ToUpper(stateName) == "NONE" {
+		return req, nil
+	}
+	state, err := parseWorkspaceState(stateName)
+	if err != nil {
+		return nil, err
+	}
+	req.PartialEqFilter = []*workspace.Workspace{{State: state}}
+	return req, nil
+}
+
+// workspaceConfigSetRequest construit la requête de création d'un workspace.
+// La description n'est transmise que si elle est renseignée.
+func workspaceConfigSetRequest(workspaceID, requestID, displayName, description string) *workspace.WorkspaceConfigSetRequest {
+	config := &workspace.WorkspaceConfig{
+		Key:           &workspace.WorkspaceKey{WorkspaceId: wrapperspb.String(workspaceID)},
+		DisplayName:   wrapperspb.String(displayName),
+		RequestParams: &workspace.RequestParams{RequestId: wrapperspb.String(requestID)},
+	}
+	if description != "" {
+		config.Description = wrapperspb.String(description)
+	}
+	return &workspace.WorkspaceConfigSetRequest{Value: config}
+}
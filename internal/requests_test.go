@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"testing"
+
+	workspace "github.com/aristanetworks/cloudvision-go/api/arista/workspace.v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// specialNames sont des valeurs utilisateur qui cassaient les requêtes construites
+// par formatage de JSON.
+var specialNames = []string{
+	"Ajout site Paris",
+	`guillemet " et antislash \`,
+	`", "injected": {"workspaceId": "autre"}, "x": "`,
+	"retour\nà la ligne\tet tabulation",
+	"Éléments accentués — 東京 🚀",
+	"",
+}
+
+// roundTrip encode msg en binaire puis en JSON (encodages utilisés vers CVaaS), le
+// décode dans une nouvelle instance de son type et vérifie que rien n'a été altéré.
+func roundTrip(t *testing.T, msg proto.Message) {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("encodage binaire : %v", err)
+	}
+	decoded := msg.ProtoReflect().New().Interface()
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("décodage binaire : %v", err)
+	}
+	if !proto.Equal(msg, decoded) {
+		t.Errorf("aller-retour binaire altéré : %v, attendu %v", decoded, msg)
+	}
+
+	data, err = protojson.Marshal(msg)
+	if err != nil {
+		t.Fatalf("encodage JSON : %v", err)
+	}
+	decoded = msg.ProtoReflect().New().Interface()
+	if err := protojson.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("décodage JSON de %s : %v", data, err)
+	}
+	if !proto.Equal(msg, decoded) {
+		t.Errorf("aller-retour JSON altéré : %s", data)
+	}
+}
+
+func TestWorkspaceConfigSetRequestRoundTrip(t *testing.T) {
+	for _, name := range specialNames {
+		for _, description := range []string{"", name} {
+			req := workspaceConfigSetRequest("ws-1", "req-1", name, description)
+			roundTrip(t, req)
+
+			data, err := protojson.Marshal(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded workspace.WorkspaceConfigSetRequest
+			if err := protojson.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			v := decoded.GetValue()
+			if got := v.GetDisplayName().GetValue(); got != name {
+				t.Errorf("displayName = %q, attendu %q", got, name)
+			}
+			if got := v.GetKey().GetWorkspaceId().GetValue(); got != "ws-1" {
+				t.Errorf("%q : workspaceId = %q, attendu ws-1", name, got)
+			}
+			if got := v.GetRequestParams().GetRequestId().GetValue(); got != "req-1" {
+				t.Errorf("%q : requestId = %q, attendu req-1", name, got)
+			}
+			if (v.GetDescription() != nil) != (description != "") {
+				t.Errorf("%q : description transmise = %v, attendu %v", description, v.GetDescription() != nil, description != "")
+			}
+			if got := v.GetDescription().GetValue(); got != description {
+				t.Errorf("description = %q, attendu %q", got, description)
+			}
+		}
+	}
+}
+
+func TestStreamRequestsRoundTrip(t *testing.T) {
+	for _, model := range specialNames {
+		roundTrip(t, deviceStreamRequest(model, true, false))
+	}
+	for _, state := range []string{"", "PENDING", "CONFLICTS", "UNRECOGNIZED"} {
+		req, err := workspaceStreamRequest(state)
+		if err != nil {
+			t.Fatalf("%q : %v", state, err)
+		}
+		roundTrip(t, req)
+	}
+}
+
+func TestDeviceStreamRequest(t *testing.T) {
+	tests := []struct {
+		model       string
+		mlag, danz  bool
+		wantFilter  bool
+		wantFeature string
+	}{
+		{"", false, false, false, ""},
+		{"cEOSLab", false, false, true, ""},
+		{`DCS-7280"SR\`, false, false, true, ""},
+		{"", true, false, true, "mlag"},
+		{"vEOS", false, true, true, "danz"},
+		// --mlag et --danz sont exclusifs : mlag l'emporte.
+		{"", true, true, true, "mlag"},
+	}
+	for _, tt := range tests {
+		req := deviceStreamRequest(tt.model, tt.mlag, tt.danz)
+		if got := len(req.GetPartialEqFilter()) == 1; got != tt.wantFilter {
+			t.Errorf("%+v : filtre = %v, attendu %v", tt, got, tt.wantFilter)
+			continue
+		}
+		if !tt.wantFilter {
+			continue
+		}
+		filter := req.GetPartialEqFilter()[0]
+		if got := filter.GetModelName().GetValue(); got != tt.model {
+			t.Errorf("%+v : modèle = %q", tt, got)
+		}
+		features := filter.GetExtendedAttributes().GetFeatureEnabled()
+		if tt.wantFeature == "" && len(features) > 0 || tt.wantFeature != "" && (len(features) != 1 || !features[tt.wantFeature]) {
+			t.Errorf("%+v : fonctionnalités = %v", tt, features)
+		}
+	}
+}
+
+func TestWorkspaceStreamRequest(t *testing.T) {
+	tests := []struct {
+		state   string
+		want    workspace.WorkspaceState
+		filter  bool
+		wantErr bool
+	}{
+		{"", 0, false, false},
+		{"none", 0, false, false},
+		{"PENDING", workspace.WorkspaceState_WORKSPACE_STATE_PENDING, true, false},
+		{"submitted", workspace.WorkspaceState_WORKSPACE_STATE_SUBMITTED, true, false},
+		{"WORKSPACE_STATE_ABANDONED", workspace.WorkspaceState_WORKSPACE_STATE_ABANDONED, true, false},
+		{"unspecified", workspace.WorkspaceState_WORKSPACE_STATE_UNSPECIFIED, true, false},
+		{"UNRECOGNIZED", workspace.WorkspaceState(-1), true, false},
+		{"unknown", 0, false, true},
+		{`PENDING" }, {"state": "SUBMITTED`, 0, false, true},
+	}
+	for _, tt := range tests {
+		req, err := workspaceStreamRequest(tt.state)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q : erreur = %v, attendu %v", tt.state, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		filters := req.GetPartialEqFilter()
+		if got := len(filters) == 1; got != tt.filter {
+			t.Errorf("%q : filtre = %v, attendu %v", tt.state, got, tt.filter)
+			continue
+		}
+		if tt.filter && filters[0].GetState() != tt.want {
+			t.Errorf("%q : état = %s, attendu %s", tt.state, filters[0].GetState(), tt.want)
+		}
+	}
+}
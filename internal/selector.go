@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchHostname indique si hostname correspond à l'un des motifs glob d'une liste
+// séparée par des virgules (ex : "leaf-*,spine-1"). Une liste vide correspond à tout.
+func MatchHostname(patterns, hostname string) bool {
+	if strings.TrimSpace(patterns) == "" {
+		return true
+	}
+	for _, p := range strings.Split(patterns, ",") {
+		if ok, _ := path.Match(strings.TrimSpace(p), hostname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectDevices retourne les équipements dont le hostname correspond aux motifs donnés.
+func SelectDevices(devices []DeviceInfo, patterns string) []DeviceInfo {
+	var selected []DeviceInfo
+	for _, d := range devices {
+		if MatchHostname(patterns, d.Hostname) {
+			selected = append(selected, d)
+		}
+	}
+	return selected
+}
+
+// InterfaceSelector associe un motif glob de hostname à un nom d'interface
+// (ex : "leaf-*:Ethernet50/1").
+type InterfaceSelector struct {
+	Devices   string
+	Interface string
+}
+
+// ParseInterfaceSelectors découpe une liste de sélecteurs "hostname:interface" séparés
+// par des virgules (ex : "leaf-1:Ethernet49/1,leaf-*:Ethernet50/1").
+func ParseInterfaceSelectors(spec string) ([]InterfaceSelector, error) {
+	var selectors []InterfaceSelector
+	for _, item := range strings.Split(spec, ",") {
+		host, intf, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || host == "" || intf == "" {
+			return nil, fmt.Errorf("sélecteur d'interface invalide : %q (attendu hostname:interface)", item)
+		}
+		selectors = append(selectors, InterfaceSelector{Devices: host, Interface: intf})
+	}
+	return selectors, nil
+}
+
+// SelectInterfaces retourne les interfaces désignées par les sélecteurs sur les
+// équipements donnés, sans doublon et dans l'ordre des équipements. interfaces liste
+// les interfaces de chaque équipement par deviceId (voir DeviceInterfaces) : une
+// interface désignée qui n'existe pas sur l'équipement est retournée dans missing.
+func SelectInterfaces(devices []DeviceInfo, selectors []InterfaceSelector, interfaces map[string]map[string]bool) (targets, missing []TagTarget) {
+	seen := map[string]bool{}
+	for _, d := range devices {
+		for _, s := range selectors {
+			if !MatchHostname(s.Devices, d.Hostname) || seen[d.DeviceID+"|"+s.Interface] {
+				continue
+			}
+			seen[d.DeviceID+"|"+s.Interface] = true
+			target := TagTarget{DeviceID: d.DeviceID, Hostname: d.Hostname, InterfaceID: s.Interface}
+			if !interfaces[d.DeviceID][s.Interface] {
+				missing = append(missing, target)
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets, missing
+}
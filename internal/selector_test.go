@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectDevices(t *testing.T) {
+	devices := []DeviceInfo{
+		{DeviceID: "SN1", Hostname: "leaf-1"},
+		{DeviceID: "SN2", Hostname: "leaf-2"},
+		{DeviceID: "SN3", Hostname: "spine-1"},
+		{DeviceID: "SN4", Hostname: "border-leaf-1"},
+	}
+	tests := []struct {
+		name     string
+		patterns string
+		want     []string
+	}{
+		{"liste vide", "", []string{"SN1", "SN2", "SN3", "SN4"}},
+		{"espaces seuls", "  ", []string{"SN1", "SN2", "SN3", "SN4"}},
+		{"nom exact", "spine-1", []string{"SN3"}},
+		{"motif ancré au début", "leaf-*", []string{"SN1", "SN2"}},
+		{"classe de caractères", "leaf-[2-9]", []string{"SN2"}},
+		{"liste avec espaces", "leaf-1, spine-*", []string{"SN1", "SN3"}},
+		{"motifs redondants", "leaf-1,leaf-*", []string{"SN1", "SN2"}},
+		{"aucune correspondance", "core-*", nil},
+		// Un motif invalide ne correspond à rien.
+		{"motif invalide", "leaf-[", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, d := range SelectDevices(devices, tt.patterns) {
+			got = append(got, d.DeviceID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : %q sélectionne %v, attendu %v", tt.name, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestParseInterfaceSelectors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []InterfaceSelector
+		wantErr bool
+	}{
+		{"leaf-1:Ethernet1", []InterfaceSelector{{"leaf-1", "Ethernet1"}}, false},
+		{"leaf-1:Ethernet49/1, leaf-*:Ethernet50/1", []InterfaceSelector{{"leaf-1", "Ethernet49/1"}, {"leaf-*", "Ethernet50/1"}}, false},
+		// Seul le premier ":" sépare le hostname de l'interface.
+		{"leaf-1:Management1:1", []InterfaceSelector{{"leaf-1", "Management1:1"}}, false},
+		{"", nil, true},
+		{"leaf-1", nil, true},
+		{":Ethernet1", nil, true},
+		{"leaf-1:", nil, true},
+		{"leaf-1:Ethernet1,", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseInterfaceSelectors(tt.spec)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q : %v (%v), attendu %v (erreur : %v)", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSelectInterfaces(t *testing.T) {
+	devices := []DeviceInfo{
+		{DeviceID: "SN1", Hostname: "leaf-1"},
+		{DeviceID: "SN2", Hostname: "leaf-2"},
+		{DeviceID: "SN3", Hostname: "spine-1"},
+	}
+	interfaces := map[string]map[string]bool{
+		"SN1": {"Ethernet49/1": true, "Ethernet50/1": true},
+		"SN2": {"Ethernet49/1": true},
+		"SN3": {"Ethernet1": true},
+	}
+	names := func(targets []TagTarget) []string {
+		var out []string
+		for _, t := range targets {
+			out = append(out, t.String())
+		}
+		return out
+	}
+	tests := []struct {
+		name        string
+		selectors   []InterfaceSelector
+		want        []string
+		wantMissing []string
+	}{
+		{"interface existante", []InterfaceSelector{{"leaf-1", "Ethernet49/1"}}, []string{"leaf-1:Ethernet49/1"}, nil},
+		{"motif sur plusieurs devices", []InterfaceSelector{{"leaf-*", "Ethernet49/1"}}, []string{"leaf-1:Ethernet49/1", "leaf-2:Ethernet49/1"}, nil},
+		{"doublons fusionnés", []InterfaceSelector{{"leaf-1", "Ethernet50/1"}, {"leaf-*", "Ethernet50/1"}}, []string{"leaf-1:Ethernet50/1"}, []string{"leaf-2:Ethernet50/1"}},
+		{"interface inexistante", []InterfaceSelector{{"spine-1", "Ethernet49/1"}}, nil, []string{"spine-1:Ethernet49/1"}},
+		{"aucun device", []InterfaceSelector{{"border-*", "Ethernet1"}}, nil, nil},
+	}
+	for _, tt := range tests {
+		targets, missing := SelectInterfaces(devices, tt.selectors, interfaces)
+		if got := names(targets); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : cibles = %v, attendu %v", tt.name, got, tt.want)
+		}
+		if got := names(missing); !reflect.DeepEqual(got, tt.wantMissing) {
+			t.Errorf("%s : absentes = %v, attendu %v", tt.name, got, tt.wantMissing)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"30d", now.AddDate(0, 0, -30), false},
+		{"2w", now.AddDate(0, 0, -14), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{" 1d ", now.AddDate(0, 0, -1), false},
+		{"0d", now, false},
+		{"2025-06-01", time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), false},
+		{"2025-06-01T08:30:00Z", time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC), false},
+		{"-3d", time.Time{}, true},
+		{"-1h", time.Time{}, true},
+		{"3 jours", time.Time{}, true},
+		{"d", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) : erreur = %v, attendu %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %s, attendu %s", tt.in, got, tt.want)
+		}
+	}
+}
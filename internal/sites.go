@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SiteRow est une ligne du fichier CSV de création de workspaces en masse.
+type SiteRow struct {
+	Line        int
+	Name        string
+	Description string
+	// Tags contient les tags à créer et assigner, au format label=value.
+	Tags []TagChange
+	// Devices sélectionne les équipements auxquels assigner les tags (motifs de hostname).
+	Devices string
+	// Query restreint les équipements à ceux satisfaisant une requête de tags (voir TagQuery).
+	Query string
+}
+
+// siteColumns sont les colonnes reconnues dans l'en-tête du CSV ; seule "name" est obligatoire.
+var siteColumns = map[string]bool{"name": true, "description": true, "tags": true, "devices": true, "query": true}
+
+// ReadSitesCSV lit un fichier CSV de création de workspaces. La première ligne est un
+// en-tête nommant les colonnes : name (obligatoire), description, tags (paires
+// label=value séparées par des ";"), devices (motifs de hostname séparés par des ",")
+// et query (requête de tags).
+func ReadSitesCSV(path string) ([]SiteRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s : en-tête illisible : %w", path, err)
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if !siteColumns[h] {
+			return nil, fmt.Errorf("%s : colonne inconnue %q", path, h)
+		}
+		columns[h] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%s : colonne name manquante", path)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []SiteRow
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s : %w", path, err)
+		}
+		row := SiteRow{
+			Line:        line,
+			Name:        field(record, "name"),
+			Description: field(record, "description"),
+			Devices:     field(record, "devices"),
+			Query:       field(record, "query"),
+		}
+		if row.Name == "" {
+			return nil, fmt.Errorf("%s:%d : nom de workspace vide", path, line)
+		}
+		if row.Query != "" {
+			if _, err := ParseTagQuery(row.Query); err != nil {
+				return nil, fmt.Errorf("%s:%d : %w", path, line, err)
+			}
+		}
+		for _, pair := range strings.Split(field(record, "tags"), ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			label, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || label == "" || value == "" {
+				return nil, fmt.Errorf("%s:%d : tag invalide %q (attendu label=value)", path, line, pair)
+			}
+			row.Tags = append(row.Tags, TagChange{Action: ChangeAdd, Label: label, Value: value, ElementType: "device"})
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Changes retourne les modifications à appliquer dans le workspace d'une ligne :
+// la création de ses tags et leur assignation aux équipements sélectionnés. tags
+// contient les tags des équipements (voir MainlineDeviceTags), nécessaires si la
+// ligne a une requête.
+func (r SiteRow) Changes(devices []DeviceInfo, tags map[string]map[string][]string) (WorkspaceChanges, error) {
+	changes := WorkspaceChanges{Tags: r.Tags}
+	if len(r.Tags) == 0 || (r.Devices == "" && r.Query == "") {
+		return changes, nil
+	}
+	selected, err := selectTemplateDevices(devices, tags, r.Devices, r.Query)
+	if err != nil {
+		return changes, err
+	}
+	for _, t := range r.Tags {
+		for _, d := range selected {
+			changes.TagAssignments = append(changes.TagAssignments, TagAssignmentChange{
+				Action:      ChangeAdd,
+				Label:       t.Label,
+				Value:       t.Value,
+				ElementType: t.ElementType,
+				DeviceID:    d.DeviceID,
+				Hostname:    d.Hostname,
+			})
+		}
+	}
+	return changes, nil
+}
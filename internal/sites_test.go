@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSitesCSV(t *testing.T) {
+	paris := TagChange{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device"}
+	leaf := TagChange{Action: ChangeAdd, Label: "role", Value: "leaf", ElementType: "device"}
+	tests := []struct {
+		name    string
+		csv     string
+		want    []SiteRow
+		wantErr string
+	}{
+		{
+			name: "toutes les colonnes",
+			csv:  "name,description,tags,devices,query\nParis,Site de Paris, site=Paris;role=leaf ,paris-*,model:DCS-7280SR\n",
+			want: []SiteRow{{Line: 2, Name: "Paris", Description: "Site de Paris", Tags: []TagChange{paris, leaf}, Devices: "paris-*", Query: "model:DCS-7280SR"}},
+		},
+		{
+			name: "colonnes dans le désordre, en-tête en majuscules",
+			csv:  "Tags,NAME\n\"site=Paris;\",Paris\n,Lyon\n",
+			want: []SiteRow{{Line: 2, Name: "Paris", Tags: []TagChange{paris}}, {Line: 3, Name: "Lyon"}},
+		},
+		{name: "en-tête seul", csv: "name\n", want: nil},
+		{name: "fichier vide", csv: "", wantErr: "en-tête illisible"},
+		{name: "colonne inconnue", csv: "name,site\nParis,Paris\n", wantErr: `colonne inconnue "site"`},
+		{name: "colonne name manquante", csv: "description\nSite\n", wantErr: "colonne name manquante"},
+		{name: "nom vide", csv: "name,tags\nParis,\n ,site=Lyon\n", wantErr: ":3 : nom de workspace vide"},
+		{name: "tag sans valeur", csv: "name,tags\nParis,site=\n", wantErr: `:2 : tag invalide "site="`},
+		{name: "tag sans signe égal", csv: "name,tags\nParis,site\n", wantErr: "tag invalide"},
+		{name: "requête invalide", csv: "name,query\nParis,site:Paris AND\n", wantErr: "sites.csv:2"},
+		{name: "nombre de champs incohérent", csv: "name,tags\nParis,site=Paris,x\n", wantErr: "wrong number of fields"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "sites.csv")
+		if err := os.WriteFile(path, []byte(tt.csv), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := ReadSitesCSV(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s : erreur = %v, attendu %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s : %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, tt.want) {
+			t.Errorf("%s : lignes = %+v, attendu %+v", tt.name, rows, tt.want)
+		}
+	}
+}
+
+func TestSiteRowChanges(t *testing.T) {
+	devices := []DeviceInfo{{DeviceID: "SN1", Hostname: "paris-leaf-1"}, {DeviceID: "SN2", Hostname: "lyon-leaf-1"}}
+	paris := TagChange{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device"}
+
+	changes, err := SiteRow{Name: "Paris", Tags: []TagChange{paris}, Devices: "paris-*"}.Changes(devices, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TagAssignmentChange{{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device", DeviceID: "SN1", Hostname: "paris-leaf-1"}}
+	if !reflect.DeepEqual(changes.TagAssignments, want) {
+		t.Errorf("assignations = %+v, attendu %+v", changes.TagAssignments, want)
+	}
+
+	// Sans sélecteur, les tags sont créés sans être assignés.
+	changes, err = SiteRow{Name: "Paris", Tags: []TagChange{paris}}.Changes(devices, nil)
+	if err != nil || len(changes.Tags) != 1 || len(changes.TagAssignments) != 0 {
+		t.Errorf("sans sélecteur : %+v (%v)", changes, err)
+	}
+}
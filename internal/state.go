@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// stateAppDir est le nom du sous-dossier propre à la CLI dans le répertoire d'état XDG.
+const stateAppDir = "cvaas-cli"
+
+// lockTimeout borne l'attente d'un verrou sur un fichier d'état partagé
+// entre plusieurs invocations concurrentes de la CLI.
+const lockTimeout = 10 * time.Second
+
+// StateDir retourne le répertoire d'état de la CLI, selon la spécification XDG :
+// `$XDG_STATE_HOME/cvaas-cli`, ou `~/.local/state/cvaas-cli` si la variable n'est pas définie.
+//
+// Le répertoire est créé s'il n'existe pas.
+func StateDir() (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("répertoire personnel introuvable : %w", err)
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	dir := filepath.Join(base, stateAppDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("création de %s : %w", dir, err)
+	}
+	return dir, nil
+}
+
+// newStateID retourne un identifiant horodaté pour un fichier d'état (run, opération),
+// triable par date : 20250601-100000-3f1c.
+func newStateID(t time.Time) string {
+	return t.Format("20060102-150405") + "-" + NewUUID()[:4]
+}
+
+// writeFileAtomic écrit data dans path via un fichier temporaire renommé ensuite,
+// afin qu'un lecteur ne voie jamais un fichier partiellement écrit.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockFile pose un verrou exclusif sur path en créant `path.lock`, et retourne la
+// fonction de libération. L'attente est bornée par lockTimeout ; un verrou laissé par
+// un processus disparu (crash, interruption) ou plus vieux que lockStaleAge est repris.
+func lockFile(path string) (func(), error) {
+	return acquireLock(path+".lock", lockTimeout, lockStaleAge)
+}
+
+// lockStaleAge est l'âge au-delà duquel un verrou de fichier d'état est considéré
+// abandonné : ces verrous ne sont détenus que le temps d'une réécriture.
+const lockStaleAge = time.Minute
+
+// acquireLock crée le fichier de verrou lockPath, contenant le PID du processus, et
+// retourne la fonction de libération.
+//
+// Paramètres :
+//   - lockPath : chemin du fichier de verrou
+//   - wait : durée maximale d'attente d'un verrou détenu (zéro : échec immédiat)
+//   - maxAge : âge au-delà duquel un verrou est repris même si son processus semble
+//     vivant (zéro : jamais)
+//
+// Retourne :
+//   - error : si le verrou est toujours détenu après wait, ou si sa création échoue.
+func acquireLock(lockPath string, wait, maxAge time.Duration) (func(), error) {
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if reclaimStaleLock(lockPath, maxAge) {
+			continue
+		}
+		if !time.Now().Before(deadline) {
+			if pid := lockHolder(lockPath); pid > 0 {
+				return nil, fmt.Errorf("verrou %s détenu par le processus %d", lockPath, pid)
+			}
+			return nil, fmt.Errorf("verrou %s toujours détenu après %s", lockPath, wait)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// lockHolder retourne le PID inscrit dans un fichier de verrou, ou 0 s'il est illisible.
+func lockHolder(lockPath string) int {
+	content, err := os.ReadFile(lockPath)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// reclaimStaleLock supprime le verrou lockPath si le processus qui l'a posé n'existe
+// plus, ou s'il est plus vieux que maxAge (zéro : pas de limite d'âge).
+//
+// Retourne :
+//   - bool : true si le verrou a été supprimé (ou a disparu entre-temps).
+func reclaimStaleLock(lockPath string, maxAge time.Duration) bool {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	pid := lockHolder(lockPath)
+	stale := pid > 0 && !processAlive(pid)
+	if maxAge > 0 && time.Since(info.ModTime()) > maxAge {
+		stale = true
+	}
+	if !stale {
+		return false
+	}
+	// Le verrou a pu être libéré puis repris entre-temps : seul celui qui a été
+	// examiné est supprimé.
+	if lockHolder(lockPath) != pid {
+		return false
+	}
+	err = os.Remove(lockPath)
+	return err == nil || os.IsNotExist(err)
+}
+
+// processAlive indique si le processus pid existe encore. Un processus appartenant à
+// un autre utilisateur (EPERM) est considéré vivant.
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,356 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// tagAssignmentBatchSize est le nombre maximal d'assignations écrites par appel SetSome.
+const tagAssignmentBatchSize = 100
+
+// TagTarget désigne l'élément auquel un tag est assigné : un device, ou une
+// interface d'un device si InterfaceID est renseigné.
+type TagTarget struct {
+	DeviceID    string `json:"deviceId"`
+	Hostname    string `json:"hostname"`
+	InterfaceID string `json:"interfaceId,omitempty"`
+}
+
+// String retourne "hostname" ou "hostname:interface".
+func (t TagTarget) String() string {
+	if t.InterfaceID != "" {
+		return t.Hostname + ":" + t.InterfaceID
+	}
+	return t.Hostname
+}
+
+// AssignmentResult est le résultat de l'écriture d'une assignation de tag pour un élément.
+type AssignmentResult struct {
+	TagTarget
+	Error string `json:"error,omitempty"`
+}
+
+// TagAssignmentInfo contient une assignation de tag retournée par le TagAssignmentService.
+type TagAssignmentInfo struct {
+	Label          string `json:"label"`
+	Value          string `json:"value"`
+	ElementType    string `json:"elementType"`
+	ElementSubType string `json:"elementSubType,omitempty"`
+	DeviceID       string `json:"deviceId"`
+	InterfaceID    string `json:"interfaceId,omitempty"`
+	CreatorType    string `json:"creatorType"`
+}
+
+// GetTagAssignments retourne les assignations de tags visibles dans un workspace
+// ("" pour mainline), filtrées par type d'élément et éventuellement par label.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour l'appel gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace à lire, "" pour mainline
+//   - label : label à filtrer (ignoré si vide)
+//   - elementType : "device" ou "interface"
+//
+// Panique :
+//   - Si le type d'élément est invalide ou si le streaming gRPC échoue.
+func GetTagAssignments(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, elementType string) []TagAssignmentInfo {
+	et, err := parseElementType(elementType)
+	if err != nil {
+		panic(fmt.Sprintf("❌ %v", err))
+	}
+	filter := &tag.TagAssignment{Key: &tag.TagAssignmentKey{WorkspaceId: wrapperspb.String(workspaceID), ElementType: et}}
+	if label != "" {
+		filter.Key.Label = wrapperspb.String(label)
+	}
+
+	client := tag.NewTagAssignmentServiceClient(conn)
+	stream, err := client.GetAll(ctx, &tag.TagAssignmentStreamRequest{PartialEqFilter: []*tag.TagAssignment{filter}})
+	if err != nil {
+		panic(fmt.Sprintf("❌ Erreur stream assignations de tags : %v", err))
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		panic(fmt.Sprintf("❌ Erreur lecture assignations de tags : %v", err))
+	}
+	assignments := make([]TagAssignmentInfo, 0, len(resps))
+	for _, r := range resps {
+		key := r.GetValue().GetKey()
+		assignments = append(assignments, TagAssignmentInfo{
+			Label:          key.GetLabel().GetValue(),
+			Value:          key.GetValue().GetValue(),
+			ElementType:    elementTypeName(key.GetElementType()),
+			ElementSubType: elementSubTypeName(key.GetElementSubType()),
+			DeviceID:       key.GetDeviceId().GetValue(),
+			InterfaceID:    key.GetInterfaceId().GetValue(),
+			CreatorType:    creatorTypeName(r.GetValue().GetTagCreatorType()),
+		})
+	}
+	return assignments
+}
+
+// DeviceInterfaces retourne les interfaces connues de chaque équipement, par deviceId.
+// CloudVision assigne des tags système à chaque interface remontée par un équipement :
+// les interfaces sont celles des assignations de tags d'interface sur mainline.
+func DeviceInterfaces(ctx context.Context, conn *grpc.ClientConn) (map[string]map[string]bool, error) {
+	client := tag.NewTagAssignmentServiceClient(conn)
+	stream, err := client.GetAll(ctx, &tag.TagAssignmentStreamRequest{PartialEqFilter: []*tag.TagAssignment{{Key: &tag.TagAssignmentKey{
+		WorkspaceId: wrapperspb.String(""),
+		ElementType: tag.ElementType_ELEMENT_TYPE_INTERFACE,
+	}}}})
+	if err != nil {
+		return nil, fmt.Errorf("lecture des interfaces : %w", err)
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return nil, fmt.Errorf("lecture des interfaces : %w", err)
+	}
+	interfaces := map[string]map[string]bool{}
+	for _, r := range resps {
+		key := r.GetValue().GetKey()
+		device := key.GetDeviceId().GetValue()
+		if interfaces[device] == nil {
+			interfaces[device] = map[string]bool{}
+		}
+		interfaces[device][key.GetInterfaceId().GetValue()] = true
+	}
+	return interfaces, nil
+}
+
+// DeviceTargets convertit une liste d'équipements en cibles d'assignation de device.
+func DeviceTargets(devices []DeviceInfo) []TagTarget {
+	targets := make([]TagTarget, 0, len(devices))
+	for _, d := range devices {
+		targets = append(targets, TagTarget{DeviceID: d.DeviceID, Hostname: d.Hostname})
+	}
+	return targets
+}
+
+// ParseTag découpe un tag au format "label=value".
+func ParseTag(s string) (label, value string, err error) {
+	label, value, ok := strings.Cut(s, "=")
+	label, value = strings.TrimSpace(label), strings.TrimSpace(value)
+	if !ok || label == "" || value == "" {
+		return "", "", fmt.Errorf("tag invalide : %q (attendu label=value)", s)
+	}
+	return label, value, nil
+}
+
+// SetTagAssignments assigne (ou retire, avec remove) le tag label=value aux cibles
+// données dans un workspace. Les écritures sont regroupées en lots via SetSome.
+//
+// Un retrait est écrit comme une assignation marquée `remove` : c'est ce qui retire
+// l'assignation de mainline à la soumission. DeleteSome ne ferait qu'annuler une
+// modification en attente dans le workspace.
+//
+// Les assignations écrites sont enregistrées dans le journal des opérations, avec
+// leur état antérieur sur mainline (voir StartOperation). Leur annulation (voir
+// Compensator) restaure celles qui étaient déjà écrites dans le workspace.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace dans lequel les assignations sont écrites
+//   - label, value : le tag à assigner
+//   - elementType : "device" ou "interface"
+//   - elementSubType : sous-type d'un tag d'interface ("external"), "" pour aucun
+//   - targets : éléments à tagger
+//   - remove : true pour retirer l'assignation
+//
+// Retourne :
+//   - []AssignmentResult : un résultat par cible, dans l'ordre des cibles.
+//   - error : si le type d'élément est invalide ou si l'état antérieur du workspace ne
+//     peut être lu ; les erreurs gRPC d'écriture sont reportées par cible.
+func SetTagAssignments(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, value, elementType, elementSubType string, targets []TagTarget, remove bool) ([]AssignmentResult, error) {
+	results, err := SetTagAssignmentGroups(ctx, conn, workspaceID, []TagAssignmentGroup{{
+		Label: label, Value: value, ElementType: elementType, ElementSubType: elementSubType, Targets: targets,
+	}}, remove)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SetTagAssignmentGroups est la variante de SetTagAssignments pour plusieurs tags : les
+// assignations de tous les groupes sont écrites dans les mêmes lots SetSome, quel que
+// soit leur tag.
+//
+// Retourne :
+//   - [][]AssignmentResult : les résultats de chaque groupe, dans l'ordre de ses cibles.
+//   - error : si un type d'élément est invalide ou si l'état antérieur du workspace ne
+//     peut être lu ; les erreurs gRPC d'écriture sont reportées par cible.
+func SetTagAssignmentGroups(ctx context.Context, conn *grpc.ClientConn, workspaceID string, groups []TagAssignmentGroup, remove bool) ([][]AssignmentResult, error) {
+	client := tag.NewTagAssignmentConfigServiceClient(conn)
+	var changes WorkspaceChanges
+	var keys []*tag.TagAssignmentKey
+	for _, g := range groups {
+		et, st, err := parseElementKind(g.ElementType, g.ElementSubType)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%s : %w", g.Label, g.Value, err)
+		}
+		for _, t := range g.Targets {
+			changes.TagAssignments = append(changes.TagAssignments, TagAssignmentChange{
+				Action: actionFor(remove, false), Label: g.Label, Value: g.Value, ElementType: elementTypeName(et),
+				ElementSubType: elementSubTypeName(st), DeviceID: t.DeviceID, Hostname: t.Hostname, InterfaceID: t.InterfaceID,
+			})
+			keys = append(keys, tagAssignmentKey(workspaceID, g.Label, g.Value, et, st, t.DeviceID, t.InterfaceID))
+		}
+	}
+	prior, err := priorConfigs(ctx, workspaceID, func() (func() (*tag.TagAssignmentConfigStreamResponse, error), error) {
+		stream, err := client.GetAll(ctx, &tag.TagAssignmentConfigStreamRequest{
+			PartialEqFilter: []*tag.TagAssignmentConfig{{Key: &tag.TagAssignmentKey{WorkspaceId: wrapperspb.String(workspaceID)}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return stream.Recv, nil
+	}, func(c *tag.TagAssignmentConfig) string { return tagAssignmentConfigID(c.GetKey()) })
+	if err != nil {
+		return nil, fmt.Errorf("lecture de l'état antérieur : %w", err)
+	}
+	write := prepareWrite(ctx, conn, workspaceID, changes)
+
+	// Les résultats sont indexés dans l'ordre des clés, tous groupes confondus.
+	results := make([]AssignmentResult, len(keys))
+	targets := make([]TagTarget, 0, len(keys))
+	for _, g := range groups {
+		targets = append(targets, g.Targets...)
+	}
+	for start := 0; start < len(keys); start += tagAssignmentBatchSize {
+		end := min(start+tagAssignmentBatchSize, len(keys))
+		batch := make([]*tag.TagAssignmentConfig, 0, end-start)
+		pending := map[string]int{}
+		for i := start; i < end; i++ {
+			results[i] = AssignmentResult{TagTarget: targets[i]}
+			pending[tagAssignmentConfigID(keys[i])] = i
+			batch = append(batch, &tag.TagAssignmentConfig{Key: keys[i], Remove: wrapperspb.Bool(remove)})
+		}
+		if err := setSomeAssignments(ctx, client, batch, results, pending); err != nil {
+			for _, i := range pending {
+				results[i].Error = err.Error()
+			}
+		}
+	}
+	write.record(func(_ string, i int) bool { return results[i].Error == "" })
+
+	grouped := make([][]AssignmentResult, len(groups))
+	offset := 0
+	for gi, g := range groups {
+		grouped[gi] = results[offset : offset+len(g.Targets)]
+		var written []*tag.TagAssignmentKey
+		var restored []*tag.TagAssignmentConfig
+		for i, r := range grouped[gi] {
+			if r.Error == "" {
+				key := keys[offset+i]
+				written = append(written, key)
+				if p, ok := prior[tagAssignmentConfigID(key)]; ok {
+					restored = append(restored, p)
+				}
+			}
+		}
+		offset += len(g.Targets)
+		if len(written) == 0 {
+			continue
+		}
+		operation := "assignation"
+		if remove {
+			operation = "désassignation"
+		}
+		registerUndo(ctx, workspaceID, fmt.Sprintf("%s de %s=%s (%d cible(s))", operation, g.Label, g.Value, len(written)), false, func(ctx context.Context) error {
+			if err := deleteAssignmentConfigs(ctx, client, written); err != nil {
+				return err
+			}
+			// Les assignations déjà écrites dans le workspace avant la commande sont restaurées.
+			for _, p := range restored {
+				if _, err := client.Set(ctx, &tag.TagAssignmentConfigSetRequest{Value: p}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return grouped, nil
+}
+
+// deleteAssignmentConfigs supprime des assignations en attente dans un workspace, par
+// lots via DeleteSome, ce qui annule leur écriture.
+func deleteAssignmentConfigs(ctx context.Context, client tag.TagAssignmentConfigServiceClient, keys []*tag.TagAssignmentKey) error {
+	for start := 0; start < len(keys); start += tagAssignmentBatchSize {
+		end := min(start+tagAssignmentBatchSize, len(keys))
+		stream, err := client.DeleteSome(ctx, &tag.TagAssignmentConfigDeleteSomeRequest{Keys: keys[start:end]})
+		if err != nil {
+			return err
+		}
+		responses, err := collect(stream.Recv)
+		if err != nil {
+			return err
+		}
+		for _, res := range responses {
+			if res.GetError() != "" {
+				return fmt.Errorf("%s : %s", res.GetKey().GetDeviceId().GetValue(), res.GetError())
+			}
+		}
+	}
+	return nil
+}
+
+// setSomeAssignments écrit un lot d'assignations et reporte les erreurs par cible.
+// Les cibles confirmées sont retirées de pending ; l'erreur retournée concerne le
+// flux lui-même et s'applique aux cibles restantes.
+func setSomeAssignments(ctx context.Context, client tag.TagAssignmentConfigServiceClient, batch []*tag.TagAssignmentConfig, results []AssignmentResult, pending map[string]int) error {
+	stream, err := client.SetSome(ctx, &tag.TagAssignmentConfigSetSomeRequest{Values: batch})
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		id := tagAssignmentConfigID(res.GetKey())
+		i, ok := pending[id]
+		if !ok {
+			continue
+		}
+		results[i].Error = res.GetError()
+		delete(pending, id)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("aucune confirmation reçue")
+	}
+	return nil
+}
+
+// tagAssignmentConfigID identifie une assignation indépendamment de son workspace
+// (voir priorConfigs).
+func tagAssignmentConfigID(k *tag.TagAssignmentKey) string {
+	return strings.Join([]string{
+		k.GetElementType().String(), k.GetElementSubType().String(), k.GetLabel().GetValue() + "=" + k.GetValue().GetValue(),
+		k.GetDeviceId().GetValue(), k.GetInterfaceId().GetValue(),
+	}, "|")
+}
+
+// tagAssignmentKey construit la clé tag.v2 d'une assignation de tag à un device, ou à
+// une interface d'un device si interfaceID n'est pas vide.
+func tagAssignmentKey(workspaceID, label, value string, elementType tag.ElementType, subType tag.ElementSubType, deviceID, interfaceID string) *tag.TagAssignmentKey {
+	key := &tag.TagAssignmentKey{
+		WorkspaceId:    wrapperspb.String(workspaceID),
+		ElementType:    elementType,
+		ElementSubType: subType,
+		Label:          wrapperspb.String(label),
+		Value:          wrapperspb.String(value),
+		DeviceId:       wrapperspb.String(deviceID),
+	}
+	if interfaceID != "" {
+		key.InterfaceId = wrapperspb.String(interfaceID)
+	}
+	return key
+}
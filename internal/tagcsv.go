@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// tagCSVHeader est l'en-tête des fichiers CSV de tags. Le serial est l'ID du device
+// dans CloudVision ; interface et elementSubType ne sont renseignés que pour les tags
+// d'interface.
+var tagCSVHeader = []string{"hostname", "serial", "label", "value", "elementType", "interface", "elementSubType"}
+
+// TagCSVRow est une ligne d'un fichier CSV de tags.
+type TagCSVRow struct {
+	Line        int    `json:"line"`
+	Hostname    string `json:"hostname"`
+	Serial      string `json:"serial"`
+	Label       string `json:"label"`
+	Value       string `json:"value"`
+	ElementType string `json:"elementType"`
+	Interface   string `json:"interface,omitempty"`
+	// ElementSubType est le sous-type tag.v2 d'un tag d'interface (ex : "external").
+	ElementSubType string `json:"elementSubType,omitempty"`
+}
+
+// String retourne la position et le contenu d'une ligne, pour les rapports.
+func (r TagCSVRow) String() string {
+	target := r.Hostname
+	if target == "" {
+		target = r.Serial
+	}
+	if r.Interface != "" {
+		target += ":" + r.Interface
+	}
+	return fmt.Sprintf("ligne %d : %s=%s → %s", r.Line, r.Label, r.Value, target)
+}
+
+// UserAssignments retourne les assignations créées par un utilisateur. Les tags
+// système (créés par CloudVision) ne sont ni exportés ni réimportables.
+func UserAssignments(assignments []TagAssignmentInfo) []TagAssignmentInfo {
+	var user []TagAssignmentInfo
+	for _, a := range assignments {
+		if a.CreatorType == "user" {
+			user = append(user, a)
+		}
+	}
+	return user
+}
+
+// WriteTagsCSV écrit des assignations de tags au format CSV, triées par hostname.
+// Les assignations de devices absents de l'inventaire sont écrites sans hostname.
+func WriteTagsCSV(w io.Writer, devices []DeviceInfo, assignments []TagAssignmentInfo) error {
+	hostnames := map[string]string{}
+	for _, d := range devices {
+		hostnames[d.DeviceID] = d.Hostname
+	}
+	rows := make([][]string, 0, len(assignments))
+	for _, a := range assignments {
+		rows = append(rows, []string{hostnames[a.DeviceID], a.DeviceID, a.Label, a.Value, a.ElementType, a.InterfaceID, a.ElementSubType})
+	}
+	sort.Slice(rows, func(i, j int) bool { return strings.Join(rows[i], "\x00") < strings.Join(rows[j], "\x00") })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(tagCSVHeader); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// ReadTagsCSV lit un fichier CSV de tags. La première ligne est un en-tête nommant
+// les colonnes (voir tagCSVHeader) : label et value sont obligatoires, ainsi que
+// hostname ou serial ; elementType vaut "device" par défaut.
+func ReadTagsCSV(path string) ([]TagCSVRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s : en-tête illisible : %w", path, err)
+	}
+	known := map[string]bool{}
+	for _, h := range tagCSVHeader {
+		known[strings.ToLower(h)] = true
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if !known[h] {
+			return nil, fmt.Errorf("%s : colonne inconnue %q", path, h)
+		}
+		columns[h] = i
+	}
+	for _, required := range []string{"label", "value"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%s : colonne %s manquante", path, required)
+		}
+	}
+	_, hasHostname := columns["hostname"]
+	_, hasSerial := columns["serial"]
+	if !hasHostname && !hasSerial {
+		return nil, fmt.Errorf("%s : colonne hostname ou serial manquante", path)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []TagCSVRow
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s : %w", path, err)
+		}
+		row := TagCSVRow{
+			Line:           line,
+			Hostname:       field(record, "hostname"),
+			Serial:         field(record, "serial"),
+			Label:          field(record, "label"),
+			Value:          field(record, "value"),
+			ElementType:    strings.ToLower(field(record, "elementType")),
+			Interface:      field(record, "interface"),
+			ElementSubType: strings.ToLower(field(record, "elementSubType")),
+		}
+		if row.ElementType == "" {
+			row.ElementType = "device"
+		}
+		switch {
+		case row.Label == "" || row.Value == "":
+			return nil, fmt.Errorf("%s:%d : label et value obligatoires", path, line)
+		case row.Hostname == "" && row.Serial == "":
+			return nil, fmt.Errorf("%s:%d : hostname ou serial obligatoire", path, line)
+		case row.ElementType != "device" && row.ElementType != "interface":
+			return nil, fmt.Errorf("%s:%d : type d'élément invalide %q (device ou interface)", path, line, row.ElementType)
+		case (row.ElementType == "interface") != (row.Interface != ""):
+			return nil, fmt.Errorf("%s:%d : la colonne interface est réservée aux tags d'interface, et obligatoire pour eux", path, line)
+		case row.ElementSubType != "" && row.ElementType != "interface":
+			return nil, fmt.Errorf("%s:%d : la colonne elementSubType est réservée aux tags d'interface", path, line)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// TagAssignmentGroup regroupe les cibles d'un même tag, écrites en un lot par
+// SetTagAssignments.
+type TagAssignmentGroup struct {
+	Label          string
+	Value          string
+	ElementType    string
+	ElementSubType string
+	Targets        []TagTarget
+}
+
+// TagImport est le résultat de la validation d'un CSV de tags contre l'inventaire.
+type TagImport struct {
+	Groups []TagAssignmentGroup `json:"-"`
+	// Unknown contient les lignes dont le device est absent de l'inventaire, ou dont
+	// le hostname et le serial désignent des devices différents.
+	Unknown []TagCSVRow `json:"unknown,omitempty"`
+	// Duplicates contient les lignes identiques à une ligne précédente (ignorées).
+	Duplicates []TagCSVRow `json:"duplicates,omitempty"`
+}
+
+// ResolveTagsCSV valide des lignes de CSV de tags contre l'inventaire et regroupe les
+// assignations par tag, dans l'ordre du fichier.
+func ResolveTagsCSV(rows []TagCSVRow, devices []DeviceInfo) TagImport {
+	byHostname := map[string]DeviceInfo{}
+	bySerial := map[string]DeviceInfo{}
+	for _, d := range devices {
+		byHostname[d.Hostname] = d
+		bySerial[d.DeviceID] = d
+	}
+
+	var result TagImport
+	groups := map[string]int{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		d, ok := bySerial[row.Serial]
+		if row.Serial == "" {
+			d, ok = byHostname[row.Hostname]
+		}
+		if !ok || (row.Hostname != "" && d.Hostname != row.Hostname) {
+			result.Unknown = append(result.Unknown, row)
+			continue
+		}
+		id := strings.Join([]string{row.Label, row.Value, row.ElementType, row.ElementSubType, d.DeviceID, row.Interface}, "|")
+		if seen[id] {
+			result.Duplicates = append(result.Duplicates, row)
+			continue
+		}
+		seen[id] = true
+
+		key := row.Label + "=" + row.Value + "|" + row.ElementType + "|" + row.ElementSubType
+		i, ok := groups[key]
+		if !ok {
+			i = len(result.Groups)
+			groups[key] = i
+			result.Groups = append(result.Groups, TagAssignmentGroup{Label: row.Label, Value: row.Value, ElementType: row.ElementType, ElementSubType: row.ElementSubType})
+		}
+		result.Groups[i].Targets = append(result.Groups[i].Targets, TagTarget{DeviceID: d.DeviceID, Hostname: d.Hostname, InterfaceID: row.Interface})
+	}
+	return result
+}
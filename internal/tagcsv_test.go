@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestCSV écrit un CSV de tags dans un fichier temporaire et retourne son chemin.
+func writeTestCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tags.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadTagsCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []TagCSVRow
+		wantErr string
+	}{
+		{
+			name: "device et interface",
+			csv:  "hostname,serial,label,value,elementType,interface,elementSubType\nleaf-1,SN1,site,Paris,,,\nleaf-1,,role,uplink,Interface,Ethernet49/1,External\n",
+			want: []TagCSVRow{
+				{Line: 2, Hostname: "leaf-1", Serial: "SN1", Label: "site", Value: "Paris", ElementType: "device"},
+				{Line: 3, Hostname: "leaf-1", Label: "role", Value: "uplink", ElementType: "interface", Interface: "Ethernet49/1", ElementSubType: "external"},
+			},
+		},
+		{
+			name: "colonnes minimales",
+			csv:  "serial,label,value\nSN2,site,Lyon\n",
+			want: []TagCSVRow{{Line: 2, Serial: "SN2", Label: "site", Value: "Lyon", ElementType: "device"}},
+		},
+		{name: "colonne inconnue", csv: "hostname,label,value,site\n", wantErr: `colonne inconnue "site"`},
+		{name: "colonne value manquante", csv: "hostname,label\n", wantErr: "colonne value manquante"},
+		{name: "ni hostname ni serial", csv: "label,value\nsite,Paris\n", wantErr: "colonne hostname ou serial manquante"},
+		{name: "valeur vide", csv: "hostname,label,value\nleaf-1,site,\n", wantErr: ":2 : label et value obligatoires"},
+		{name: "device vide", csv: "hostname,serial,label,value\n,,site,Paris\n", wantErr: "hostname ou serial obligatoire"},
+		{name: "type invalide", csv: "hostname,label,value,elementType\nleaf-1,site,Paris,port\n", wantErr: `type d'élément invalide "port"`},
+		{name: "interface sans tag d'interface", csv: "hostname,label,value,interface\nleaf-1,site,Paris,Ethernet1\n", wantErr: "colonne interface"},
+		{name: "tag d'interface sans interface", csv: "hostname,label,value,elementType\nleaf-1,role,uplink,interface\n", wantErr: "colonne interface"},
+		{name: "sous-type d'un tag de device", csv: "hostname,label,value,elementSubType\nleaf-1,site,Paris,external\n", wantErr: "colonne elementSubType"},
+	}
+	for _, tt := range tests {
+		rows, err := ReadTagsCSV(writeTestCSV(t, tt.csv))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s : erreur = %v, attendu %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s : %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, tt.want) {
+			t.Errorf("%s : lignes = %+v, attendu %+v", tt.name, rows, tt.want)
+		}
+	}
+}
+
+func TestResolveTagsCSV(t *testing.T) {
+	devices := []DeviceInfo{{DeviceID: "SN1", Hostname: "leaf-1"}, {DeviceID: "SN2", Hostname: "leaf-2"}}
+	row := func(line int, hostname, serial, label, value string) TagCSVRow {
+		return TagCSVRow{Line: line, Hostname: hostname, Serial: serial, Label: label, Value: value, ElementType: "device"}
+	}
+	rows := []TagCSVRow{
+		row(2, "leaf-1", "", "site", "Paris"),
+		row(3, "", "SN2", "site", "Paris"),
+		row(4, "leaf-2", "SN2", "role", "leaf"),
+		// Doublon de la ligne 3, désigné par hostname.
+		row(5, "leaf-2", "", "site", "Paris"),
+		row(6, "leaf-9", "", "site", "Paris"),
+		row(7, "", "SN9", "site", "Paris"),
+		// Hostname et serial de devices différents.
+		row(8, "leaf-1", "SN2", "site", "Lyon"),
+		{Line: 9, Hostname: "leaf-1", Label: "role", Value: "leaf", ElementType: "interface", Interface: "Ethernet1"},
+	}
+
+	plan := ResolveTagsCSV(rows, devices)
+
+	lines := func(rows []TagCSVRow) []int {
+		var out []int
+		for _, r := range rows {
+			out = append(out, r.Line)
+		}
+		return out
+	}
+	if got, want := lines(plan.Duplicates), []int{5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("doublons = %v, attendu %v", got, want)
+	}
+	if got, want := lines(plan.Unknown), []int{6, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("inconnues = %v, attendu %v", got, want)
+	}
+	var groups []string
+	for _, g := range plan.Groups {
+		var targets []string
+		for _, t := range g.Targets {
+			targets = append(targets, t.DeviceID+":"+t.InterfaceID)
+		}
+		groups = append(groups, g.Label+"="+g.Value+"/"+g.ElementType+" "+strings.Join(targets, ","))
+	}
+	want := []string{"site=Paris/device SN1:,SN2:", "role=leaf/device SN2:", "role=leaf/interface SN1:Ethernet1"}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groupes = %v, attendu %v", groups, want)
+	}
+}
+
+func TestTagsCSVRoundTrip(t *testing.T) {
+	devices := []DeviceInfo{{DeviceID: "SN1", Hostname: "leaf-1"}}
+	assignments := []TagAssignmentInfo{
+		{Label: "role", Value: "uplink", ElementType: "interface", ElementSubType: "external", DeviceID: "SN1", InterfaceID: "Ethernet49/1"},
+		{Label: "site", Value: "Paris", ElementType: "device", DeviceID: "SN1"},
+		// Device absent de l'inventaire : exporté sans hostname.
+		{Label: "site", Value: "Lyon", ElementType: "device", DeviceID: "SN9"},
+	}
+	var buf bytes.Buffer
+	if err := WriteTagsCSV(&buf, devices, assignments); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadTagsCSV(writeTestCSV(t, buf.String()))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, buf.String())
+	}
+	want := []TagCSVRow{
+		{Line: 2, Serial: "SN9", Label: "site", Value: "Lyon", ElementType: "device"},
+		{Line: 3, Hostname: "leaf-1", Serial: "SN1", Label: "role", Value: "uplink", ElementType: "interface", Interface: "Ethernet49/1", ElementSubType: "external"},
+		{Line: 4, Hostname: "leaf-1", Serial: "SN1", Label: "site", Value: "Paris", ElementType: "device"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("lignes relues = %+v, attendu %+v", rows, want)
+	}
+}
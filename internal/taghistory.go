@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aristanetworks/cloudvision-go/api/arista/subscriptions"
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+	aristatime "github.com/aristanetworks/cloudvision-go/api/arista/time"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// historyMatchWindow est l'écart maximal entre la soumission d'un workspace et une
+// modification de mainline pour attribuer la modification à ce workspace.
+const historyMatchWindow = 15 * time.Minute
+
+// TagHistoryEvent est un ajout ou un retrait d'assignation de tag sur mainline.
+type TagHistoryEvent struct {
+	Time        time.Time    `json:"time"`
+	Action      ChangeAction `json:"action"`
+	Label       string       `json:"label"`
+	Value       string       `json:"value"`
+	InterfaceID string       `json:"interfaceId,omitempty"`
+	// WorkspaceID et WorkspaceName désignent le workspace soumis ayant porté la
+	// modification, s'il a pu être identifié.
+	WorkspaceID   string `json:"workspaceId,omitempty"`
+	WorkspaceName string `json:"workspaceName,omitempty"`
+}
+
+// GetTagHistory retourne la chronologie des ajouts et retraits d'assignations de tags
+// (device et interfaces) d'un device sur mainline depuis une date.
+//
+// Chaque modification est attribuée au workspace soumis qui écrit la même assignation et
+// dont la soumission est la plus proche (à historyMatchWindow près).
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - deviceID : device concerné
+//   - label : label à filtrer (ignoré si vide)
+//   - since : début de la période
+//
+// Retourne :
+//   - []TagHistoryEvent : les modifications, par ordre chronologique.
+//   - error : l'erreur gRPC si l'historique est illisible.
+func GetTagHistory(ctx context.Context, conn *grpc.ClientConn, deviceID, label string, since time.Time) ([]TagHistoryEvent, error) {
+	key := &tag.TagAssignmentKey{WorkspaceId: wrapperspb.String(""), DeviceId: wrapperspb.String(deviceID)}
+	if label != "" {
+		key.Label = wrapperspb.String(label)
+	}
+	client := tag.NewTagAssignmentServiceClient(conn)
+	stream, err := client.GetAll(ctx, &tag.TagAssignmentStreamRequest{
+		PartialEqFilter: []*tag.TagAssignment{{Key: key}},
+		Time:            &aristatime.TimeBounds{Start: timestamppb.New(since), End: timestamppb.Now()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resps, err := collect(stream.Recv)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []TagHistoryEvent
+	for _, r := range resps {
+		var action ChangeAction
+		switch r.GetType() {
+		case subscriptions.Operation_UPDATED:
+			action = ChangeAdd
+		case subscriptions.Operation_DELETED:
+			action = ChangeRemove
+		default:
+			// INITIAL : état au début de la période, pas une modification.
+			continue
+		}
+		k := r.GetValue().GetKey()
+		events = append(events, TagHistoryEvent{
+			Time:        r.GetTime().AsTime(),
+			Action:      action,
+			Label:       k.GetLabel().GetValue(),
+			Value:       k.GetValue().GetValue(),
+			InterfaceID: k.GetInterfaceId().GetValue(),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	if len(events) > 0 {
+		attributeTagHistory(ctx, conn, deviceID, since, events)
+	}
+	return events, nil
+}
+
+// attributeTagHistory renseigne le workspace des événements à partir des workspaces
+// soumis depuis since. Un workspace illisible est ignoré, et aucun événement n'est
+// attribué si la liste des workspaces ne peut être lue : l'attribution est indicative.
+func attributeTagHistory(ctx context.Context, conn *grpc.ClientConn, deviceID string, since time.Time, events []TagHistoryEvent) {
+	type submission struct {
+		ws   WorkspaceInfo
+		keys map[string]bool
+	}
+	var submissions []submission
+	workspaces, err := ListWorkspaces(ctx, conn, "SUBMITTED")
+	if err != nil {
+		// Sans la liste des workspaces, les événements restent non attribués.
+		return
+	}
+	client := tag.NewTagAssignmentConfigServiceClient(conn)
+	for _, ws := range workspaces {
+		if ws.LastModifiedAt.Before(since.Add(-historyMatchWindow)) {
+			continue
+		}
+		stream, err := client.GetAll(ctx, &tag.TagAssignmentConfigStreamRequest{
+			PartialEqFilter: []*tag.TagAssignmentConfig{{Key: &tag.TagAssignmentKey{
+				WorkspaceId: wrapperspb.String(ws.ID),
+				DeviceId:    wrapperspb.String(deviceID),
+			}}},
+		})
+		if err != nil {
+			continue
+		}
+		resps, err := collect(stream.Recv)
+		if err != nil || len(resps) == 0 {
+			continue
+		}
+		s := submission{ws: ws, keys: map[string]bool{}}
+		for _, r := range resps {
+			k := r.GetValue().GetKey()
+			action := actionFor(r.GetValue().GetRemove().GetValue(), false)
+			s.keys[historyKey(action, k.GetLabel().GetValue(), k.GetValue().GetValue(), k.GetInterfaceId().GetValue())] = true
+		}
+		submissions = append(submissions, s)
+	}
+
+	for i := range events {
+		e := &events[i]
+		best := historyMatchWindow + 1
+		for _, s := range submissions {
+			if !s.keys[historyKey(e.Action, e.Label, e.Value, e.InterfaceID)] {
+				continue
+			}
+			gap := e.Time.Sub(s.ws.LastModifiedAt).Abs()
+			if gap < best {
+				best = gap
+				e.WorkspaceID, e.WorkspaceName = s.ws.ID, s.ws.DisplayName
+			}
+		}
+	}
+}
+
+// historyKey identifie une modification d'assignation pour le rapprochement avec les workspaces.
+func historyKey(action ChangeAction, label, value, interfaceID string) string {
+	return string(action) + "|" + label + "=" + value + "|" + interfaceID
+}
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc"
+)
+
+// DeviceLabels associe un device à une liste de labels (ex : labels manquants).
+type DeviceLabels struct {
+	DeviceID string   `json:"deviceId"`
+	Hostname string   `json:"hostname"`
+	Labels   []string `json:"labels"`
+}
+
+// MultiValuedLabel signale un device portant plusieurs valeurs d'un label censé
+// n'en avoir qu'une.
+type MultiValuedLabel struct {
+	DeviceID string   `json:"deviceId"`
+	Hostname string   `json:"hostname"`
+	Label    string   `json:"label"`
+	Values   []string `json:"values"`
+}
+
+// SingleValueLabel signale un label créé par un utilisateur qui n'a qu'une valeur sur
+// l'ensemble de la flotte : il ne distingue aucun élément d'un autre.
+type SingleValueLabel struct {
+	Label       string `json:"label"`
+	Value       string `json:"value"`
+	ElementType string `json:"elementType"`
+	// Assigned est le nombre d'éléments portant le tag.
+	Assigned int `json:"assigned"`
+}
+
+// TagLintReport est le rapport d'incohérences des tags de mainline.
+type TagLintReport struct {
+	// UnusedTags contient les tags créés par un utilisateur et assignés à aucun élément.
+	UnusedTags []TagInfo `json:"unusedTags"`
+	// MissingLabels contient les devices auxquels il manque un label obligatoire.
+	MissingLabels []DeviceLabels `json:"missingLabels"`
+	// MultiValued contient les devices ayant plusieurs valeurs d'un label à valeur unique.
+	MultiValued []MultiValuedLabel `json:"multiValued"`
+	// SingleValued contient les labels n'ayant qu'une valeur, assignée, sur toute la flotte.
+	SingleValued []SingleValueLabel `json:"singleValued"`
+	// Orphans contient les assignations pointant vers un device absent de l'inventaire.
+	Orphans []TagAssignmentInfo `json:"orphans"`
+}
+
+// Count retourne le nombre total de problèmes du rapport.
+func (r TagLintReport) Count() int {
+	return len(r.UnusedTags) + len(r.MissingLabels) + len(r.MultiValued) + len(r.SingleValued) + len(r.Orphans)
+}
+
+// LintTags analyse les tags et assignations de mainline au regard de l'inventaire.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - devices : inventaire courant
+//   - required : labels que chaque device doit porter
+//   - single : labels à valeur unique (en plus des labels obligatoires)
+//
+// Panique :
+//   - Si la lecture des tags ou des assignations échoue.
+func LintTags(ctx context.Context, conn *grpc.ClientConn, devices []DeviceInfo, required, single []string) TagLintReport {
+	var tags []TagInfo
+	var assignments []TagAssignmentInfo
+	for _, elementType := range []string{"device", "interface"} {
+		assignments = append(assignments, GetTagAssignments(ctx, conn, "", "", elementType)...)
+		tags = append(tags, GetTags(ctx, conn, "", "", elementType)...)
+	}
+	return lintTags(devices, tags, assignments, required, single)
+}
+
+// lintTags applique les contrôles de LintTags aux tags et assignations déjà lus.
+//
+// Paramètres :
+//   - devices : inventaire courant
+//   - tags : tags de mainline, tous types d'éléments confondus
+//   - assignments : assignations de mainline, tous types d'éléments confondus
+//   - required : labels que chaque device doit porter
+//   - single : labels à valeur unique (en plus des labels obligatoires)
+//
+// Retourne :
+//   - TagLintReport : le rapport, dont les listes ne sont jamais nil
+func lintTags(devices []DeviceInfo, tags []TagInfo, assignments []TagAssignmentInfo, required, single []string) TagLintReport {
+	report := TagLintReport{
+		UnusedTags:    []TagInfo{},
+		MissingLabels: []DeviceLabels{},
+		MultiValued:   []MultiValuedLabel{},
+		SingleValued:  []SingleValueLabel{},
+		Orphans:       []TagAssignmentInfo{},
+	}
+	known := map[string]bool{}
+	for _, d := range devices {
+		known[d.DeviceID] = true
+	}
+
+	used := map[string]int{}
+	values := map[string]map[string][]string{}
+	for _, a := range assignments {
+		used[a.ElementType+"|"+a.Label+"="+a.Value]++
+		if !known[a.DeviceID] {
+			report.Orphans = append(report.Orphans, a)
+			continue
+		}
+		if a.ElementType == "device" {
+			if values[a.DeviceID] == nil {
+				values[a.DeviceID] = map[string][]string{}
+			}
+			values[a.DeviceID][a.Label] = append(values[a.DeviceID][a.Label], a.Value)
+		}
+	}
+	labels := map[string]map[string][]TagInfo{}
+	for _, t := range tags {
+		if t.CreatorType != "user" {
+			continue
+		}
+		if labels[t.ElementType] == nil {
+			labels[t.ElementType] = map[string][]TagInfo{}
+		}
+		labels[t.ElementType][t.Label] = append(labels[t.ElementType][t.Label], t)
+		if used[t.ElementType+"|"+t.Label+"="+t.Value] == 0 {
+			report.UnusedTags = append(report.UnusedTags, t)
+		}
+	}
+	for _, elementType := range []string{"device", "interface"} {
+		report.SingleValued = append(report.SingleValued, singleValueLabels(labels[elementType], used)...)
+	}
+
+	singleValued := append(append([]string{}, required...), single...)
+	for _, d := range devices {
+		var missing []string
+		for _, label := range required {
+			if len(values[d.DeviceID][label]) == 0 {
+				missing = append(missing, label)
+			}
+		}
+		if len(missing) > 0 {
+			report.MissingLabels = append(report.MissingLabels, DeviceLabels{DeviceID: d.DeviceID, Hostname: d.Hostname, Labels: missing})
+		}
+		seen := map[string]bool{}
+		for _, label := range singleValued {
+			if v := values[d.DeviceID][label]; len(v) > 1 && !seen[label] {
+				sort.Strings(v)
+				report.MultiValued = append(report.MultiValued, MultiValuedLabel{DeviceID: d.DeviceID, Hostname: d.Hostname, Label: label, Values: v})
+			}
+			seen[label] = true
+		}
+	}
+	return report
+}
+
+// singleValueLabels retourne, triés, les labels d'un type d'élément n'ayant qu'une
+// valeur. Un label dont l'unique tag n'est pas assigné est déjà signalé parmi les
+// tags inutilisés et n'est pas repris.
+func singleValueLabels(labels map[string][]TagInfo, used map[string]int) []SingleValueLabel {
+	var single []SingleValueLabel
+	for label, tags := range labels {
+		if len(tags) != 1 {
+			continue
+		}
+		t := tags[0]
+		if n := used[t.ElementType+"|"+t.Label+"="+t.Value]; n > 0 {
+			single = append(single, SingleValueLabel{Label: label, Value: t.Value, ElementType: t.ElementType, Assigned: n})
+		}
+	}
+	sort.Slice(single, func(i, j int) bool { return single[i].Label < single[j].Label })
+	return single
+}
+
+// Cleanup retourne les modifications supprimant les tags inutilisés et les assignations
+// orphelines créées par un utilisateur. Les labels manquants et les valeurs multiples demandent un arbitrage et
+// ne sont pas corrigés.
+func (r TagLintReport) Cleanup() WorkspaceChanges {
+	var changes WorkspaceChanges
+	for _, t := range r.UnusedTags {
+		changes.Tags = append(changes.Tags, TagChange{Action: ChangeRemove, Label: t.Label, Value: t.Value, ElementType: t.ElementType, ElementSubType: t.ElementSubType})
+	}
+	for _, a := range r.Orphans {
+		if a.CreatorType != "user" {
+			continue
+		}
+		changes.TagAssignments = append(changes.TagAssignments, TagAssignmentChange{
+			Action: ChangeRemove, Label: a.Label, Value: a.Value, ElementType: a.ElementType,
+			ElementSubType: a.ElementSubType, DeviceID: a.DeviceID, InterfaceID: a.InterfaceID,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintTags(t *testing.T) {
+	devices := []DeviceInfo{{DeviceID: "SN1", Hostname: "leaf-1"}, {DeviceID: "SN2", Hostname: "leaf-2"}}
+	tag := func(label, value, elementType, creator string) TagInfo {
+		return TagInfo{Label: label, Value: value, ElementType: elementType, CreatorType: creator}
+	}
+	assign := func(label, value, deviceID string) TagAssignmentInfo {
+		return TagAssignmentInfo{Label: label, Value: value, ElementType: "device", DeviceID: deviceID, CreatorType: "user"}
+	}
+	empty := TagLintReport{
+		UnusedTags:    []TagInfo{},
+		MissingLabels: []DeviceLabels{},
+		MultiValued:   []MultiValuedLabel{},
+		SingleValued:  []SingleValueLabel{},
+		Orphans:       []TagAssignmentInfo{},
+	}
+	// with retourne un rapport vide modifié par f.
+	with := func(f func(r *TagLintReport)) TagLintReport {
+		r := empty
+		f(&r)
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		tags        []TagInfo
+		assignments []TagAssignmentInfo
+		required    []string
+		single      []string
+		want        TagLintReport
+	}{
+		{
+			name:        "aucun problème",
+			tags:        []TagInfo{tag("site", "Paris", "device", "user"), tag("site", "Lyon", "device", "user")},
+			assignments: []TagAssignmentInfo{assign("site", "Paris", "SN1"), assign("site", "Lyon", "SN2")},
+			required:    []string{"site"},
+			want:        empty,
+		},
+		{
+			name: "tag inutilisé",
+			tags: []TagInfo{tag("site", "Paris", "device", "user"), tag("site", "Lyon", "device", "user"), tag("model", "cEOS", "device", "system")},
+			assignments: []TagAssignmentInfo{
+				assign("site", "Paris", "SN1"), assign("site", "Paris", "SN2"),
+			},
+			// Un tag système inutilisé n'est pas signalé ; site n'a qu'une valeur assignée
+			// mais deux tags, il n'est donc pas à valeur unique.
+			want: with(func(r *TagLintReport) { r.UnusedTags = []TagInfo{tag("site", "Lyon", "device", "user")} }),
+		},
+		{
+			name:        "label obligatoire manquant",
+			tags:        []TagInfo{tag("site", "Paris", "device", "user"), tag("site", "Lyon", "device", "user")},
+			assignments: []TagAssignmentInfo{assign("site", "Paris", "SN1"), assign("site", "Lyon", "SN1")},
+			required:    []string{"site", "role"},
+			want: with(func(r *TagLintReport) {
+				r.MissingLabels = []DeviceLabels{
+					{DeviceID: "SN1", Hostname: "leaf-1", Labels: []string{"role"}},
+					{DeviceID: "SN2", Hostname: "leaf-2", Labels: []string{"site", "role"}},
+				}
+				r.MultiValued = []MultiValuedLabel{{DeviceID: "SN1", Hostname: "leaf-1", Label: "site", Values: []string{"Lyon", "Paris"}}}
+			}),
+		},
+		{
+			name:        "label à valeur unique déclaré deux fois",
+			tags:        []TagInfo{tag("role", "leaf", "device", "user"), tag("role", "spine", "device", "user")},
+			assignments: []TagAssignmentInfo{assign("role", "spine", "SN2"), assign("role", "leaf", "SN2")},
+			required:    []string{"role"},
+			single:      []string{"role"},
+			want: with(func(r *TagLintReport) {
+				r.MissingLabels = []DeviceLabels{{DeviceID: "SN1", Hostname: "leaf-1", Labels: []string{"role"}}}
+				r.MultiValued = []MultiValuedLabel{{DeviceID: "SN2", Hostname: "leaf-2", Label: "role", Values: []string{"leaf", "spine"}}}
+			}),
+		},
+		{
+			name:        "plusieurs valeurs d'un label libre",
+			tags:        []TagInfo{tag("app", "web", "device", "user"), tag("app", "db", "device", "user")},
+			assignments: []TagAssignmentInfo{assign("app", "web", "SN1"), assign("app", "db", "SN1")},
+			want:        empty,
+		},
+		{
+			name: "label à valeur unique sur la flotte",
+			tags: []TagInfo{
+				tag("dc", "DC1", "device", "user"),
+				tag("uplink", "true", "interface", "user"),
+				tag("pod", "1", "device", "user"),
+			},
+			assignments: []TagAssignmentInfo{
+				assign("dc", "DC1", "SN1"), assign("dc", "DC1", "SN2"),
+				{Label: "uplink", Value: "true", ElementType: "interface", DeviceID: "SN1", InterfaceID: "Ethernet49/1", CreatorType: "user"},
+			},
+			// pod n'est pas assigné : il est signalé comme inutilisé, pas comme à valeur unique.
+			want: with(func(r *TagLintReport) {
+				r.UnusedTags = []TagInfo{tag("pod", "1", "device", "user")}
+				r.SingleValued = []SingleValueLabel{
+					{Label: "dc", Value: "DC1", ElementType: "device", Assigned: 2},
+					{Label: "uplink", Value: "true", ElementType: "interface", Assigned: 1},
+				}
+			}),
+		},
+		{
+			name: "assignation orpheline",
+			tags: []TagInfo{tag("site", "Paris", "device", "user"), tag("site", "Lyon", "device", "user")},
+			assignments: []TagAssignmentInfo{
+				assign("site", "Paris", "SN1"), assign("site", "Lyon", "SN2"), assign("site", "Lyon", "SN9"),
+			},
+			// Le tag assigné uniquement à un device absent n'est pas inutilisé.
+			want: with(func(r *TagLintReport) { r.Orphans = []TagAssignmentInfo{assign("site", "Lyon", "SN9")} }),
+		},
+	}
+	for _, tt := range tests {
+		got := lintTags(devices, tt.tags, tt.assignments, tt.required, tt.single)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : rapport = %+v, attendu %+v", tt.name, got, tt.want)
+		}
+		if got.Count() != tt.want.Count() {
+			t.Errorf("%s : %d problèmes, attendu %d", tt.name, got.Count(), tt.want.Count())
+		}
+	}
+}
+
+func TestTagLintCleanup(t *testing.T) {
+	report := TagLintReport{
+		UnusedTags: []TagInfo{{Label: "role", Value: "uplink", ElementType: "interface", ElementSubType: "external", CreatorType: "user"}},
+		// Les labels manquants et valeurs multiples ne sont pas corrigés.
+		MissingLabels: []DeviceLabels{{DeviceID: "SN1", Labels: []string{"site"}}},
+		MultiValued:   []MultiValuedLabel{{DeviceID: "SN1", Label: "role", Values: []string{"leaf", "spine"}}},
+		Orphans: []TagAssignmentInfo{
+			{Label: "site", Value: "Paris", ElementType: "device", DeviceID: "SN9", CreatorType: "user"},
+			{Label: "model", Value: "cEOS", ElementType: "device", DeviceID: "SN9", CreatorType: "system"},
+		},
+	}
+	want := WorkspaceChanges{
+		Tags: []TagChange{{Action: ChangeRemove, Label: "role", Value: "uplink", ElementType: "interface", ElementSubType: "external"}},
+		TagAssignments: []TagAssignmentChange{
+			{Action: ChangeRemove, Label: "site", Value: "Paris", ElementType: "device", DeviceID: "SN9"},
+		},
+	}
+	if got := report.Cleanup(); !reflect.DeepEqual(got, want) {
+		t.Errorf("nettoyage = %+v, attendu %+v", got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+)
+
+// TagRule est une entrée du fichier de tags déclaratif (data/tag.yaml) : un tag de
+// device et, facultativement, les devices auxquels il doit être assigné.
+//
+// Sans sélecteur, seule la définition du tag est gérée. Avec plusieurs sélecteurs
+// (devices, model, query), un device doit les satisfaire tous.
+type TagRule struct {
+	Label   string `yaml:"label"`
+	Value   string `yaml:"value"`
+	Devices string `yaml:"devices,omitempty"`
+	Model   string `yaml:"model,omitempty"`
+	Query   string `yaml:"query,omitempty"`
+
+	query *TagQuery
+}
+
+// TagFile est le contenu du fichier de tags déclaratif.
+type TagFile struct {
+	Tag []TagRule `yaml:"tag"`
+}
+
+// LoadTagFile lit et valide un fichier de tags déclaratif.
+func LoadTagFile(path string) (TagFile, error) {
+	var file TagFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return file, fmt.Errorf("%s : %w", path, err)
+	}
+	seen := map[string]bool{}
+	for i, r := range file.Tag {
+		if r.Label == "" || r.Value == "" {
+			return file, fmt.Errorf("%s : entrée %d : label et value obligatoires", path, i+1)
+		}
+		id := r.Label + "=" + r.Value
+		if seen[id] {
+			return file, fmt.Errorf("%s : tag %s déclaré deux fois", path, id)
+		}
+		seen[id] = true
+		if r.Query != "" {
+			if file.Tag[i].query, err = ParseTagQuery(r.Query); err != nil {
+				return file, fmt.Errorf("%s : tag %s : %w", path, id, err)
+			}
+		}
+	}
+	return file, nil
+}
+
+// HasSelector indique si la règle déclare des assignations.
+func (r TagRule) HasSelector() bool {
+	return r.Devices != "" || r.Model != "" || r.Query != ""
+}
+
+// Selects indique si un device, dont tags contient les tags actuels (voir DeviceTags),
+// doit porter le tag de la règle.
+func (r TagRule) Selects(d DeviceInfo, tags map[string][]string) bool {
+	if !r.HasSelector() {
+		return false
+	}
+	return MatchHostname(r.Devices, d.Hostname) && (r.Model == "" || r.Model == d.Model) &&
+		(r.query == nil || r.query.Match(tags))
+}
+
+// PlanTags calcule les modifications à écrire pour que mainline corresponde au
+// fichier de tags : tags à créer et assignations à ajouter.
+//
+// Avec prune, les assignations de mainline non déclarées sont retirées. Seuls les labels
+// dont au moins une règle a un sélecteur sont concernés (un label seulement défini
+// n'a pas d'assignations gérées), et seules les assignations créées par un
+// utilisateur : les tags système ne sont jamais retirés.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour les appels gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - file : fichier de tags déclaratif
+//   - devices : inventaire utilisé pour résoudre les sélecteurs
+//   - prune : retirer les assignations non déclarées
+//
+// Retourne :
+//   - WorkspaceChanges : les tags et assignations à écrire, applicables avec
+//     ApplyWorkspaceChanges.
+//
+// Panique :
+//   - Si la lecture des tags ou des assignations de mainline échoue.
+func PlanTags(ctx context.Context, conn *grpc.ClientConn, file TagFile, devices []DeviceInfo, prune bool) WorkspaceChanges {
+	mainline := readMainlineTags(ctx, conn)
+	deviceTags := DeviceTags(devices, mainline.assignments)
+
+	var defs []TagChange
+	var desired []TagAssignmentChange
+	managed := map[string]bool{}
+	for _, r := range file.Tag {
+		if r.HasSelector() {
+			managed[r.Label] = true
+		}
+		defs = append(defs, TagChange{Action: ChangeAdd, Label: r.Label, Value: r.Value, ElementType: "device"})
+		for _, d := range devices {
+			if r.Selects(d, deviceTags[d.DeviceID]) {
+				desired = append(desired, TagAssignmentChange{
+					Action: ChangeAdd, Label: r.Label, Value: r.Value, ElementType: "device",
+					DeviceID: d.DeviceID, Hostname: d.Hostname,
+				})
+			}
+		}
+	}
+
+	var prunable func(TagAssignmentInfo) bool
+	if prune {
+		prunable = func(a TagAssignmentInfo) bool { return managed[a.Label] }
+	}
+	return mainline.plan(defs, desired, devices, prunable)
+}
+
+// mainlineTags contient les tags et les assignations de tags de device de mainline.
+type mainlineTags struct {
+	existing    map[string]bool
+	assignments []TagAssignmentInfo
+}
+
+// readMainlineTags lit les tags et assignations de tags de device de mainline.
+//
+// Panique :
+//   - Si la lecture des tags ou des assignations échoue.
+func readMainlineTags(ctx context.Context, conn *grpc.ClientConn) mainlineTags {
+	m := mainlineTags{existing: map[string]bool{}}
+	for _, t := range GetTags(ctx, conn, "", "", "device") {
+		m.existing[t.Label+"="+t.Value] = true
+	}
+	m.assignments = GetTagAssignments(ctx, conn, "", "", "device")
+	return m
+}
+
+// plan compare des tags et des assignations souhaités à mainline et retourne les
+// modifications à écrire : tags et assignations manquants, puis, si prunable n'est pas
+// nil, retrait des assignations non souhaitées pour lesquelles prunable est vrai.
+// Seules les assignations créées par un utilisateur sont retirées.
+func (m mainlineTags) plan(defs []TagChange, desired []TagAssignmentChange, devices []DeviceInfo, prunable func(TagAssignmentInfo) bool) WorkspaceChanges {
+	var changes WorkspaceChanges
+	for _, t := range defs {
+		id := t.Label + "=" + t.Value
+		if !m.existing[id] {
+			changes.Tags = append(changes.Tags, t)
+			m.existing[id] = true
+		}
+	}
+
+	assigned := map[string]bool{}
+	for _, a := range m.assignments {
+		assigned[a.Label+"="+a.Value+"|"+a.DeviceID] = true
+	}
+	wanted := map[string]bool{}
+	for _, a := range desired {
+		id := a.Label + "=" + a.Value + "|" + a.DeviceID
+		if !assigned[id] && !wanted[id] {
+			changes.TagAssignments = append(changes.TagAssignments, a)
+		}
+		wanted[id] = true
+	}
+	if prunable == nil {
+		return changes
+	}
+
+	hostnames := map[string]string{}
+	for _, d := range devices {
+		hostnames[d.DeviceID] = d.Hostname
+	}
+	var removals []TagAssignmentChange
+	for _, a := range m.assignments {
+		if wanted[a.Label+"="+a.Value+"|"+a.DeviceID] || a.CreatorType != "user" || !prunable(a) {
+			continue
+		}
+		removals = append(removals, TagAssignmentChange{
+			Action: ChangeRemove, Label: a.Label, Value: a.Value, ElementType: "device",
+			DeviceID: a.DeviceID, Hostname: hostnames[a.DeviceID],
+		})
+	}
+	sort.Slice(removals, func(i, j int) bool { return removals[i].String() < removals[j].String() })
+	changes.TagAssignments = append(changes.TagAssignments, removals...)
+	return changes
+}
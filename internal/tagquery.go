@@ -0,0 +1,284 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc"
+)
+
+// TagQuery est une requête de tags à la CloudVision, par exemple
+// `site:Paris AND role:leaf AND NOT model:cEOSLab`.
+//
+// Syntaxe :
+//   - un terme `label:valeur` est vrai si le device porte ce tag ; la valeur accepte
+//     les motifs glob (`leaf-*`) et les guillemets (`site:"New York"`) ;
+//   - les termes se combinent avec AND, OR, NOT et des parenthèses, AND étant
+//     prioritaire sur OR ; deux termes juxtaposés sont combinés par AND ;
+//   - les mots-clés sont insensibles à la casse.
+//
+// Outre les tags assignés, chaque device porte les pseudo-tags `device` (ID),
+// `hostname`, `model`, `version` et `mac`, issus de l'inventaire.
+type TagQuery struct {
+	root queryNode
+}
+
+// queryNode est un nœud de l'arbre d'une requête de tags.
+type queryNode interface {
+	eval(tags map[string][]string) bool
+}
+
+type (
+	queryTerm struct{ label, value string }
+	queryNot  struct{ node queryNode }
+	queryAnd  struct{ left, right queryNode }
+	queryOr   struct{ left, right queryNode }
+)
+
+func (t queryTerm) eval(tags map[string][]string) bool {
+	for _, v := range tags[t.label] {
+		if ok, _ := path.Match(t.value, v); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (n queryNot) eval(tags map[string][]string) bool { return !n.node.eval(tags) }
+func (n queryAnd) eval(tags map[string][]string) bool { return n.left.eval(tags) && n.right.eval(tags) }
+func (n queryOr) eval(tags map[string][]string) bool  { return n.left.eval(tags) || n.right.eval(tags) }
+
+// ParseTagQuery analyse une requête de tags.
+//
+// Retourne :
+//   - *TagQuery : la requête, à évaluer avec Match.
+//   - error : une erreur de syntaxe indiquant le jeton fautif.
+func ParseTagQuery(query string) (*TagQuery, error) {
+	tokens, err := lexTagQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("requête de tags vide")
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("requête de tags : jeton inattendu %q", p.tokens[p.pos])
+	}
+	return &TagQuery{root: root}, nil
+}
+
+// Match indique si un ensemble de tags (label → valeurs) satisfait la requête.
+func (q *TagQuery) Match(tags map[string][]string) bool {
+	return q.root.eval(tags)
+}
+
+// lexTagQuery découpe une requête en jetons : parenthèses, mots-clés et termes.
+// Les guillemets d'un terme sont retirés.
+func lexTagQuery(query string) ([]string, error) {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			var b strings.Builder
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] != '"' {
+					b.WriteRune(runes[i])
+					i++
+					continue
+				}
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					end++
+				}
+				if end == len(runes) {
+					return nil, fmt.Errorf("requête de tags : guillemet non fermé")
+				}
+				b.WriteString(string(runes[i+1 : end]))
+				i = end + 1
+			}
+			tokens = append(tokens, b.String())
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser est un analyseur descendant récursif sur les jetons d'une requête.
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+// peek retourne le jeton courant, ou "" en fin de requête.
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// keyword indique si le jeton courant est le mot-clé donné.
+func (p *queryParser) keyword(kw string) bool {
+	return strings.EqualFold(p.peek(), kw)
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.keyword("AND") {
+			p.pos++
+		} else if tok := p.peek(); tok == "" || tok == ")" || p.keyword("OR") {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = queryAnd{left, right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.keyword("NOT") {
+		p.pos++
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("requête de tags : fin inattendue")
+	case tok == "(":
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("requête de tags : parenthèse non fermée")
+		}
+		p.pos++
+		return node, nil
+	case tok == ")" || p.keyword("AND") || p.keyword("OR"):
+		return nil, fmt.Errorf("requête de tags : jeton inattendu %q", tok)
+	}
+	p.pos++
+	label, value, ok := strings.Cut(tok, ":")
+	if !ok || label == "" || value == "" {
+		return nil, fmt.Errorf("requête de tags : terme invalide %q (attendu label:valeur)", tok)
+	}
+	if _, err := path.Match(value, ""); err != nil {
+		return nil, fmt.Errorf("requête de tags : motif invalide %q", value)
+	}
+	return queryTerm{label: label, value: value}, nil
+}
+
+// DeviceAttributes retourne les attributs d'inventaire d'un device exposés comme
+// pseudo-tags : device (ID), hostname, model, version et mac.
+func DeviceAttributes(d DeviceInfo) map[string]string {
+	return map[string]string{
+		"device":   d.DeviceID,
+		"hostname": d.Hostname,
+		"model":    d.Model,
+		"version":  d.Version,
+		"mac":      d.SystemMac,
+	}
+}
+
+// DeviceTags construit, pour chaque device, l'ensemble de ses tags (label → valeurs) :
+// assignations de tags de device et pseudo-tags issus de l'inventaire.
+func DeviceTags(devices []DeviceInfo, assignments []TagAssignmentInfo) map[string]map[string][]string {
+	tags := make(map[string]map[string][]string, len(devices))
+	for _, d := range devices {
+		t := map[string][]string{}
+		for name, value := range DeviceAttributes(d) {
+			t[name] = []string{value}
+		}
+		tags[d.DeviceID] = t
+	}
+	for _, a := range assignments {
+		if t, ok := tags[a.DeviceID]; ok && a.InterfaceID == "" {
+			t[a.Label] = append(t[a.Label], a.Value)
+		}
+	}
+	return tags
+}
+
+// MainlineDeviceTags retourne les tags de chaque device (voir DeviceTags) d'après les
+// assignations de tags de mainline.
+//
+// Panique :
+//   - Si la lecture des assignations de tags échoue.
+func MainlineDeviceTags(ctx context.Context, conn *grpc.ClientConn, devices []DeviceInfo) map[string]map[string][]string {
+	return DeviceTags(devices, GetTagAssignments(ctx, conn, "", "", "device"))
+}
+
+// SelectByTagQuery retourne les équipements dont les tags (voir DeviceTags) satisfont
+// la requête. Une requête nil retourne tous les équipements.
+func SelectByTagQuery(devices []DeviceInfo, tags map[string]map[string][]string, q *TagQuery) []DeviceInfo {
+	if q == nil {
+		return devices
+	}
+	var selected []DeviceInfo
+	for _, d := range devices {
+		if q.Match(tags[d.DeviceID]) {
+			selected = append(selected, d)
+		}
+	}
+	return selected
+}
+
+// FilterByTagQuery retourne les équipements satisfaisant la requête de tags, évaluée sur
+// les assignations de tags de mainline. Une requête vide retourne tous les équipements.
+//
+// Panique :
+//   - Si la lecture des assignations de tags échoue.
+func FilterByTagQuery(ctx context.Context, conn *grpc.ClientConn, devices []DeviceInfo, query string) ([]DeviceInfo, error) {
+	if strings.TrimSpace(query) == "" {
+		return devices, nil
+	}
+	q, err := ParseTagQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return SelectByTagQuery(devices, MainlineDeviceTags(ctx, conn, devices), q), nil
+}
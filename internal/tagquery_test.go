@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagQueryMatch(t *testing.T) {
+	paris := map[string][]string{"site": {"Paris"}, "role": {"leaf"}, "hostname": {"paris-leaf-1"}, "model": {"cEOSLab"}}
+	lyon := map[string][]string{"site": {"Lyon"}, "role": {"spine"}, "hostname": {"lyon-spine-1"}, "model": {"DCS-7280SR"}}
+	newYork := map[string][]string{"site": {"New York"}, "role": {"leaf", "border"}, "hostname": {"ny-leaf-1"}}
+
+	tests := []struct {
+		query string
+		want  []bool // paris, lyon, newYork
+	}{
+		{"site:Paris", []bool{true, false, false}},
+		{"site:paris", []bool{false, false, false}},
+		// OR est moins prioritaire que AND : a OR (b AND c).
+		{"site:Lyon OR site:Paris AND role:spine", []bool{false, true, false}},
+		{"(site:Lyon OR site:Paris) AND role:leaf", []bool{true, false, false}},
+		// NOT s'applique au seul terme qui le suit.
+		{"NOT site:Paris AND role:leaf", []bool{false, false, true}},
+		{"NOT (site:Paris OR site:Lyon)", []bool{false, false, true}},
+		{"NOT NOT site:Paris", []bool{true, false, false}},
+		// Deux termes juxtaposés sont combinés par AND.
+		{"role:leaf site:Paris", []bool{true, false, false}},
+		{"role:leaf site:Paris OR site:Lyon", []bool{true, true, false}},
+		// Mots-clés insensibles à la casse.
+		{"site:Paris or site:Lyon", []bool{true, true, false}},
+		{"role:leaf and not site:Paris", []bool{false, false, true}},
+		// Guillemets et motifs glob.
+		{`site:"New York"`, []bool{false, false, true}},
+		{`site:"New *"`, []bool{false, false, true}},
+		{"hostname:*-leaf-*", []bool{true, false, true}},
+		{"model:DCS-7280*", []bool{false, true, false}},
+		// Un label multivalué correspond si l'une de ses valeurs correspond.
+		{"role:border", []bool{false, false, true}},
+		{"unknown:*", []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		q, err := ParseTagQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseTagQuery(%q) : %v", tt.query, err)
+			continue
+		}
+		for i, tags := range []map[string][]string{paris, lyon, newYork} {
+			if got := q.Match(tags); got != tt.want[i] {
+				t.Errorf("%q sur %v = %v, attendu %v", tt.query, tags, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseTagQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "vide"},
+		{"   ", "vide"},
+		{"site", "terme invalide"},
+		{"site:", "terme invalide"},
+		{":Paris", "terme invalide"},
+		{`site:"Paris`, "guillemet non fermé"},
+		{"(site:Paris", "parenthèse non fermée"},
+		{"site:Paris)", "jeton inattendu"},
+		{"site:Paris AND", "fin inattendue"},
+		{"OR site:Paris", "jeton inattendu"},
+		{"NOT", "fin inattendue"},
+		{"site:[", "motif invalide"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTagQuery(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseTagQuery(%q) = %v, attendu une erreur contenant %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestSelectByTagQuery(t *testing.T) {
+	devices := []DeviceInfo{
+		{DeviceID: "SN1", Hostname: "leaf-1", Model: "cEOSLab"},
+		{DeviceID: "SN2", Hostname: "leaf-2", Model: "DCS-7280SR"},
+		{DeviceID: "SN3", Hostname: "spine-1", Model: "cEOSLab"},
+	}
+	tags := DeviceTags(devices, []TagAssignmentInfo{
+		{Label: "site", Value: "Paris", DeviceID: "SN1"},
+		{Label: "site", Value: "Paris", DeviceID: "SN3"},
+		// Les tags d'interface ne sont pas des tags du device.
+		{Label: "site", Value: "Paris", DeviceID: "SN2", InterfaceID: "Ethernet1"},
+	})
+	q, err := ParseTagQuery("site:Paris AND NOT model:cEOSLab OR hostname:spine-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range SelectByTagQuery(devices, tags, q) {
+		got = append(got, d.DeviceID)
+	}
+	if strings.Join(got, ",") != "SN3" {
+		t.Errorf("sélection = %v, attendu [SN3]", got)
+	}
+	if all := SelectByTagQuery(devices, tags, nil); len(all) != len(devices) {
+		t.Errorf("requête nil : %d équipements, attendu %d", len(all), len(devices))
+	}
+}
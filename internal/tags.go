@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	tag "github.com/aristanetworks/cloudvision-go/api/arista/tag.v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// tagConfigID identifie un tag indépendamment de son workspace (voir priorConfigs).
+func tagConfigID(k *tag.TagKey) string {
+	return k.GetElementType().String() + "|" + k.GetElementSubType().String() + "|" + k.GetLabel().GetValue() + "=" + k.GetValue().GetValue()
+}
+
+// TagInfo contient les informations d'un tag retourné par le TagService.
+type TagInfo struct {
+	Label          string `json:"label"`
+	Value          string `json:"value"`
+	ElementType    string `json:"elementType"`
+	ElementSubType string `json:"elementSubType,omitempty"`
+	CreatorType    string `json:"creatorType"`
+}
+
+// tagKey construit la clé tag.v2 d'un tag dans un workspace ("" pour mainline).
+func tagKey(workspaceID, label, value string, elementType tag.ElementType, subType tag.ElementSubType) *tag.TagKey {
+	return &tag.TagKey{
+		WorkspaceId:    wrapperspb.String(workspaceID),
+		ElementType:    elementType,
+		ElementSubType: subType,
+		Label:          wrapperspb.String(label),
+		Value:          wrapperspb.String(value),
+	}
+}
+
+// setTagConfig écrit (ou retire, avec remove) la définition d'un tag dans un workspace.
+func setTagConfig(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, value, elementType, elementSubType string, remove bool) error {
+	if label == "" || value == "" {
+		return fmt.Errorf("label et valeur obligatoires")
+	}
+	et, st, err := parseElementKind(elementType, elementSubType)
+	if err != nil {
+		return err
+	}
+	client := tag.NewTagConfigServiceClient(conn)
+	key := tagKey(workspaceID, label, value, et, st)
+	var prior map[string]*tag.TagConfig
+	if restoresWrites(ctx, workspaceID) {
+		resp, err := client.GetOne(ctx, &tag.TagConfigRequest{Key: key})
+		switch {
+		case err == nil:
+			prior = map[string]*tag.TagConfig{tagConfigID(key): resp.GetValue()}
+		case status.Code(err) != codes.NotFound:
+			return fmt.Errorf("lecture de l'état antérieur : %w", err)
+		}
+	}
+	write := prepareWrite(ctx, conn, workspaceID, WorkspaceChanges{
+		Tags: []TagChange{{Action: actionFor(remove, false), Label: label, Value: value, ElementType: elementTypeName(et), ElementSubType: elementSubTypeName(st)}},
+	})
+	_, err = client.Set(ctx, &tag.TagConfigSetRequest{Value: &tag.TagConfig{
+		Key:    key,
+		Remove: wrapperspb.Bool(remove),
+	}})
+	if err != nil {
+		return err
+	}
+	write.record(allWritten)
+	operation := "création"
+	if remove {
+		operation = "suppression"
+	}
+	registerUndo(ctx, workspaceID, fmt.Sprintf("%s du tag %s=%s", operation, label, value), false, restoreOrDelete(prior, tagConfigID(key), func(ctx context.Context, prior *tag.TagConfig) error {
+		_, err := client.Set(ctx, &tag.TagConfigSetRequest{Value: prior})
+		return err
+	}, func(ctx context.Context) error {
+		_, err := client.Delete(ctx, &tag.TagConfigDeleteRequest{Key: key})
+		return err
+	}))
+	return nil
+}
+
+// CreateTag crée un tag label=value dans un workspace via le TagConfigService et
+// retourne le tag créé.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour l'appel gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace dans lequel le tag est créé
+//   - label, value : le tag à créer (ex : "site", "Paris")
+//   - elementType : "device" ou "interface"
+//   - elementSubType : sous-type d'un tag d'interface ("external"), "" pour aucun
+//
+// Panique :
+//   - Si le type d'élément est invalide ou si l'appel gRPC échoue.
+func CreateTag(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, value, elementType, elementSubType string) TagInfo {
+	if err := setTagConfig(ctx, conn, workspaceID, label, value, elementType, elementSubType, false); err != nil {
+		panic(fmt.Sprintf("❌ Erreur ajout tag %s=%s : %v", label, value, err))
+	}
+	return TagInfo{Label: label, Value: value, ElementType: elementType, ElementSubType: elementSubType, CreatorType: "user"}
+}
+
+// DeleteTag supprime un tag label=value dans un workspace : la suppression sera
+// effective sur mainline à la soumission du workspace. Retourne le tag supprimé.
+//
+// Panique :
+//   - Si le type d'élément est invalide ou si l'appel gRPC échoue.
+func DeleteTag(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, value, elementType, elementSubType string) TagInfo {
+	if err := setTagConfig(ctx, conn, workspaceID, label, value, elementType, elementSubType, true); err != nil {
+		panic(fmt.Sprintf("❌ Erreur suppression tag %s=%s : %v", label, value, err))
+	}
+	return TagInfo{Label: label, Value: value, ElementType: elementType, ElementSubType: elementSubType, CreatorType: "user"}
+}
+
+// GetTags retourne les tags visibles dans un workspace ("" pour mainline), filtrés
+// par type d'élément et éventuellement par label.
+//
+// Paramètres :
+//   - ctx : contexte d'exécution pour l'appel gRPC
+//   - conn : connexion gRPC active vers CloudVision
+//   - workspaceID : workspace à lire, "" pour mainline
+//   - label : label à filtrer (ignoré si vide)
+//   - elementType : "device" ou "interface"
+//
+// Panique :
+//   - Si le type d'élément est invalide ou si le streaming gRPC échoue.
+func GetTags(ctx context.Context, conn *grpc.ClientConn, workspaceID, label, elementType string) []TagInfo {
+	et, err := parseElementType(elementType)
+	if err != nil {
+		panic(fmt.Sprintf("❌ %v", err))
+	}
+	filter := &tag.Tag{Key: &tag.TagKey{WorkspaceId: wrapperspb.String(workspaceID), ElementType: et}}
+	if label != "" {
+		filter.Key.Label = wrapperspb.String(label)
+	}
+
+	client := tag.NewTagServiceClient(conn)
+	stream, err := client.GetAll(ctx, &tag.TagStreamRequest{PartialEqFilter: []*tag.Tag{filter}})
+	if err != nil {
+		panic(fmt.Sprintf("❌ Erreur stream tags : %v", err))
+	}
+	var tags []TagInfo
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(fmt.Sprintf("❌ Erreur lecture tags : %v", err))
+		}
+		val := res.GetValue()
+		tags = append(tags, TagInfo{
+			Label:          val.GetKey().GetLabel().GetValue(),
+			Value:          val.GetKey().GetValue().GetValue(),
+			ElementType:    elementTypeName(val.GetKey().GetElementType()),
+			ElementSubType: elementSubTypeName(val.GetKey().GetElementSubType()),
+			CreatorType:    creatorTypeName(val.GetCreatorType()),
+		})
+	}
+	return tags
+}
+
+// creatorTypeName convertit un type de créateur tag.v2 en nom court ("user", "system"...).
+func creatorTypeName(t tag.CreatorType) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "CREATOR_TYPE_"))
+}
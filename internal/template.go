@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TemplateVariable décrit une variable d'un template de workspace.
+type TemplateVariable struct {
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+	Pattern     string `yaml:"pattern,omitempty"`
+}
+
+// TemplateAssignment assigne un tag aux équipements dont le hostname correspond à Devices
+// (motifs glob séparés par des virgules) et qui satisfont Query (requête de tags,
+// voir TagQuery). Un sélecteur vide ne filtre pas. Un tag d'interface est assigné à
+// l'interface Interface (ex: "Ethernet1") de chaque équipement sélectionné.
+type TemplateAssignment struct {
+	Label       string `yaml:"label"`
+	Value       string `yaml:"value"`
+	ElementType string `yaml:"elementType,omitempty"`
+	Interface   string `yaml:"interface,omitempty"`
+	Devices     string `yaml:"devices,omitempty"`
+	Query       string `yaml:"query,omitempty"`
+}
+
+// TemplateBody est la partie interprétée d'un template : le nom du workspace et
+// la liste des opérations à y appliquer.
+type TemplateBody struct {
+	Name         string               `yaml:"name,omitempty"`
+	Description  string               `yaml:"description,omitempty"`
+	Tags         []TagChange          `yaml:"tags,omitempty"`
+	Assignments  []TemplateAssignment `yaml:"assignments,omitempty"`
+	StudioInputs []StudioInputChange  `yaml:"studioInputs,omitempty"`
+}
+
+// WorkspaceTemplate est un template de workspace : un premier document YAML déclare
+// les variables, un second (séparé par `---`) est un template Go produisant un TemplateBody.
+type WorkspaceTemplate struct {
+	Variables map[string]TemplateVariable `yaml:"variables"`
+	body      string
+}
+
+// templateSeparator sépare la déclaration des variables du corps du template.
+var templateSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// LoadTemplate lit un template de workspace.
+func LoadTemplate(path string) (*WorkspaceTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parts := templateSeparator.Split(string(data), 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%s : séparateur `---` attendu entre les variables et les opérations", path)
+	}
+	var tmpl WorkspaceTemplate
+	if err := yaml.UnmarshalStrict([]byte(parts[0]), &tmpl); err != nil {
+		return nil, fmt.Errorf("%s : déclaration des variables : %w", path, err)
+	}
+	tmpl.body = parts[1]
+	return &tmpl, nil
+}
+
+// ParseSetFlags convertit des affectations `clé=valeur` (flags --set) en map.
+func ParseSetFlags(sets []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, s := range sets {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("affectation invalide : %q (attendu clé=valeur)", s)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Validate vérifie les valeurs fournies au regard des variables déclarées (variables
+// inconnues, obligatoires manquantes, motifs) et retourne les valeurs complétées
+// par les défauts.
+func (t *WorkspaceTemplate) Validate(values map[string]string) (map[string]string, error) {
+	var problems []string
+	resolved := map[string]string{}
+	for name, value := range values {
+		if _, ok := t.Variables[name]; !ok {
+			problems = append(problems, fmt.Sprintf("variable inconnue : %s", name))
+			continue
+		}
+		resolved[name] = value
+	}
+	for name, v := range t.Variables {
+		value, ok := resolved[name]
+		if !ok {
+			if v.Required {
+				problems = append(problems, fmt.Sprintf("variable obligatoire manquante : %s", name))
+				continue
+			}
+			value = v.Default
+			resolved[name] = value
+		}
+		if v.Pattern != "" {
+			re, err := regexp.Compile(v.Pattern)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("motif invalide pour %s : %v", name, err))
+			} else if !re.MatchString(value) {
+				problems = append(problems, fmt.Sprintf("%s=%q ne respecte pas le motif %s", name, value, v.Pattern))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%s", strings.Join(problems, " ; "))
+	}
+	return resolved, nil
+}
+
+// templateFuncs sont les fonctions disponibles dans le corps d'un template.
+var templateFuncs = template.FuncMap{
+	// quote produit une chaîne YAML entre guillemets, sûre quel que soit son contenu.
+	"quote": strconv.Quote,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Render interprète le corps du template avec des valeurs validées. Toute référence
+// à une variable non déclarée est une erreur.
+func (t *WorkspaceTemplate) Render(values map[string]string) (TemplateBody, error) {
+	var body TemplateBody
+	tmpl, err := template.New("workspace").Funcs(templateFuncs).Option("missingkey=error").Parse(t.body)
+	if err != nil {
+		return body, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return body, err
+	}
+	if err := yaml.UnmarshalStrict(out.Bytes(), &body); err != nil {
+		return body, fmt.Errorf("opérations rendues invalides : %w", err)
+	}
+	return body, nil
+}
+
+// UsesTagQuery indique si une assignation du template sélectionne ses équipements par
+// requête de tags : Changes a alors besoin des tags des équipements.
+func (b TemplateBody) UsesTagQuery() bool {
+	for _, a := range b.Assignments {
+		if a.Query != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes convertit les opérations d'un template en modifications de workspace.
+// Les assignations sont résolues sur l'inventaire et, pour les requêtes de tags, sur
+// tags (voir MainlineDeviceTags ; nil si UsesTagQuery est faux) : un sélecteur ne
+// correspondant à aucun équipement est une erreur, de même qu'un tag d'interface sans
+// interface ou un tag d'équipement avec une interface.
+func (b TemplateBody) Changes(devices []DeviceInfo, tags map[string]map[string][]string) (WorkspaceChanges, error) {
+	changes := WorkspaceChanges{}
+	for _, t := range b.Tags {
+		if t.ElementType == "" {
+			t.ElementType = "device"
+		}
+		t.Action = ChangeAdd
+		changes.Tags = append(changes.Tags, t)
+	}
+	for _, a := range b.Assignments {
+		if a.ElementType == "" {
+			a.ElementType = "device"
+		}
+		switch {
+		case a.ElementType != "device" && a.ElementType != "interface":
+			return changes, fmt.Errorf("tag %s=%s : type d'élément invalide %q (device ou interface)", a.Label, a.Value, a.ElementType)
+		case (a.ElementType == "interface") != (a.Interface != ""):
+			return changes, fmt.Errorf("tag %s=%s : le champ interface est réservé aux tags d'interface, et obligatoire pour eux", a.Label, a.Value)
+		}
+		selected, err := selectTemplateDevices(devices, tags, a.Devices, a.Query)
+		if err != nil {
+			return changes, fmt.Errorf("tag %s=%s : %w", a.Label, a.Value, err)
+		}
+		for _, d := range selected {
+			changes.TagAssignments = append(changes.TagAssignments, TagAssignmentChange{
+				Action:      ChangeAdd,
+				Label:       a.Label,
+				Value:       a.Value,
+				ElementType: a.ElementType,
+				DeviceID:    d.DeviceID,
+				Hostname:    d.Hostname,
+				InterfaceID: a.Interface,
+			})
+		}
+	}
+	for _, s := range b.StudioInputs {
+		s.Action = ChangeModify
+		changes.StudioInputs = append(changes.StudioInputs, s)
+	}
+	return changes, nil
+}
+
+// selectTemplateDevices retourne les équipements correspondant aux motifs de hostname
+// et à la requête de tags d'une assignation de template ou d'une ligne de CSV.
+//
+// Retourne :
+//   - error : si la requête est invalide ou si aucun équipement ne correspond.
+func selectTemplateDevices(devices []DeviceInfo, tags map[string]map[string][]string, patterns, query string) ([]DeviceInfo, error) {
+	selected := SelectDevices(devices, patterns)
+	if query != "" {
+		q, err := ParseTagQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		selected = SelectByTagQuery(selected, tags, q)
+	}
+	if len(selected) == 0 && query != "" {
+		return nil, fmt.Errorf("aucun équipement ne correspond à %q (requête %q)", patterns, query)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("aucun équipement ne correspond à %q", patterns)
+	}
+	return selected, nil
+}
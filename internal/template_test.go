@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testTemplate est un template de workspace de test.
+const testTemplate = `variables:
+  site: {required: true, pattern: "^[A-Za-z -]+$"}
+  role: {default: leaf}
+  uplink: {default: ""}
+---
+name: {{ quote (printf "Site %s" .site) }}
+tags:
+  - {label: site, value: {{ quote .site }}}
+assignments:
+  - {label: role, value: {{ .role }}, devices: {{ quote (printf "%s-*" (lower .site)) }}}
+{{- if .uplink }}
+  - {label: uplink, value: "true", elementType: interface, interface: {{ quote .uplink }}, devices: {{ quote (printf "%s-*" (lower .site)) }}}
+{{- end }}
+`
+
+func TestWorkspaceTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	if err := os.WriteFile(path, []byte(testTemplate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := []DeviceInfo{
+		{DeviceID: "SN1", Hostname: "paris-leaf-1"},
+		{DeviceID: "SN2", Hostname: "lyon-leaf-1"},
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    WorkspaceChanges
+		wantErr string
+	}{
+		{
+			name:   "valeurs par défaut",
+			values: map[string]string{"site": "Paris"},
+			want: WorkspaceChanges{
+				Tags:           []TagChange{{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device"}},
+				TagAssignments: []TagAssignmentChange{{Action: ChangeAdd, Label: "role", Value: "leaf", ElementType: "device", DeviceID: "SN1", Hostname: "paris-leaf-1"}},
+			},
+		},
+		{
+			name:   "tag d'interface",
+			values: map[string]string{"site": "Paris", "uplink": "Ethernet49"},
+			want: WorkspaceChanges{
+				Tags: []TagChange{{Action: ChangeAdd, Label: "site", Value: "Paris", ElementType: "device"}},
+				TagAssignments: []TagAssignmentChange{
+					{Action: ChangeAdd, Label: "role", Value: "leaf", ElementType: "device", DeviceID: "SN1", Hostname: "paris-leaf-1"},
+					{Action: ChangeAdd, Label: "uplink", Value: "true", ElementType: "interface", DeviceID: "SN1", Hostname: "paris-leaf-1", InterfaceID: "Ethernet49"},
+				},
+			},
+		},
+		{name: "variable obligatoire manquante", values: map[string]string{}, wantErr: "obligatoire manquante : site"},
+		{name: "variable inconnue", values: map[string]string{"site": "Paris", "pod": "1"}, wantErr: "variable inconnue : pod"},
+		{name: "motif non respecté", values: map[string]string{"site": `Paris"}`}, wantErr: "ne respecte pas le motif"},
+		{name: "aucun équipement", values: map[string]string{"site": "Nice"}, wantErr: "tag role=leaf"},
+	}
+	for _, tt := range tests {
+		changes, err := renderTemplate(tmpl, tt.values, devices)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s : erreur = %v, attendu %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s : %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(changes, tt.want) {
+			t.Errorf("%s : modifications = %+v, attendu %+v", tt.name, changes, tt.want)
+		}
+	}
+
+	for _, a := range []TemplateAssignment{
+		{Label: "uplink", Value: "true", ElementType: "interface"},
+		{Label: "role", Value: "leaf", Interface: "Ethernet1"},
+		{Label: "role", Value: "leaf", ElementType: "port"},
+	} {
+		if _, err := (TemplateBody{Assignments: []TemplateAssignment{a}}).Changes(devices, nil); err == nil {
+			t.Errorf("assignation %+v acceptée", a)
+		}
+	}
+
+	// quote protège le YAML rendu des caractères spéciaux d'une valeur.
+	tmpl.Variables["site"] = TemplateVariable{Required: true}
+	values, err := tmpl.Validate(map[string]string{"site": `Saint-Denis: "Nord" #1`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := tmpl.Render(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `Site Saint-Denis: "Nord" #1`; body.Name != want {
+		t.Errorf("nom rendu = %q, attendu %q", body.Name, want)
+	}
+}
+
+// renderTemplate valide les valeurs, rend le template et résout ses opérations sur devices.
+func renderTemplate(tmpl *WorkspaceTemplate, values map[string]string, devices []DeviceInfo) (WorkspaceChanges, error) {
+	resolved, err := tmpl.Validate(values)
+	if err != nil {
+		return WorkspaceChanges{}, err
+	}
+	body, err := tmpl.Render(resolved)
+	if err != nil {
+		return WorkspaceChanges{}, err
+	}
+	return body.Changes(devices, nil)
+}
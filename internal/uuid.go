@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewUUID génère un identifiant aléatoire au format UUID v4 (RFC 4122).
+//
+// Utilisé pour les IDs de workspace et les requestID : contrairement à un
+// horodatage à la seconde, deux appels successifs ne peuvent pas entrer en collision.
+//
+// Panique :
+//   - Si la source d'aléa du système est indisponible.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("❌ Erreur génération UUID : %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StepStatus est l'état d'une étape d'un workflow.
+type StepStatus string
+
+const (
+	StepOK      StepStatus = "ok"
+	StepFailed  StepStatus = "failed"
+	StepSkipped StepStatus = "skipped"
+	// StepRunning n'apparaît que dans les journaux d'exécution : l'étape a commencé
+	// mais son issue n'a pas été enregistrée (interruption).
+	StepRunning StepStatus = "running"
+)
+
+// ErrStepSkipped, retournée par Step.Run, marque l'étape comme ignorée sans
+// interrompre le workflow (ex : condition non remplie).
+var ErrStepSkipped = errors.New("étape ignorée")
+
+// Step est une étape d'un workflow. Run retourne un court détail du résultat
+// (ex : l'ID du workspace créé) ; une panique est traitée comme un échec.
+type Step struct {
+	Name string
+	Run  func() (string, error)
+}
+
+// StepReport est le résultat d'une étape d'un workflow.
+type StepReport struct {
+	Name     string        `json:"name"`
+	Status   StepStatus    `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RunSteps exécute les étapes dans l'ordre et s'arrête au premier échec ; les étapes
+// suivantes sont marquées comme ignorées. onStep, s'il n'est pas nil, est appelé après
+// chaque étape exécutée ou ignorée, avec son index.
+//
+// Retourne :
+//   - []StepReport : un rapport par étape, dans l'ordre.
+//   - error : l'erreur de l'étape en échec, nil si toutes ont réussi.
+func RunSteps(steps []Step, onStep func(int, StepReport)) ([]StepReport, error) {
+	reports := make([]StepReport, 0, len(steps))
+	var failure error
+	for i, step := range steps {
+		report := StepReport{Name: step.Name, Status: StepSkipped}
+		if failure == nil {
+			start := time.Now()
+			detail, err := runStep(step)
+			report.Duration = time.Since(start)
+			report.Detail = detail
+			report.Status = StepOK
+			if errors.Is(err, ErrStepSkipped) {
+				report.Status = StepSkipped
+			} else if err != nil {
+				report.Status = StepFailed
+				report.Error = err.Error()
+				failure = fmt.Errorf("%s : %w", step.Name, err)
+			}
+		}
+		reports = append(reports, report)
+		if onStep != nil {
+			onStep(i, report)
+		}
+	}
+	return reports, failure
+}
+
+// runStep exécute une étape en convertissant une panique en erreur.
+func runStep(step Step) (detail string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return step.Run()
+}